@@ -0,0 +1,426 @@
+package r9e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFlushBatchSize is the default number of pending writes that triggers a flush
+	// in write-behind mode.
+	DefaultFlushBatchSize = 100
+
+	// DefaultFlushInterval is the default interval between the flushes in write-behind mode.
+	DefaultFlushInterval = time.Second
+
+	// DefaultFlushAttempts is the default number of times a write is attempted in each flush.
+	DefaultFlushAttempts = 3
+
+	// DefaultFlushBackoff is the default delay before retrying a failed write, doubled after each attempt.
+	DefaultFlushBackoff = 100 * time.Millisecond
+)
+
+// WriteMode defines when the writes of a BackedMapKeyValue container reach the BackingStore.
+type WriteMode int
+
+const (
+	// WriteThrough stores every write in the BackingStore before it is applied to the container,
+	// so a failed write changes nothing. This is the default mode.
+	WriteThrough WriteMode = iota
+
+	// WriteBehind applies the writes to the container and stores them later in the BackingStore,
+	// in batches. The writes of the same key are coalesced, only the last one is stored.
+	WriteBehind
+)
+
+type backedMapKeyValueOptions struct {
+	mode         WriteMode
+	batchSize    int
+	interval     time.Duration
+	attempts     int
+	backoff      time.Duration
+	errorHandler func(error)
+	cacheOptions []MapKeyValueOptions
+}
+
+// BackedMapKeyValueOptions are the options for BackedMapKeyValue.
+type BackedMapKeyValueOptions func(*backedMapKeyValueOptions)
+
+// WithWriteMode sets the WriteMode of the container. The default value is WriteThrough.
+func WithWriteMode(mode WriteMode) BackedMapKeyValueOptions {
+	return func(bo *backedMapKeyValueOptions) {
+		bo.mode = mode
+	}
+}
+
+// WithFlushBatchSize sets the number of pending writes that triggers a flush in write-behind mode.
+// The default value is DefaultFlushBatchSize.
+func WithFlushBatchSize(size int) BackedMapKeyValueOptions {
+	return func(bo *backedMapKeyValueOptions) {
+		bo.batchSize = size
+	}
+}
+
+// WithFlushInterval sets the interval between the flushes in write-behind mode.
+// The default value is DefaultFlushInterval.
+func WithFlushInterval(interval time.Duration) BackedMapKeyValueOptions {
+	return func(bo *backedMapKeyValueOptions) {
+		bo.interval = interval
+	}
+}
+
+// WithFlushRetry sets the number of times a write is attempted in each flush, and the delay
+// before retrying the failed writes together, doubled after each attempt. The writes still
+// failing are kept pending and retried in the next flush. The default values are DefaultFlushAttempts and DefaultFlushBackoff.
+func WithFlushRetry(attempts int, backoff time.Duration) BackedMapKeyValueOptions {
+	return func(bo *backedMapKeyValueOptions) {
+		bo.attempts = attempts
+		bo.backoff = backoff
+	}
+}
+
+// WithFlushErrorHandler sets the function called with the error of the flushes done in the
+// background in write-behind mode. By default, the errors are ignored and the failed writes
+// are retried in the next flush.
+func WithFlushErrorHandler(fn func(err error)) BackedMapKeyValueOptions {
+	return func(bo *backedMapKeyValueOptions) {
+		bo.errorHandler = fn
+	}
+}
+
+// WithBackedCacheOptions sets the options of the MapKeyValue container in front of the BackingStore,
+// like WithDefaultTTL or WithMaxEntries.
+func WithBackedCacheOptions(options ...MapKeyValueOptions) BackedMapKeyValueOptions {
+	return func(bo *backedMapKeyValueOptions) {
+		bo.cacheOptions = append(bo.cacheOptions, options...)
+	}
+}
+
+// BackedMapKeyValue is a MapKeyValue container in front of a slower BackingStore.
+// The keys missing in the container are loaded from the store, and the writes are stored
+// synchronously or in the background depending on the WriteMode.
+type BackedMapKeyValue[K comparable, T any] struct {
+	kv    *MapKeyValue[K, T]
+	store BackingStore[K, T]
+
+	mode         WriteMode
+	batchSize    int
+	attempts     int
+	backoff      time.Duration
+	errorHandler func(error)
+
+	// mu protects the pending writes and the changes of the container, seq counts the writes.
+	mu       sync.Mutex
+	seq      uint64
+	pending  map[K]pendingWrite[T]
+	flushing map[K]pendingWrite[T]
+
+	// locks serializes the writes of each key in write-through mode, so they reach the store
+	// and the container in the same order without holding mu during the store calls.
+	locks map[K]*keyLock
+
+	// flushMu serializes the flushes.
+	flushMu sync.Mutex
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	// closeOnce runs the shutdown of the first Close.
+	closeOnce sync.Once
+}
+
+// keyLock is the lock of a key, removed when nobody holds or waits for it.
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// pendingWrite is a write not yet stored in the BackingStore.
+type pendingWrite[T any] struct {
+	value   T
+	deleted bool
+}
+
+// NewBackedMapKeyValue returns a new BackedMapKeyValue container in front of the store.
+// In write-behind mode, a goroutine flushes the pending writes until Close is called.
+func NewBackedMapKeyValue[K comparable, T any](store BackingStore[K, T], options ...BackedMapKeyValueOptions) *BackedMapKeyValue[K, T] {
+	bo := backedMapKeyValueOptions{
+		mode:      WriteThrough,
+		batchSize: DefaultFlushBatchSize,
+		interval:  DefaultFlushInterval,
+		attempts:  DefaultFlushAttempts,
+		backoff:   DefaultFlushBackoff,
+	}
+	for _, opt := range options {
+		opt(&bo)
+	}
+
+	r := &BackedMapKeyValue[K, T]{
+		kv:           NewMapKeyValue[K, T](bo.cacheOptions...),
+		store:        store,
+		mode:         bo.mode,
+		batchSize:    max(bo.batchSize, 1),
+		attempts:     max(bo.attempts, 1),
+		backoff:      bo.backoff,
+		errorHandler: bo.errorHandler,
+		pending:      make(map[K]pendingWrite[T]),
+		locks:        make(map[K]*keyLock),
+	}
+
+	if r.mode == WriteBehind {
+		r.wake = make(chan struct{}, 1)
+		r.done = make(chan struct{})
+		r.stopped = make(chan struct{})
+		go r.flusher(bo.interval)
+	}
+	return r
+}
+
+// Get returns the value associated with the key, loading it from the store if it is not in the container.
+// If the key doesn't exist, ErrNotFound is returned.
+func (r *BackedMapKeyValue[K, T]) Get(ctx context.Context, key K) (T, error) {
+	if value, ok := r.kv.GetAndCheck(key); ok {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	if w, ok := r.lookupPending(key); ok {
+		r.mu.Unlock()
+		if w.deleted {
+			return w.value, ErrNotFound
+		}
+		return w.value, nil
+	}
+	seq := r.seq
+	r.mu.Unlock()
+
+	value, err := r.store.Load(ctx, key)
+	if err != nil {
+		return value, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the loaded value is stale if there was a write meanwhile
+	if r.seq == seq {
+		r.kv.Set(key, value)
+	}
+	return value, nil
+}
+
+// Set stores the value associated with the key.
+// In write-through mode, the error of the store is returned and the container is not changed.
+// The writes of different keys are stored concurrently.
+func (r *BackedMapKeyValue[K, T]) Set(ctx context.Context, key K, value T) error {
+	return r.apply(ctx, key, pendingWrite[T]{value: value})
+}
+
+// Delete deletes the key.
+// In write-through mode, the error of the store is returned and the container is not changed.
+// The writes of different keys are stored concurrently.
+func (r *BackedMapKeyValue[K, T]) Delete(ctx context.Context, key K) error {
+	return r.apply(ctx, key, pendingWrite[T]{deleted: true})
+}
+
+// apply stores the write in the BackingStore, or queues it in write-behind mode, and applies it
+// to the container.
+func (r *BackedMapKeyValue[K, T]) apply(ctx context.Context, key K, w pendingWrite[T]) error {
+	if r.mode == WriteThrough {
+		unlock := r.lockKey(key)
+		defer unlock()
+
+		if err := r.write(ctx, key, w); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == WriteBehind {
+		r.enqueue(key, w)
+	}
+
+	r.seq++
+	if w.deleted {
+		r.kv.Delete(key)
+	} else {
+		r.kv.Set(key, w.value)
+	}
+	return nil
+}
+
+// lockKey locks the key and returns the function unlocking it.
+func (r *BackedMapKeyValue[K, T]) lockKey(key K) func() {
+	r.mu.Lock()
+	l, ok := r.locks[key]
+	if !ok {
+		l = &keyLock{}
+		r.locks[key] = l
+	}
+	l.refs++
+	r.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		l.refs--
+		if l.refs == 0 {
+			delete(r.locks, key)
+		}
+	}
+}
+
+// Flush stores the pending writes in the BackingStore, retrying the failed ones as set by WithFlushRetry.
+// The writes still failing are kept pending, and their errors are returned joined.
+// In write-through mode, there is nothing to flush.
+func (r *BackedMapKeyValue[K, T]) Flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	batch := r.pending
+	if len(batch) == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	r.pending = make(map[K]pendingWrite[T])
+	r.flushing = batch
+	r.mu.Unlock()
+
+	failed, err := r.writeAll(ctx, batch)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the failed writes are kept unless the key was written again meanwhile
+	for key, w := range failed {
+		if _, ok := r.pending[key]; !ok {
+			r.pending[key] = w
+		}
+	}
+	r.flushing = nil
+	return err
+}
+
+// Pending returns the number of writes not yet stored in the BackingStore.
+func (r *BackedMapKeyValue[K, T]) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pending)
+}
+
+// Cache returns the MapKeyValue container in front of the BackingStore.
+// The changes done directly to it are not stored.
+func (r *BackedMapKeyValue[K, T]) Cache() *MapKeyValue[K, T] {
+	return r.kv
+}
+
+// Close stops the flushing goroutine, drains the pending writes to the BackingStore and closes
+// the container. Returns the errors of the writes that couldn't be stored, which are kept
+// pending and can be retried using Flush. The calls after the first one do nothing.
+func (r *BackedMapKeyValue[K, T]) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		if r.done != nil {
+			close(r.done)
+			<-r.stopped
+		}
+
+		err = errors.Join(r.Flush(context.Background()), r.kv.Close())
+	})
+	return err
+}
+
+// flusher flushes the pending writes periodically, or when there are enough of them,
+// until the container is closed.
+func (r *BackedMapKeyValue[K, T]) flusher(interval time.Duration) {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.wake:
+		case <-r.done:
+			return
+		}
+
+		if err := r.Flush(context.Background()); err != nil && r.errorHandler != nil {
+			r.errorHandler(err)
+		}
+	}
+}
+
+// enqueue adds a pending write, replacing the previous one of the key, and wakes up the
+// flusher when the batch is full.
+// The caller must hold the lock.
+func (r *BackedMapKeyValue[K, T]) enqueue(key K, w pendingWrite[T]) {
+	r.pending[key] = w
+	if len(r.pending) < r.batchSize {
+		return
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// lookupPending returns the last write of the key not yet stored in the BackingStore.
+// The caller must hold the lock.
+func (r *BackedMapKeyValue[K, T]) lookupPending(key K) (pendingWrite[T], bool) {
+	if w, ok := r.pending[key]; ok {
+		return w, true
+	}
+	w, ok := r.flushing[key]
+	return w, ok
+}
+
+// writeAll stores the batch of pending writes in the BackingStore, retrying the failed ones
+// together with exponential backoff. Returns the writes still failing and their errors joined.
+func (r *BackedMapKeyValue[K, T]) writeAll(ctx context.Context, batch map[K]pendingWrite[T]) (map[K]pendingWrite[T], error) {
+	backoff := r.backoff
+
+	for attempt := 1; ; attempt++ {
+		failed := make(map[K]pendingWrite[T])
+		var errs []error
+		for key, w := range batch {
+			if err := r.write(ctx, key, w); err != nil {
+				failed[key] = w
+				errs = append(errs, fmt.Errorf("r9e: flushing key %v: %w", key, err))
+			}
+		}
+		if len(failed) == 0 || attempt == r.attempts {
+			return failed, errors.Join(errs...)
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+			batch = failed
+		case <-ctx.Done():
+			return failed, errors.Join(append(errs, ctx.Err())...)
+		}
+	}
+}
+
+// write stores the write in the BackingStore.
+func (r *BackedMapKeyValue[K, T]) write(ctx context.Context, key K, w pendingWrite[T]) error {
+	if w.deleted {
+		return r.store.Delete(ctx, key)
+	}
+	return r.store.Store(ctx, key, w.value)
+}
@@ -0,0 +1,367 @@
+package r9e
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyStore is a MemoryStore failing the next writes and counting them.
+type flakyStore[K comparable, T any] struct {
+	*MemoryStore[K, T]
+	failures atomic.Int32
+	writes   atomic.Int32
+}
+
+var errFlakyStore = errors.New("store is down")
+
+func (s *flakyStore[K, T]) Store(ctx context.Context, key K, value T) error {
+	s.writes.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		return errFlakyStore
+	}
+	return s.MemoryStore.Store(ctx, key, value)
+}
+
+func (s *flakyStore[K, T]) Delete(ctx context.Context, key K) error {
+	s.writes.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		return errFlakyStore
+	}
+	return s.MemoryStore.Delete(ctx, key)
+}
+
+func newFlakyStore[K comparable, T any]() *flakyStore[K, T] {
+	return &flakyStore[K, T]{MemoryStore: NewMemoryStore[K, T]()}
+}
+
+// blockingStore is a MemoryStore whose write of the key "slow" waits until release is closed.
+type blockingStore[T any] struct {
+	*MemoryStore[string, T]
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore[T]) Store(ctx context.Context, key string, value T) error {
+	if key == "slow" {
+		close(s.started)
+		<-s.release
+	}
+	return s.MemoryStore.Store(ctx, key, value)
+}
+
+func TestWriteThrough_BackedMapKeyValue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("test Set and Delete write to the store", func(t *testing.T) {
+		store := NewMemoryStore[string, int]()
+		kv := NewBackedMapKeyValue[string, int](store)
+		defer kv.Close()
+
+		kv.Set(ctx, "one", 1)
+		if value, err := store.Load(ctx, "one"); err != nil || value != 1 {
+			t.Errorf("Expected value to be %v, got %v %v", 1, value, err)
+		}
+
+		kv.Delete(ctx, "one")
+		if _, err := store.Load(ctx, "one"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error to be %v, got %v", ErrNotFound, err)
+		}
+		if kv.Cache().ContainsKey("one") {
+			t.Errorf("Expected key %v to be deleted", "one")
+		}
+	})
+
+	t.Run("test a failed write doesn't change the container", func(t *testing.T) {
+		store := newFlakyStore[string, int]()
+		kv := NewBackedMapKeyValue[string, int](store)
+		defer kv.Close()
+
+		kv.Set(ctx, "one", 1)
+		store.failures.Store(1)
+
+		if err := kv.Set(ctx, "one", 2); !errors.Is(err, errFlakyStore) {
+			t.Errorf("Expected error to be %v, got %v", errFlakyStore, err)
+		}
+		if value, _ := kv.Get(ctx, "one"); value != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, value)
+		}
+	})
+
+	t.Run("test a slow write doesn't block the other keys", func(t *testing.T) {
+		store := &blockingStore[int]{MemoryStore: NewMemoryStore[string, int](), started: make(chan struct{}), release: make(chan struct{})}
+		kv := NewBackedMapKeyValue[string, int](store)
+		defer kv.Close()
+
+		done := make(chan error)
+		go func() {
+			done <- kv.Set(ctx, "slow", 1)
+		}()
+		<-store.started
+
+		withoutDeadlock(t, func() {
+			kv.Set(ctx, "fast", 2)
+			if value, err := kv.Get(ctx, "fast"); err != nil || value != 2 {
+				t.Errorf("Expected value to be %v, got %v %v", 2, value, err)
+			}
+		})
+		if kv.Cache().ContainsKey("slow") {
+			t.Errorf("Expected key %v not to be cached before it is stored", "slow")
+		}
+
+		close(store.release)
+		if err := <-done; err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+		if value, _ := kv.Get(ctx, "slow"); value != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, value)
+		}
+	})
+
+	t.Run("test the concurrent writes of a key keep the container and the store equal", func(t *testing.T) {
+		store := NewMemoryStore[string, int]()
+		kv := NewBackedMapKeyValue[string, int](store)
+		defer kv.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if j%10 == 0 {
+						kv.Delete(ctx, "key")
+						continue
+					}
+					kv.Set(ctx, "key", i*100+j)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		stored, err := store.Load(ctx, "key")
+		cached, ok := kv.Cache().GetAndCheck("key")
+		if (err == nil) != ok || stored != cached {
+			t.Errorf("Expected cached value to be %v %v, got %v %v", stored, err, cached, ok)
+		}
+	})
+
+	t.Run("test Get loads the missing keys from the store", func(t *testing.T) {
+		store := NewMemoryStore[string, int]()
+		store.Store(ctx, "one", 1)
+
+		kv := NewBackedMapKeyValue[string, int](store)
+		defer kv.Close()
+
+		if value, err := kv.Get(ctx, "one"); err != nil || value != 1 {
+			t.Errorf("Expected value to be %v, got %v %v", 1, value, err)
+		}
+		if !kv.Cache().ContainsKey("one") {
+			t.Errorf("Expected key %v to be cached", "one")
+		}
+		if _, err := kv.Get(ctx, "two"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error to be %v, got %v", ErrNotFound, err)
+		}
+	})
+}
+
+func TestWriteBehind_BackedMapKeyValue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("test Flush coalesces the writes", func(t *testing.T) {
+		store := newFlakyStore[string, int]()
+		kv := NewBackedMapKeyValue[string, int](store, WithWriteMode(WriteBehind), WithFlushInterval(time.Hour))
+		defer kv.Close()
+
+		for i := 0; i < 10; i++ {
+			kv.Set(ctx, "counter", i)
+		}
+		kv.Set(ctx, "deleted", 1)
+		kv.Delete(ctx, "deleted")
+
+		if store.Len() != 0 {
+			t.Errorf("Expected store size to be %v, got %v", 0, store.Len())
+		}
+		if kv.Pending() != 2 {
+			t.Errorf("Expected pending to be %v, got %v", 2, kv.Pending())
+		}
+
+		if err := kv.Flush(ctx); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+		if store.writes.Load() != 2 {
+			t.Errorf("Expected writes to be %v, got %v", 2, store.writes.Load())
+		}
+		if value, _ := store.Load(ctx, "counter"); value != 9 {
+			t.Errorf("Expected value to be %v, got %v", 9, value)
+		}
+		if kv.Pending() != 0 {
+			t.Errorf("Expected pending to be %v, got %v", 0, kv.Pending())
+		}
+	})
+
+	t.Run("test the pending writes hide the store", func(t *testing.T) {
+		store := NewMemoryStore[string, int]()
+		store.Store(ctx, "one", 1)
+		store.Store(ctx, "two", 2)
+
+		kv := NewBackedMapKeyValue[string, int](store, WithWriteMode(WriteBehind), WithFlushInterval(time.Hour),
+			WithBackedCacheOptions(WithMaxEntries(1)))
+		defer kv.Close()
+
+		kv.Delete(ctx, "one")
+		kv.Set(ctx, "two", 20)
+		kv.Set(ctx, "three", 3) // evicts two from the container
+
+		if _, err := kv.Get(ctx, "one"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error to be %v, got %v", ErrNotFound, err)
+		}
+		if value, _ := kv.Get(ctx, "two"); value != 20 {
+			t.Errorf("Expected value to be %v, got %v", 20, value)
+		}
+	})
+
+	t.Run("test the batch size triggers a flush", func(t *testing.T) {
+		store := NewMemoryStore[int, int]()
+		kv := NewBackedMapKeyValue[int, int](store, WithWriteMode(WriteBehind), WithFlushInterval(time.Hour), WithFlushBatchSize(10))
+		defer kv.Close()
+
+		for i := 0; i < 10; i++ {
+			kv.Set(ctx, i, i)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for store.Len() < 10 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if store.Len() != 10 {
+			t.Errorf("Expected store size to be %v, got %v", 10, store.Len())
+		}
+	})
+
+	t.Run("test the interval triggers a flush", func(t *testing.T) {
+		store := NewMemoryStore[int, int]()
+		kv := NewBackedMapKeyValue[int, int](store, WithWriteMode(WriteBehind), WithFlushInterval(5*time.Millisecond))
+		defer kv.Close()
+
+		kv.Set(ctx, 1, 1)
+
+		deadline := time.Now().Add(time.Second)
+		for store.Len() < 1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if store.Len() != 1 {
+			t.Errorf("Expected store size to be %v, got %v", 1, store.Len())
+		}
+	})
+
+	t.Run("test Flush retries the failed writes", func(t *testing.T) {
+		store := newFlakyStore[string, int]()
+		kv := NewBackedMapKeyValue[string, int](store, WithWriteMode(WriteBehind), WithFlushInterval(time.Hour),
+			WithFlushRetry(3, time.Millisecond))
+		defer kv.Close()
+
+		kv.Set(ctx, "one", 1)
+		store.failures.Store(2)
+
+		if err := kv.Flush(ctx); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+		if store.writes.Load() != 3 {
+			t.Errorf("Expected writes to be %v, got %v", 3, store.writes.Load())
+		}
+		if value, _ := store.Load(ctx, "one"); value != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, value)
+		}
+	})
+
+	t.Run("test Flush retries the failed writes together", func(t *testing.T) {
+		store := newFlakyStore[int, int]()
+		kv := NewBackedMapKeyValue[int, int](store, WithWriteMode(WriteBehind), WithFlushInterval(time.Hour),
+			WithFlushRetry(2, 50*time.Millisecond))
+		defer kv.Close()
+
+		for i := 0; i < 10; i++ {
+			kv.Set(ctx, i, i)
+		}
+		store.failures.Store(10)
+
+		start := time.Now()
+		if err := kv.Flush(ctx); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+
+		// the second attempts of every write wait for a single delay
+		if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+			t.Errorf("Expected flush to take less than %v, got %v", 250*time.Millisecond, elapsed)
+		}
+		if store.writes.Load() != 20 || store.Len() != 10 {
+			t.Errorf("Expected writes and store size to be %v and %v, got %v and %v", 20, 10, store.writes.Load(), store.Len())
+		}
+	})
+
+	t.Run("test Flush keeps the writes still failing", func(t *testing.T) {
+		store := newFlakyStore[string, int]()
+		kv := NewBackedMapKeyValue[string, int](store, WithWriteMode(WriteBehind), WithFlushInterval(time.Hour),
+			WithFlushRetry(2, time.Millisecond))
+		defer kv.Close()
+
+		kv.Set(ctx, "one", 1)
+		store.failures.Store(2)
+
+		if err := kv.Flush(ctx); !errors.Is(err, errFlakyStore) {
+			t.Errorf("Expected error to be %v, got %v", errFlakyStore, err)
+		}
+		if kv.Pending() != 1 {
+			t.Errorf("Expected pending to be %v, got %v", 1, kv.Pending())
+		}
+
+		if err := kv.Flush(ctx); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+		if store.Len() != 1 {
+			t.Errorf("Expected store size to be %v, got %v", 1, store.Len())
+		}
+	})
+
+	t.Run("test Close drains the pending writes", func(t *testing.T) {
+		store, err := NewFileStore[string, string](t.TempDir())
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		kv := NewBackedMapKeyValue[string, string](store, WithWriteMode(WriteBehind), WithFlushInterval(time.Hour))
+		kv.Set(ctx, "alice", "admin")
+		kv.Set(ctx, "bob", "user")
+
+		if err := kv.Close(); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+
+		reopened := NewBackedMapKeyValue[string, string](store)
+		defer reopened.Close()
+
+		if value, err := reopened.Get(ctx, "bob"); err != nil || value != "user" {
+			t.Errorf("Expected value to be %v, got %v %v", "user", value, err)
+		}
+	})
+	t.Run("test concurrent Close calls shut down the container once", func(t *testing.T) {
+		kv := NewBackedMapKeyValue[string, string](NewMemoryStore[string, string](), WithWriteMode(WriteBehind))
+		kv.Set(ctx, "alice", "admin")
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := kv.Close(); err != nil {
+					t.Errorf("Expected error to be %v, got %v", nil, err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,162 @@
+package r9e
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BackingStore is the slower store behind a BackedMapKeyValue container, like a database or a
+// remote service. Load returns ErrNotFound, or an error wrapping it, when the key doesn't exist,
+// and Delete doesn't fail when the key doesn't exist.
+type BackingStore[K comparable, T any] interface {
+	Load(ctx context.Context, key K) (T, error)
+	Store(ctx context.Context, key K, value T) error
+	Delete(ctx context.Context, key K) error
+}
+
+var (
+	_ BackingStore[string, any] = (*MemoryStore[string, any])(nil)
+	_ BackingStore[string, any] = (*FileStore[string, any])(nil)
+)
+
+// MemoryStore is a BackingStore keeping the key-value pairs in memory, useful for tests.
+type MemoryStore[K comparable, T any] struct {
+	mu   sync.RWMutex
+	data map[K]T
+}
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore[K comparable, T any]() *MemoryStore[K, T] {
+	return &MemoryStore[K, T]{
+		data: make(map[K]T),
+	}
+}
+
+// Load returns the value associated with the key, or ErrNotFound if the key doesn't exist.
+func (s *MemoryStore[K, T]) Load(ctx context.Context, key K) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.data[key]
+	if !ok {
+		return value, ErrNotFound
+	}
+	return value, nil
+}
+
+// Store stores the value associated with the key.
+func (s *MemoryStore[K, T]) Store(ctx context.Context, key K, value T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = value
+	return nil
+}
+
+// Delete deletes the key.
+func (s *MemoryStore[K, T]) Delete(ctx context.Context, key K) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, key)
+	return nil
+}
+
+// Len returns the number of key-value pairs stored.
+func (s *MemoryStore[K, T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.data)
+}
+
+// FileStore is a BackingStore keeping each key-value pair in a file of a directory.
+// The file is named after the SHA-256 of the encoded key and holds the encoded value,
+// and it is replaced atomically when the value is stored.
+type FileStore[K comparable, T any] struct {
+	dir    string
+	codecs snapshotOptions
+}
+
+// NewFileStore returns a FileStore storing the key-value pairs in the directory dir, which is created
+// if it doesn't exist. The keys and values are encoded using the codecs set by WithKeyCodec and
+// WithValueCodec, GobCodec by default.
+func NewFileStore[K comparable, T any](dir string, options ...SnapshotOptions) (*FileStore[K, T], error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &FileStore[K, T]{
+		dir:    dir,
+		codecs: newSnapshotOptions(options...),
+	}, nil
+}
+
+// Load returns the value associated with the key, or ErrNotFound if the key doesn't exist.
+func (s *FileStore[K, T]) Load(ctx context.Context, key K) (T, error) {
+	var value T
+
+	path, err := s.path(key)
+	if err != nil {
+		return value, err
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return value, ErrNotFound
+	}
+	if err != nil {
+		return value, err
+	}
+
+	err = s.codecs.valueCodec.Unmarshal(data, &value)
+	return value, err
+}
+
+// Store stores the value associated with the key.
+func (s *FileStore[K, T]) Store(ctx context.Context, key K, value T) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.codecs.valueCodec.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Delete deletes the key.
+func (s *FileStore[K, T]) Delete(ctx context.Context, key K) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the path of the file holding the key.
+func (s *FileStore[K, T]) path(key K) (string, error) {
+	data, err := s.codecs.keyCodec.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])), nil
+}
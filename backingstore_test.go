@@ -0,0 +1,66 @@
+package r9e
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBackingStore(t *testing.T) {
+	fileStore, err := NewFileStore[string, backingStoreTestStruct](t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected error to be %v, got %v", nil, err)
+	}
+
+	jsonFileStore, err := NewFileStore[string, backingStoreTestStruct](t.TempDir(), WithKeyCodec(JSONCodec{}), WithValueCodec(JSONCodec{}))
+	if err != nil {
+		t.Fatalf("Expected error to be %v, got %v", nil, err)
+	}
+
+	stores := map[string]BackingStore[string, backingStoreTestStruct]{
+		"MemoryStore":         NewMemoryStore[string, backingStoreTestStruct](),
+		"FileStore":           fileStore,
+		"FileStore with JSON": jsonFileStore,
+	}
+
+	for name, store := range stores {
+		t.Run("test "+name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := store.Load(ctx, "alice"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected error to be %v, got %v", ErrNotFound, err)
+			}
+
+			alice := backingStoreTestStruct{Name: "Alice", Age: 30}
+			if err := store.Store(ctx, "alice", alice); err != nil {
+				t.Errorf("Expected error to be %v, got %v", nil, err)
+			}
+			if value, err := store.Load(ctx, "alice"); err != nil || value != alice {
+				t.Errorf("Expected value to be %v, got %v %v", alice, value, err)
+			}
+
+			alice.Age++
+			store.Store(ctx, "alice", alice)
+			if value, _ := store.Load(ctx, "alice"); value != alice {
+				t.Errorf("Expected value to be %v, got %v", alice, value)
+			}
+
+			if err := store.Delete(ctx, "alice"); err != nil {
+				t.Errorf("Expected error to be %v, got %v", nil, err)
+			}
+			if _, err := store.Load(ctx, "alice"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected error to be %v, got %v", ErrNotFound, err)
+			}
+
+			// deleting a missing key doesn't fail
+			if err := store.Delete(ctx, "alice"); err != nil {
+				t.Errorf("Expected error to be %v, got %v", nil, err)
+			}
+		})
+	}
+}
+
+type backingStoreTestStruct struct {
+	Name string
+	Age  int
+}
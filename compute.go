@@ -0,0 +1,294 @@
+package r9e
+
+import "time"
+
+// ComputeAction is returned by the function given to Compute to choose what to do with the key.
+type ComputeAction int
+
+const (
+	// ComputeSet stores the value returned by the function.
+	ComputeSet ComputeAction = iota
+
+	// ComputeDelete deletes the key.
+	ComputeDelete
+
+	// ComputeKeep leaves the key unchanged.
+	ComputeKeep
+)
+
+// GetOrSet returns the existing value associated with the key if this exist and true.
+// Otherwise, it sets the given value and returns it and false. A value refused by
+// WithRejectOversized is not set, and the zero value and false are returned, use SetIfAbsent
+// to know if the value was set.
+func (r *MapKeyValue[K, T]) GetOrSet(key K, value T) (actual T, loaded bool) {
+	actual, loaded, _ = r.getOrSet(key, value)
+	return actual, loaded
+}
+
+// SetIfAbsent sets the value associated with the key only if the key doesn't exist.
+// Returns true if the value was set, false if the key exist or the value was refused by
+// WithRejectOversized.
+func (r *MapKeyValue[K, T]) SetIfAbsent(key K, value T) bool {
+	_, _, stored := r.getOrSet(key, value)
+	return stored
+}
+
+// getOrSet returns the existing value associated with the key if this exist and true.
+// Otherwise, it sets the given value and returns it, false and true if it wasn't refused by
+// WithRejectOversized.
+func (r *MapKeyValue[K, T]) getOrSet(key K, value T) (actual T, loaded, stored bool) {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if actual, loaded := r.access(key); loaded {
+		return actual, true, false
+	}
+
+	if r.oversized(value) {
+		var empty T
+		return empty, false, false
+	}
+
+	r.set(key, value, r.deadline(r.defaultTTL))
+	return value, false, true
+}
+
+// SetIfPresent sets the value associated with the key only if the key exist, keeping its
+// expiration time. Returns true if the value was set.
+func (r *MapKeyValue[K, T]) SetIfPresent(key K, value T) bool {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.peek(key); !ok || r.oversized(value) {
+		return false
+	}
+
+	r.set(key, value, r.expiration(key, true))
+	return true
+}
+
+// CompareAndSwap sets the new value associated with the key only if the key exist and its
+// value is equal to old, using the given equality function, keeping its expiration time.
+// Returns true if the value was swapped.
+func (r *MapKeyValue[K, T]) CompareAndSwap(key K, old, new T, eq func(a, b T) bool) bool {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.peek(key); !ok || !eq(current, old) || r.oversized(new) {
+		return false
+	}
+
+	r.set(key, new, r.expiration(key, true))
+	return true
+}
+
+// CompareAndDelete deletes the key only if it exist and its value is equal to old,
+// using the given equality function.
+// Returns true if the key was deleted.
+func (r *MapKeyValue[K, T]) CompareAndDelete(key K, old T, eq func(a, b T) bool) bool {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.peek(key); !ok || !eq(current, old) {
+		return false
+	}
+
+	r.delete(key, OpDelete)
+	return true
+}
+
+// Compute calls fn with the value associated with the key, and if it exist, and sets, deletes or
+// keeps the key according to the returned action, in one atomic step. An existing key keeps
+// its expiration time, and a value refused by WithRejectOversized leaves the key unchanged.
+// Returns the value associated with the key after the change and true if the key exist.
+// A refused value returns the same as ComputeKeep, the old value and true if the key exist, so
+// the callers storing values that may be refused must check if the returned value is the one they returned.
+// fn must not use the container.
+func (r *MapKeyValue[K, T]) Compute(key K, fn func(old T, exists bool) (T, ComputeAction)) (T, bool) {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.peek(key)
+	value, action := fn(old, exists)
+
+	switch action {
+	case ComputeSet:
+		if r.oversized(value) {
+			return old, exists
+		}
+		r.set(key, value, r.expiration(key, exists))
+		return value, true
+	case ComputeDelete:
+		r.delete(key, OpDelete)
+		var empty T
+		return empty, false
+	default:
+		return old, exists
+	}
+}
+
+// expiration returns the expiration time of a value stored with the key by a conditional change:
+// the one of the key if it exist, or the default time to live otherwise.
+// The caller must hold the lock.
+func (r *MapKeyValue[K, T]) expiration(key K, exists bool) time.Time {
+	if exists {
+		return r.expires[key]
+	}
+	return r.deadline(r.defaultTTL)
+}
+
+// GetOrSet returns the existing value associated with the key if this exist and true.
+// Otherwise, it sets the given value and returns it and false.
+func (r *SMapKeyValue[K, T]) GetOrSet(key K, value T) (actual T, loaded bool) {
+	defer r.notify()
+
+	r.lockWrite()
+	defer r.unlockWrite()
+
+	r.count.Add(1)
+	if p, loaded := r.data().LoadOrStore(key, &value); loaded {
+		r.count.Add(^uint64(0))
+		r.stats.get(true)
+		return *p.(*T), true
+	}
+
+	r.seq.Add(1)
+	r.stats.get(false)
+	r.stats.set()
+	r.publishSet(key, nil, false, value)
+	return value, false
+}
+
+// SetIfAbsent sets the value associated with the key only if the key doesn't exist.
+// Returns true if the value was set.
+func (r *SMapKeyValue[K, T]) SetIfAbsent(key K, value T) bool {
+	_, loaded := r.GetOrSet(key, value)
+	return !loaded
+}
+
+// SetIfPresent sets the value associated with the key only if the key exist.
+// Returns true if the value was set.
+func (r *SMapKeyValue[K, T]) SetIfPresent(key K, value T) bool {
+	return r.CompareAndSwap(key, value, value, func(a, b T) bool {
+		return true
+	})
+}
+
+// CompareAndSwap sets the new value associated with the key only if the key exist and its
+// value is equal to old, using the given equality function.
+// Returns true if the value was swapped.
+func (r *SMapKeyValue[K, T]) CompareAndSwap(key K, old, new T, eq func(a, b T) bool) bool {
+	defer r.notify()
+
+	r.lockWrite()
+	defer r.unlockWrite()
+
+	for {
+		p, ok := r.data().Load(key)
+		if !ok || !eq(*p.(*T), old) {
+			return false
+		}
+
+		// the entries are compared by pointer, so the swap fails if the key was set meanwhile
+		if r.data().CompareAndSwap(key, p, &new) {
+			r.seq.Add(1)
+			r.stats.set()
+			r.publishSet(key, p, true, new)
+			return true
+		}
+	}
+}
+
+// CompareAndDelete deletes the key only if it exist and its value is equal to old,
+// using the given equality function.
+// Returns true if the key was deleted.
+func (r *SMapKeyValue[K, T]) CompareAndDelete(key K, old T, eq func(a, b T) bool) bool {
+	defer r.notify()
+
+	r.lockWrite()
+	defer r.unlockWrite()
+
+	for {
+		p, ok := r.data().Load(key)
+		if !ok || !eq(*p.(*T), old) {
+			return false
+		}
+
+		if r.data().CompareAndDelete(key, p) {
+			r.count.Add(^uint64(0))
+			r.seq.Add(1)
+			r.stats.remove(OpDelete)
+			r.publish(Event[K, T]{Op: OpDelete, Key: key, OldValue: *p.(*T), HasOldValue: true})
+			return true
+		}
+	}
+}
+
+// Compute calls fn with the value associated with the key, and if it exist, and sets, deletes or
+// keeps the key according to the returned action, in one atomic step.
+// Returns the value associated with the key after the change and true if the key exist.
+// fn is called again if the key is changed concurrently, so it must not have side effects.
+func (r *SMapKeyValue[K, T]) Compute(key K, fn func(old T, exists bool) (T, ComputeAction)) (T, bool) {
+	defer r.notify()
+
+	r.lockWrite()
+	defer r.unlockWrite()
+
+	for {
+		var old T
+		p, exists := r.data().Load(key)
+		if exists {
+			old = *p.(*T)
+		}
+
+		value, action := fn(old, exists)
+
+		switch action {
+		case ComputeSet:
+			if exists {
+				if !r.data().CompareAndSwap(key, p, &value) {
+					continue
+				}
+			} else {
+				r.count.Add(1)
+				if _, loaded := r.data().LoadOrStore(key, &value); loaded {
+					r.count.Add(^uint64(0))
+					continue
+				}
+			}
+
+			r.seq.Add(1)
+			r.stats.set()
+			r.publishSet(key, p, exists, value)
+			return value, true
+
+		case ComputeDelete:
+			if !exists {
+				return old, false
+			}
+			if !r.data().CompareAndDelete(key, p) {
+				continue
+			}
+
+			r.count.Add(^uint64(0))
+			r.seq.Add(1)
+			r.stats.remove(OpDelete)
+			r.publish(Event[K, T]{Op: OpDelete, Key: key, OldValue: old, HasOldValue: true})
+			var empty T
+			return empty, false
+
+		default:
+			return old, exists
+		}
+	}
+}
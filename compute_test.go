@@ -0,0 +1,197 @@
+package r9e
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rmwKeyValue is implemented by the containers with read-modify-write methods.
+type rmwKeyValue interface {
+	Get(key string) []int
+	Set(key string, value []int)
+	ContainsKey(key string) bool
+	Size() int
+	GetOrSet(key string, value []int) ([]int, bool)
+	SetIfAbsent(key string, value []int) bool
+	SetIfPresent(key string, value []int) bool
+	CompareAndSwap(key string, old, new []int, eq func(a, b []int) bool) bool
+	CompareAndDelete(key string, old []int, eq func(a, b []int) bool) bool
+	Compute(key string, fn func(old []int, exists bool) ([]int, ComputeAction)) ([]int, bool)
+}
+
+func TestCompute(t *testing.T) {
+	// slices aren't comparable, so the values must be compared using the equality function
+	eq := func(a, b []int) bool { return reflect.DeepEqual(a, b) }
+
+	containers := []struct {
+		name string
+		new  func() rmwKeyValue
+	}{
+		{"MapKeyValue", func() rmwKeyValue { return NewMapKeyValue[string, []int]() }},
+		{"SMapKeyValue", func() rmwKeyValue { return NewSMapKeyValue[string, []int]() }},
+	}
+
+	for _, c := range containers {
+		t.Run("test GetOrSet, SetIfAbsent and SetIfPresent for "+c.name, func(t *testing.T) {
+			kv := c.new()
+
+			if actual, loaded := kv.GetOrSet("a", []int{1}); loaded || !eq(actual, []int{1}) {
+				t.Errorf("Expected GetOrSet to be %v and %v, got %v and %v", []int{1}, false, actual, loaded)
+			}
+			if actual, loaded := kv.GetOrSet("a", []int{2}); !loaded || !eq(actual, []int{1}) {
+				t.Errorf("Expected GetOrSet to be %v and %v, got %v and %v", []int{1}, true, actual, loaded)
+			}
+			if kv.SetIfAbsent("a", []int{3}) || !kv.SetIfAbsent("b", []int{3}) {
+				t.Errorf("Expected SetIfAbsent to set only the missing key")
+			}
+			if kv.SetIfPresent("c", []int{4}) || !kv.SetIfPresent("b", []int{4}) {
+				t.Errorf("Expected SetIfPresent to set only the existing key")
+			}
+			if kv.ContainsKey("c") || !eq(kv.Get("b"), []int{4}) {
+				t.Errorf("Expected value to be %v, got %v", []int{4}, kv.Get("b"))
+			}
+		})
+
+		t.Run("test CompareAndSwap and CompareAndDelete for "+c.name, func(t *testing.T) {
+			kv := c.new()
+			kv.Set("a", []int{1, 2})
+
+			if kv.CompareAndSwap("a", []int{1}, []int{3}, eq) {
+				t.Errorf("Expected CompareAndSwap to be %v, got %v", false, true)
+			}
+			if !kv.CompareAndSwap("a", []int{1, 2}, []int{3}, eq) || !eq(kv.Get("a"), []int{3}) {
+				t.Errorf("Expected value to be %v, got %v", []int{3}, kv.Get("a"))
+			}
+			if kv.CompareAndSwap("missing", nil, []int{3}, eq) || kv.ContainsKey("missing") {
+				t.Errorf("Expected CompareAndSwap of a missing key to be %v, got %v", false, true)
+			}
+			if kv.CompareAndDelete("a", []int{1, 2}, eq) || !kv.ContainsKey("a") {
+				t.Errorf("Expected CompareAndDelete to be %v, got %v", false, true)
+			}
+			if !kv.CompareAndDelete("a", []int{3}, eq) || kv.ContainsKey("a") {
+				t.Errorf("Expected CompareAndDelete to be %v, got %v", true, false)
+			}
+			if kv.Size() != 0 {
+				t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+			}
+		})
+
+		t.Run("test Compute for "+c.name, func(t *testing.T) {
+			kv := c.new()
+
+			appendOne := func(old []int, exists bool) ([]int, ComputeAction) {
+				return append(append([]int(nil), old...), 1), ComputeSet
+			}
+
+			if value, ok := kv.Compute("a", appendOne); !ok || !eq(value, []int{1}) {
+				t.Errorf("Expected value to be %v, got %v", []int{1}, value)
+			}
+			if value, ok := kv.Compute("a", appendOne); !ok || !eq(value, []int{1, 1}) {
+				t.Errorf("Expected value to be %v, got %v", []int{1, 1}, value)
+			}
+
+			value, ok := kv.Compute("a", func(old []int, exists bool) ([]int, ComputeAction) {
+				return nil, ComputeKeep
+			})
+			if !ok || !eq(value, []int{1, 1}) {
+				t.Errorf("Expected value to be %v, got %v", []int{1, 1}, value)
+			}
+
+			_, ok = kv.Compute("a", func(old []int, exists bool) ([]int, ComputeAction) {
+				return nil, ComputeDelete
+			})
+			if ok || kv.ContainsKey("a") || kv.Size() != 0 {
+				t.Errorf("Expected key to be deleted")
+			}
+		})
+
+		t.Run("test concurrent Compute for "+c.name, func(t *testing.T) {
+			kv := c.new()
+
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 100; i++ {
+						kv.Compute("counter", func(old []int, exists bool) ([]int, ComputeAction) {
+							if !exists {
+								return []int{1}, ComputeSet
+							}
+							return []int{old[0] + 1}, ComputeSet
+						})
+					}
+				}()
+			}
+			wg.Wait()
+
+			if value := kv.Get("counter"); value[0] != 800 {
+				t.Errorf("Expected counter to be %v, got %v", 800, value[0])
+			}
+		})
+	}
+
+	t.Run("test the changes of an existing key keep its expiration for MapKeyValue", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int](WithDefaultTTL(time.Hour))
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("short", 1, time.Minute)
+		kv.Set("persisted", 1)
+		kv.Persist("persisted")
+
+		for name, change := range map[string]func(key string) bool{
+			"SetIfPresent": func(key string) bool { return kv.SetIfPresent(key, 2) },
+			"CompareAndSwap": func(key string) bool {
+				return kv.CompareAndSwap(key, kv.Get(key), kv.Get(key)+1, func(a, b int) bool { return a == b })
+			},
+			"Compute": func(key string) bool {
+				_, ok := kv.Compute(key, func(old int, exists bool) (int, ComputeAction) { return old + 1, ComputeSet })
+				return ok
+			},
+		} {
+			if !change("short") || !change("persisted") {
+				t.Errorf("Expected %v to change the keys", name)
+			}
+			if ttl, _ := kv.TTL("short"); ttl != time.Minute {
+				t.Errorf("Expected TTL after %v to be %v, got %v", name, time.Minute, ttl)
+			}
+			if ttl, _ := kv.TTL("persisted"); ttl != NoExpiration {
+				t.Errorf("Expected TTL after %v to be %v, got %v", name, NoExpiration, ttl)
+			}
+		}
+
+		kv.Compute("new", func(old int, exists bool) (int, ComputeAction) { return 1, ComputeSet })
+		if ttl, _ := kv.TTL("new"); ttl != time.Hour {
+			t.Errorf("Expected TTL to be %v, got %v", time.Hour, ttl)
+		}
+	})
+
+	t.Run("test the refused values are not reported as stored for MapKeyValue", func(t *testing.T) {
+		kv := NewMapKeyValue[string, []int](WithMaxCost(2), WithRejectOversized(), WithCostFunc(func(value []int) int { return len(value) }))
+		kv.Set("a", []int{1})
+
+		huge := []int{1, 2, 3}
+		if value, ok := kv.Compute("a", func(old []int, exists bool) ([]int, ComputeAction) { return huge, ComputeSet }); !ok || !eq(value, []int{1}) {
+			t.Errorf("Expected Compute to be %v and %v, got %v and %v", []int{1}, true, value, ok)
+		}
+		if value, ok := kv.Compute("b", func(old []int, exists bool) ([]int, ComputeAction) { return huge, ComputeSet }); ok || value != nil {
+			t.Errorf("Expected Compute to be %v and %v, got %v and %v", nil, false, value, ok)
+		}
+		if kv.SetIfPresent("a", huge) || kv.CompareAndSwap("a", []int{1}, huge, eq) {
+			t.Errorf("Expected SetIfPresent and CompareAndSwap to refuse the value")
+		}
+		if kv.SetIfAbsent("b", huge) {
+			t.Errorf("Expected SetIfAbsent to refuse the value")
+		}
+		if value, loaded := kv.GetOrSet("b", huge); loaded || value != nil {
+			t.Errorf("Expected GetOrSet to be %v and %v, got %v and %v", nil, false, value, loaded)
+		}
+		if kv.Size() != 1 || !eq(kv.Get("a"), []int{1}) {
+			t.Errorf("Expected value to be %v, got %v", []int{1}, kv.Get("a"))
+		}
+	})
+}
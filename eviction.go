@@ -0,0 +1,153 @@
+package r9e
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// EvictionPolicy decides which key-value pair is evicted when a bounded container is full.
+//
+// The container calls the methods of the policy holding its write lock, so the implementations
+// don't need to be thread-safe. Remove can be called with keys unknown by the policy.
+type EvictionPolicy[K comparable] interface {
+	// Add is called when a new key is stored in the container.
+	Add(key K)
+
+	// Access is called when an existing key is read or updated.
+	Access(key K)
+
+	// Remove is called when a key is deleted from the container.
+	Remove(key K)
+
+	// Evict removes from the policy and returns the key that must be evicted from the container.
+	// The returned key can be the last added one, when the policy rejects it.
+	Evict() (K, bool)
+
+	// Reset removes all the keys from the policy.
+	Reset()
+}
+
+// WithEvictionPolicy sets the function used to create the EvictionPolicy of a bounded
+// MapKeyValue container. The function receives the maximum number of entries set using WithMaxEntries.
+// When the container is bounded and no policy is given, NewLRUPolicy is used.
+//
+// Example:
+//
+//	kv := NewMapKeyValue[string, int](WithMaxEntries(1024), WithEvictionPolicy(NewARCPolicy[string]))
+func WithEvictionPolicy[K comparable](newPolicy func(capacity int) EvictionPolicy[K]) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.newPolicy = newPolicy
+	}
+}
+
+// newEvictionPolicy returns the eviction policy configured in the options, or a LRU policy if none was given.
+func newEvictionPolicy[K comparable](kvo mapKeyValueOptions) EvictionPolicy[K] {
+	if kvo.newPolicy == nil {
+		return NewLRUPolicy[K](kvo.maxEntries)
+	}
+
+	newPolicy, ok := kvo.newPolicy.(func(capacity int) EvictionPolicy[K])
+	if !ok {
+		var key K
+		panic(fmt.Sprintf("r9e: the eviction policy doesn't match the container key type %T", key))
+	}
+	return newPolicy(kvo.maxEntries)
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy[K comparable] struct {
+	ll    *list.List
+	items map[K]*list.Element
+}
+
+// NewLRUPolicy returns an EvictionPolicy that evicts the least recently used key.
+func NewLRUPolicy[K comparable](capacity int) EvictionPolicy[K] {
+	return &lruPolicy[K]{
+		ll:    list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+func (p *lruPolicy[K]) Add(key K) {
+	p.Access(key)
+}
+
+func (p *lruPolicy[K]) Access(key K) {
+	if e, ok := p.items[key]; ok {
+		p.ll.MoveToFront(e)
+		return
+	}
+	p.items[key] = p.ll.PushFront(key)
+}
+
+func (p *lruPolicy[K]) Remove(key K) {
+	if e, ok := p.items[key]; ok {
+		p.ll.Remove(e)
+		delete(p.items, key)
+	}
+}
+
+func (p *lruPolicy[K]) Evict() (K, bool) {
+	e := p.ll.Back()
+	if e == nil {
+		var empty K
+		return empty, false
+	}
+
+	key := p.ll.Remove(e).(K)
+	delete(p.items, key)
+	return key, true
+}
+
+func (p *lruPolicy[K]) Reset() {
+	p.ll.Init()
+	p.items = make(map[K]*list.Element)
+}
+
+// fifoPolicy evicts the oldest added key.
+type fifoPolicy[K comparable] struct {
+	ll    *list.List
+	items map[K]*list.Element
+}
+
+// NewFIFOPolicy returns an EvictionPolicy that evicts the oldest added key,
+// no matter how many times it was accessed.
+func NewFIFOPolicy[K comparable](capacity int) EvictionPolicy[K] {
+	return &fifoPolicy[K]{
+		ll:    list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+func (p *fifoPolicy[K]) Add(key K) {
+	if _, ok := p.items[key]; ok {
+		return
+	}
+	p.items[key] = p.ll.PushFront(key)
+}
+
+func (p *fifoPolicy[K]) Access(key K) {}
+
+func (p *fifoPolicy[K]) Remove(key K) {
+	if e, ok := p.items[key]; ok {
+		p.ll.Remove(e)
+		delete(p.items, key)
+	}
+}
+
+func (p *fifoPolicy[K]) Evict() (K, bool) {
+	e := p.ll.Back()
+	if e == nil {
+		var empty K
+		return empty, false
+	}
+
+	key := p.ll.Remove(e).(K)
+	delete(p.items, key)
+	return key, true
+}
+
+func (p *fifoPolicy[K]) Reset() {
+	p.ll.Init()
+	p.items = make(map[K]*list.Element)
+}
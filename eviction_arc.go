@@ -0,0 +1,181 @@
+package r9e
+
+import "container/list"
+
+// arcList identifies the list of the arcPolicy where a key is.
+type arcList uint8
+
+const (
+	arcT1 arcList = iota // resident keys seen once recently
+	arcT2                // resident keys seen at least twice recently
+	arcB1                // ghost keys evicted from T1
+	arcB2                // ghost keys evicted from T2
+)
+
+// arcEntry is the position of a key in the arcPolicy.
+type arcEntry struct {
+	list    arcList
+	element *list.Element
+}
+
+// arcPolicy implements the Adaptive Replacement Cache algorithm (Megiddo and Modha).
+// It balances recency (T1) and frequency (T2) adapting the target size of T1 using
+// the hits on the ghost lists B1 and B2.
+type arcPolicy[K comparable] struct {
+	capacity int
+	p        int // target size of T1
+	lists    [4]*list.List
+	items    map[K]*arcEntry
+
+	// the last added key, used to don't choose it as victim and to know if it was in B2
+	pending    K
+	hasPending bool
+	pendingB2  bool
+}
+
+// NewARCPolicy returns an EvictionPolicy that implements the Adaptive Replacement Cache algorithm.
+// It is scan resistant and self tuning between recency and frequency, keeping track of up to
+// capacity evicted keys.
+func NewARCPolicy[K comparable](capacity int) EvictionPolicy[K] {
+	p := &arcPolicy[K]{
+		capacity: capacity,
+		items:    make(map[K]*arcEntry),
+	}
+	for i := range p.lists {
+		p.lists[i] = list.New()
+	}
+	return p
+}
+
+// c returns the capacity of the cache, when it is unknown the number of resident keys is used.
+func (p *arcPolicy[K]) c() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return p.lists[arcT1].Len() + p.lists[arcT2].Len()
+}
+
+// move moves the key to the front of the given list.
+func (p *arcPolicy[K]) move(key K, entry *arcEntry, to arcList) {
+	p.lists[entry.list].Remove(entry.element)
+	entry.list = to
+	entry.element = p.lists[to].PushFront(key)
+}
+
+// drop removes the least recent key of the given ghost list.
+func (p *arcPolicy[K]) drop(from arcList) {
+	if e := p.lists[from].Back(); e != nil {
+		delete(p.items, p.lists[from].Remove(e).(K))
+	}
+}
+
+func (p *arcPolicy[K]) Add(key K) {
+	entry, ok := p.items[key]
+	if ok && (entry.list == arcT1 || entry.list == arcT2) {
+		p.Access(key)
+		return
+	}
+
+	c := p.c()
+	b1, b2 := p.lists[arcB1].Len(), p.lists[arcB2].Len()
+	p.pending, p.hasPending, p.pendingB2 = key, true, false
+
+	switch {
+	case ok && entry.list == arcB1:
+		delta := 1
+		if b1 > 0 && b2/b1 > delta {
+			delta = b2 / b1
+		}
+		p.p = p.p + delta
+		if p.p > c {
+			p.p = c
+		}
+		p.move(key, entry, arcT2)
+
+	case ok && entry.list == arcB2:
+		delta := 1
+		if b2 > 0 && b1/b2 > delta {
+			delta = b1 / b2
+		}
+		p.p = p.p - delta
+		if p.p < 0 {
+			p.p = 0
+		}
+		p.pendingB2 = true
+		p.move(key, entry, arcT2)
+
+	default:
+		t1 := p.lists[arcT1].Len()
+		if t1+b1 >= c && b1 > 0 {
+			p.drop(arcB1)
+		} else if t1+p.lists[arcT2].Len()+b1+b2 >= 2*c && b2 > 0 {
+			p.drop(arcB2)
+		}
+		p.items[key] = &arcEntry{list: arcT1, element: p.lists[arcT1].PushFront(key)}
+	}
+}
+
+func (p *arcPolicy[K]) Access(key K) {
+	entry, ok := p.items[key]
+	if !ok || entry.list == arcB1 || entry.list == arcB2 {
+		return
+	}
+	p.move(key, entry, arcT2)
+}
+
+func (p *arcPolicy[K]) Remove(key K) {
+	entry, ok := p.items[key]
+	if !ok {
+		return
+	}
+	p.lists[entry.list].Remove(entry.element)
+	delete(p.items, key)
+
+	if p.hasPending && p.pending == key {
+		p.hasPending = false
+	}
+}
+
+func (p *arcPolicy[K]) Evict() (K, bool) {
+	t1 := p.lists[arcT1].Len()
+	if p.hasPending && p.items[p.pending] != nil && p.items[p.pending].list == arcT1 {
+		// the key being added doesn't take part in the replacement decision
+		t1--
+	}
+
+	from := arcT2
+	if t1 > 0 && ((p.pendingB2 && t1 == p.p) || t1 > p.p || p.lists[arcT2].Len() == 0) {
+		from = arcT1
+	}
+
+	e := p.lists[from].Back()
+	if e == nil {
+		// only the key being added is resident
+		e = p.lists[arcT1+arcT2-from].Back()
+	}
+	if e == nil {
+		var empty K
+		return empty, false
+	}
+
+	key := e.Value.(K)
+	ghost := arcB1
+	if from == arcT2 {
+		ghost = arcB2
+	}
+	p.move(key, p.items[key], ghost)
+
+	if p.hasPending && p.pending == key {
+		p.hasPending = false
+	}
+	return key, true
+}
+
+func (p *arcPolicy[K]) Reset() {
+	for _, l := range p.lists {
+		l.Init()
+	}
+	p.items = make(map[K]*arcEntry)
+	p.p = 0
+	p.hasPending = false
+}
@@ -0,0 +1,119 @@
+package r9e
+
+import "container/list"
+
+// lfuFrequency is a node of the lfuPolicy frequency list, it holds all the keys
+// with the same number of accesses ordered from the most to the least recent.
+type lfuFrequency[K comparable] struct {
+	count int
+	keys  *list.List
+}
+
+// lfuEntry is the position of a key in the lfuPolicy.
+type lfuEntry[K comparable] struct {
+	frequency *list.Element
+	element   *list.Element
+}
+
+// lfuPolicy evicts the least frequently used key, using the least recently used one to break ties.
+// All the operations are O(1).
+type lfuPolicy[K comparable] struct {
+	frequencies *list.List
+	items       map[K]*lfuEntry[K]
+
+	// the last added key, it is evicted only if there is no other key
+	pending    K
+	hasPending bool
+}
+
+// NewLFUPolicy returns an EvictionPolicy that evicts the least frequently used key.
+// When several keys have the same frequency, the least recently used is evicted.
+func NewLFUPolicy[K comparable](capacity int) EvictionPolicy[K] {
+	return &lfuPolicy[K]{
+		frequencies: list.New(),
+		items:       make(map[K]*lfuEntry[K]),
+	}
+}
+
+func (p *lfuPolicy[K]) Add(key K) {
+	if _, ok := p.items[key]; ok {
+		p.Access(key)
+		return
+	}
+
+	front := p.frequencies.Front()
+	if front == nil || front.Value.(*lfuFrequency[K]).count != 1 {
+		front = p.frequencies.PushFront(&lfuFrequency[K]{count: 1, keys: list.New()})
+	}
+
+	p.items[key] = &lfuEntry[K]{
+		frequency: front,
+		element:   front.Value.(*lfuFrequency[K]).keys.PushFront(key),
+	}
+	p.pending, p.hasPending = key, true
+}
+
+func (p *lfuPolicy[K]) Access(key K) {
+	entry, ok := p.items[key]
+	if !ok {
+		return
+	}
+
+	current := entry.frequency.Value.(*lfuFrequency[K])
+	next := entry.frequency.Next()
+	if next == nil || next.Value.(*lfuFrequency[K]).count != current.count+1 {
+		next = p.frequencies.InsertAfter(&lfuFrequency[K]{count: current.count + 1, keys: list.New()}, entry.frequency)
+	}
+
+	current.keys.Remove(entry.element)
+	if current.keys.Len() == 0 {
+		p.frequencies.Remove(entry.frequency)
+	}
+
+	entry.frequency = next
+	entry.element = next.Value.(*lfuFrequency[K]).keys.PushFront(key)
+}
+
+func (p *lfuPolicy[K]) Remove(key K) {
+	entry, ok := p.items[key]
+	if !ok {
+		return
+	}
+
+	frequency := entry.frequency.Value.(*lfuFrequency[K])
+	frequency.keys.Remove(entry.element)
+	if frequency.keys.Len() == 0 {
+		p.frequencies.Remove(entry.frequency)
+	}
+	delete(p.items, key)
+
+	if p.hasPending && p.pending == key {
+		p.hasPending = false
+	}
+}
+
+func (p *lfuPolicy[K]) Evict() (K, bool) {
+	for f := p.frequencies.Front(); f != nil; f = f.Next() {
+		key := f.Value.(*lfuFrequency[K]).keys.Back().Value.(K)
+		if p.hasPending && p.pending == key && len(p.items) > 1 {
+			// the key being added is the only one with this frequency
+			if keys := f.Value.(*lfuFrequency[K]).keys; keys.Len() > 1 {
+				key = keys.Back().Prev().Value.(K)
+			} else {
+				continue
+			}
+		}
+
+		p.Remove(key)
+		return key, true
+	}
+
+	var empty K
+	return empty, false
+}
+
+func (p *lfuPolicy[K]) Reset() {
+	p.frequencies.Init()
+	p.items = make(map[K]*lfuEntry[K])
+	p.hasPending = false
+}
@@ -0,0 +1,198 @@
+package r9e
+
+import "container/list"
+
+// s3fifoMaxFrequency is the maximum value of the access counter of a key.
+const s3fifoMaxFrequency = 3
+
+// s3fifoQueue identifies the queue of the s3fifoPolicy where a key is.
+type s3fifoQueue uint8
+
+const (
+	s3fifoSmall s3fifoQueue = iota
+	s3fifoMain
+	s3fifoGhost
+)
+
+// s3fifoEntry is the position and the access counter of a key in the s3fifoPolicy.
+type s3fifoEntry struct {
+	queue     s3fifoQueue
+	frequency uint8
+	element   *list.Element
+}
+
+// s3fifoPolicy implements the S3-FIFO algorithm (Yang et al., SOSP 2023).
+// New keys go to a small FIFO queue, the ones accessed while they are there are promoted to
+// the main FIFO queue and the rest are evicted quickly, remembering them in a ghost queue.
+type s3fifoPolicy[K comparable] struct {
+	capacity int
+	queues   [3]*list.List
+	items    map[K]*s3fifoEntry
+
+	// the last added key, it is inserted after the eviction so it doesn't take part in it
+	pending    K
+	hasPending bool
+}
+
+// NewS3FIFOPolicy returns an EvictionPolicy that implements the S3-FIFO algorithm.
+// It uses a small queue of 10% of the capacity to filter out the keys accessed only once,
+// which makes it scan resistant.
+func NewS3FIFOPolicy[K comparable](capacity int) EvictionPolicy[K] {
+	p := &s3fifoPolicy[K]{
+		capacity: capacity,
+		items:    make(map[K]*s3fifoEntry),
+	}
+	for i := range p.queues {
+		p.queues[i] = list.New()
+	}
+	return p
+}
+
+// c returns the capacity of the cache, when it is unknown the number of resident keys is used.
+func (p *s3fifoPolicy[K]) c() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return p.queues[s3fifoSmall].Len() + p.queues[s3fifoMain].Len()
+}
+
+// push inserts the key at the head of the given queue.
+func (p *s3fifoPolicy[K]) push(key K, entry *s3fifoEntry, to s3fifoQueue) {
+	if entry.element != nil {
+		p.queues[entry.queue].Remove(entry.element)
+	}
+	entry.queue = to
+	entry.element = p.queues[to].PushFront(key)
+}
+
+func (p *s3fifoPolicy[K]) Add(key K) {
+	entry, ok := p.items[key]
+	switch {
+	case ok && entry.queue == s3fifoGhost:
+		entry.frequency = 0
+		p.push(key, entry, s3fifoMain)
+	case ok:
+		p.Access(key)
+	default:
+		entry = &s3fifoEntry{}
+		p.items[key] = entry
+		p.push(key, entry, s3fifoSmall)
+		p.pending, p.hasPending = key, true
+	}
+}
+
+func (p *s3fifoPolicy[K]) Access(key K) {
+	if entry, ok := p.items[key]; ok && entry.queue != s3fifoGhost && entry.frequency < s3fifoMaxFrequency {
+		entry.frequency++
+	}
+}
+
+func (p *s3fifoPolicy[K]) Remove(key K) {
+	if entry, ok := p.items[key]; ok {
+		p.queues[entry.queue].Remove(entry.element)
+		delete(p.items, key)
+	}
+	if p.hasPending && p.pending == key {
+		p.hasPending = false
+	}
+}
+
+func (p *s3fifoPolicy[K]) Evict() (K, bool) {
+	smallTarget := p.c() / 10
+	if smallTarget < 1 {
+		smallTarget = 1
+	}
+
+	small := p.queues[s3fifoSmall].Len()
+	if p.isPending(s3fifoSmall) {
+		small--
+	}
+
+	if small >= smallTarget || p.queues[s3fifoMain].Len() == 0 {
+		if key, ok := p.evictSmall(); ok {
+			return key, true
+		}
+	}
+	if key, ok := p.evictMain(); ok {
+		return key, true
+	}
+
+	// the only resident key is the pending one
+	p.hasPending = false
+	return p.evictSmall()
+}
+
+// isPending returns true if the last added key is still in the given queue.
+func (p *s3fifoPolicy[K]) isPending(queue s3fifoQueue) bool {
+	if !p.hasPending {
+		return false
+	}
+	entry, ok := p.items[p.pending]
+	return ok && entry.queue == queue
+}
+
+// evictSmall moves the accessed keys from the tail of the small queue to the main queue
+// until it finds a key to evict, which is remembered in the ghost queue.
+func (p *s3fifoPolicy[K]) evictSmall() (K, bool) {
+	small := p.queues[s3fifoSmall]
+	for e := small.Back(); e != nil; e = small.Back() {
+		key := e.Value.(K)
+		entry := p.items[key]
+
+		if p.hasPending && p.pending == key {
+			break
+		}
+
+		if entry.frequency > 1 {
+			entry.frequency = 0
+			p.push(key, entry, s3fifoMain)
+			continue
+		}
+
+		p.push(key, entry, s3fifoGhost)
+		p.trimGhost()
+		return key, true
+	}
+
+	var empty K
+	return empty, false
+}
+
+// evictMain reinserts the accessed keys from the tail of the main queue, decreasing their
+// access counter, until it finds a key not accessed to evict.
+func (p *s3fifoPolicy[K]) evictMain() (K, bool) {
+	mainQueue := p.queues[s3fifoMain]
+	for e := mainQueue.Back(); e != nil; e = mainQueue.Back() {
+		key := e.Value.(K)
+		entry := p.items[key]
+
+		if entry.frequency > 0 {
+			entry.frequency--
+			p.push(key, entry, s3fifoMain)
+			continue
+		}
+
+		mainQueue.Remove(e)
+		delete(p.items, key)
+		return key, true
+	}
+
+	var empty K
+	return empty, false
+}
+
+// trimGhost removes the oldest keys of the ghost queue when it is bigger than the main queue capacity.
+func (p *s3fifoPolicy[K]) trimGhost() {
+	ghost := p.queues[s3fifoGhost]
+	for ghost.Len() > p.c()-p.c()/10 && ghost.Len() > 0 {
+		delete(p.items, ghost.Remove(ghost.Back()).(K))
+	}
+}
+
+func (p *s3fifoPolicy[K]) Reset() {
+	for _, q := range p.queues {
+		q.Init()
+	}
+	p.items = make(map[K]*s3fifoEntry)
+	p.hasPending = false
+}
@@ -0,0 +1,304 @@
+package r9e
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestWithMaxEntries_MapKeyValue(t *testing.T) {
+	t.Run("test Set evicts the least recently used key", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int](WithMaxEntries(3))
+
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+		kv.Set("three", 3)
+
+		// "one" becomes the most recently used
+		if v := kv.Get("one"); v != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, v)
+		}
+
+		kv.Set("four", 4)
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+		if kv.ContainsKey("two") {
+			t.Errorf("Expected key %v to be evicted", "two")
+		}
+
+		// "three" becomes the most recently used
+		if _, ok := kv.GetAndCheck("three"); !ok {
+			t.Errorf("Expected GetAndCheck to be %v, got %v", true, ok)
+		}
+
+		kv.Set("five", 5)
+
+		keys := kv.Keys()
+		sort.Strings(keys)
+		expected := []string{"five", "four", "three"}
+		for i := range expected {
+			if keys[i] != expected[i] {
+				t.Errorf("Expected keys to be %v, got %v", expected, keys)
+				break
+			}
+		}
+	})
+
+	t.Run("test Set of existing key doesn't evict", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithMaxEntries(2))
+
+		kv.Set(1, 1)
+		kv.Set(2, 2)
+		kv.Set(1, 11)
+		kv.Set(3, 3)
+
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+		if v := kv.Get(1); v != 11 {
+			t.Errorf("Expected value to be %v, got %v", 11, v)
+		}
+		if kv.ContainsKey(2) {
+			t.Errorf("Expected key %v to be evicted", 2)
+		}
+	})
+
+	t.Run("test Delete, GetAnDelete and Clear keep the recency consistent", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithMaxEntries(2))
+
+		kv.Set(1, 1)
+		kv.Set(2, 2)
+		kv.Delete(1)
+		kv.Set(3, 3)
+
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+
+		if _, ok := kv.GetAnDelete(2); !ok {
+			t.Errorf("Expected GetAnDelete to be %v, got %v", true, ok)
+		}
+		kv.Set(4, 4)
+
+		if !kv.ContainsKey(3) || !kv.ContainsKey(4) {
+			t.Errorf("Expected keys to be %v, got %v", []int{3, 4}, kv.Keys())
+		}
+
+		kv.Clear()
+		kv.Set(5, 5)
+		kv.Set(6, 6)
+		kv.Set(7, 7)
+
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+		if kv.ContainsKey(5) {
+			t.Errorf("Expected key %v to be evicted", 5)
+		}
+	})
+
+	t.Run("test WithMaxEntries equal zero is unbounded", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithMaxEntries(0))
+
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+		}
+
+		if kv.Size() != 100 {
+			t.Errorf("Expected size to be %v, got %v", 100, kv.Size())
+		}
+	})
+}
+
+func TestPeek_MapKeyValue(t *testing.T) {
+	t.Run("test Peek doesn't update the recency", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int](WithMaxEntries(2))
+
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+
+		if v, ok := kv.Peek("one"); !ok || v != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, v)
+		}
+		if _, ok := kv.Peek("three"); ok {
+			t.Errorf("Expected Peek to be %v, got %v", false, ok)
+		}
+
+		kv.Set("three", 3)
+
+		if kv.ContainsKey("one") {
+			t.Errorf("Expected key %v to be evicted", "one")
+		}
+	})
+}
+
+// evictionPolicies are the built-in eviction policies under test.
+var evictionPolicies = []struct {
+	name      string
+	newPolicy func(capacity int) EvictionPolicy[int]
+}{
+	{"LRU", NewLRUPolicy[int]},
+	{"LFU", NewLFUPolicy[int]},
+	{"FIFO", NewFIFOPolicy[int]},
+	{"ARC", NewARCPolicy[int]},
+	{"S3FIFO", NewS3FIFOPolicy[int]},
+	{"WTinyLFU", NewWTinyLFUPolicy[int]},
+}
+
+func TestWithEvictionPolicy_MapKeyValue(t *testing.T) {
+	for _, tc := range evictionPolicies {
+		tc := tc
+		t.Run("test random operations keep the container bounded and the policy consistent for "+tc.name, func(t *testing.T) {
+			const capacity = 64
+			kv := NewMapKeyValue[int, int](WithMaxEntries(capacity), WithEvictionPolicy(tc.newPolicy))
+			rnd := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 20000; i++ {
+				key := rnd.Intn(capacity * 4)
+				switch op := rnd.Intn(10); {
+				case op < 5:
+					kv.Set(key, i)
+				case op < 8:
+					kv.Get(key)
+				case op < 9:
+					kv.Delete(key)
+				default:
+					kv.GetAnDelete(key)
+				}
+
+				if kv.Size() > capacity {
+					t.Fatalf("Expected size to be less or equal than %v, got %v", capacity, kv.Size())
+				}
+			}
+
+			// every evicted key must be in the container and evicted only once
+			evicted := make(map[int]bool)
+			for {
+				key, ok := kv.policy.Evict()
+				if !ok {
+					break
+				}
+				if _, ok := kv.data[key]; !ok {
+					t.Fatalf("Expected evicted key %v to be in the container", key)
+				}
+				if evicted[key] {
+					t.Fatalf("Expected key %v to be evicted only once", key)
+				}
+				evicted[key] = true
+			}
+
+			if len(evicted) != kv.Size() {
+				t.Errorf("Expected evicted keys to be %v, got %v", kv.Size(), len(evicted))
+			}
+		})
+
+		t.Run("test Clear resets the policy for "+tc.name, func(t *testing.T) {
+			kv := NewMapKeyValue[int, int](WithMaxEntries(8), WithEvictionPolicy(tc.newPolicy))
+			for i := 0; i < 32; i++ {
+				kv.Set(i, i)
+			}
+
+			kv.Clear()
+
+			if _, ok := kv.policy.Evict(); ok {
+				t.Errorf("Expected policy to be empty after Clear")
+			}
+		})
+	}
+
+	t.Run("test WithEvictionPolicy with a different key type panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected NewMapKeyValue to panic")
+			}
+		}()
+
+		NewMapKeyValue[string, int](WithMaxEntries(8), WithEvictionPolicy(NewLFUPolicy[int]))
+	})
+}
+
+func TestFIFOPolicy(t *testing.T) {
+	t.Run("test FIFO evicts the oldest key even if it is accessed", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithMaxEntries(2), WithEvictionPolicy(NewFIFOPolicy[int]))
+
+		kv.Set(1, 1)
+		kv.Set(2, 2)
+		kv.Get(1)
+		kv.Set(3, 3)
+
+		if kv.ContainsKey(1) {
+			t.Errorf("Expected key %v to be evicted", 1)
+		}
+	})
+}
+
+func TestLFUPolicy(t *testing.T) {
+	t.Run("test LFU evicts the least frequently used key", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithMaxEntries(3), WithEvictionPolicy(NewLFUPolicy[int]))
+
+		kv.Set(1, 1)
+		kv.Set(2, 2)
+		kv.Set(3, 3)
+		kv.Get(1)
+		kv.Get(1)
+		kv.Get(3)
+		kv.Set(4, 4)
+
+		if kv.ContainsKey(2) {
+			t.Errorf("Expected key %v to be evicted", 2)
+		}
+
+		// 4 and 3 have a lower frequency than 1, 4 is the least recently used of them
+		kv.Get(4)
+		kv.Get(4)
+		kv.Set(5, 5)
+
+		if kv.ContainsKey(3) {
+			t.Errorf("Expected key %v to be evicted", 3)
+		}
+		if !kv.ContainsKey(1) {
+			t.Errorf("Expected key %v to be in the container", 1)
+		}
+	})
+}
+
+func TestScanResistance_EvictionPolicy(t *testing.T) {
+	for _, tc := range evictionPolicies {
+		tc := tc
+		if tc.name != "ARC" && tc.name != "S3FIFO" && tc.name != "WTinyLFU" {
+			continue
+		}
+
+		t.Run("test a scan doesn't evict the hot keys for "+tc.name, func(t *testing.T) {
+			const capacity = 100
+			kv := NewMapKeyValue[int, int](WithMaxEntries(capacity), WithEvictionPolicy(tc.newPolicy))
+
+			// hot keys accessed several times
+			for round := 0; round < 5; round++ {
+				for key := 0; key < capacity/2; key++ {
+					if _, ok := kv.GetAndCheck(key); !ok {
+						kv.Set(key, key)
+					}
+				}
+			}
+
+			// scan of keys accessed only once
+			for key := 1000; key < 1000+10*capacity; key++ {
+				kv.Set(key, key)
+			}
+
+			hot := 0
+			for key := 0; key < capacity/2; key++ {
+				if kv.ContainsKey(key) {
+					hot++
+				}
+			}
+
+			if hot < capacity/4 {
+				t.Errorf("Expected at least %v hot keys after the scan, got %v", capacity/4, hot)
+			}
+		})
+	}
+}
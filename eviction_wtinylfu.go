@@ -0,0 +1,250 @@
+package r9e
+
+import "container/list"
+
+// wtinylfuSegment identifies the segment of the wtinylfuPolicy where a key is.
+type wtinylfuSegment uint8
+
+const (
+	wtinylfuWindow wtinylfuSegment = iota
+	wtinylfuProbation
+	wtinylfuProtected
+)
+
+// wtinylfuEntry is the position of a key in the wtinylfuPolicy.
+type wtinylfuEntry struct {
+	segment wtinylfuSegment
+	element *list.Element
+}
+
+// wtinylfuPolicy implements the W-TinyLFU algorithm (Einziger, Friedman and Manes).
+// New keys go to a small LRU window, the keys leaving the window are admitted in the main
+// segmented LRU only if their estimated frequency is higher than the one of the probation victim.
+type wtinylfuPolicy[K comparable] struct {
+	windowCapacity    int
+	protectedCapacity int
+	segments          [3]*list.List
+	items             map[K]*wtinylfuEntry
+	sketch            *countMinSketch
+	hash              Hasher[K]
+
+	// the last key moved from the window to the probation segment, it must be admitted
+	candidate    K
+	hasCandidate bool
+}
+
+// NewWTinyLFUPolicy returns an EvictionPolicy that implements the W-TinyLFU algorithm.
+// It uses a window of 1% of the capacity and a Count-Min sketch to estimate the frequency
+// of the keys, so the keys accessed only once can't evict the popular ones.
+func NewWTinyLFUPolicy[K comparable](capacity int) EvictionPolicy[K] {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	window := capacity / 100
+	if window < 1 {
+		window = 1
+	}
+
+	p := &wtinylfuPolicy[K]{
+		windowCapacity:    window,
+		protectedCapacity: (capacity - window) * 8 / 10,
+		items:             make(map[K]*wtinylfuEntry),
+		sketch:            newCountMinSketch(capacity),
+		hash:              NewHasher[K](),
+	}
+	for i := range p.segments {
+		p.segments[i] = list.New()
+	}
+	return p
+}
+
+// push inserts the key at the front of the given segment.
+func (p *wtinylfuPolicy[K]) push(key K, entry *wtinylfuEntry, to wtinylfuSegment) {
+	if entry.element != nil {
+		p.segments[entry.segment].Remove(entry.element)
+	}
+	entry.segment = to
+	entry.element = p.segments[to].PushFront(key)
+}
+
+func (p *wtinylfuPolicy[K]) Add(key K) {
+	if _, ok := p.items[key]; ok {
+		p.Access(key)
+		return
+	}
+
+	p.sketch.increment(p.hash(key))
+	entry := &wtinylfuEntry{}
+	p.items[key] = entry
+	p.push(key, entry, wtinylfuWindow)
+
+	// the least recent key of the window moves to probation, where it competes to be admitted
+	window := p.segments[wtinylfuWindow]
+	if window.Len() > p.windowCapacity {
+		candidate := window.Back().Value.(K)
+		p.push(candidate, p.items[candidate], wtinylfuProbation)
+		p.candidate, p.hasCandidate = candidate, true
+	}
+}
+
+func (p *wtinylfuPolicy[K]) Access(key K) {
+	entry, ok := p.items[key]
+	if !ok {
+		return
+	}
+
+	p.sketch.increment(p.hash(key))
+
+	switch entry.segment {
+	case wtinylfuWindow, wtinylfuProtected:
+		p.segments[entry.segment].MoveToFront(entry.element)
+	case wtinylfuProbation:
+		p.push(key, entry, wtinylfuProtected)
+
+		// the protected segment is full, demote its least recent key to probation
+		protected := p.segments[wtinylfuProtected]
+		if protected.Len() > p.protectedCapacity {
+			demoted := protected.Back().Value.(K)
+			p.push(demoted, p.items[demoted], wtinylfuProbation)
+		}
+	}
+}
+
+func (p *wtinylfuPolicy[K]) Remove(key K) {
+	if entry, ok := p.items[key]; ok {
+		p.segments[entry.segment].Remove(entry.element)
+		delete(p.items, key)
+	}
+
+	if p.hasCandidate && p.candidate == key {
+		p.hasCandidate = false
+	}
+}
+
+func (p *wtinylfuPolicy[K]) Evict() (K, bool) {
+	victim, ok := p.victim()
+	if !ok {
+		return victim, false
+	}
+
+	// the candidate is admitted only if it is more frequent than the victim
+	if p.hasCandidate && p.candidate != victim && p.items[p.candidate].segment == wtinylfuProbation {
+		candidate := p.candidate
+		p.hasCandidate = false
+
+		if p.sketch.estimate(p.hash(candidate)) <= p.sketch.estimate(p.hash(victim)) {
+			victim = candidate
+		}
+	}
+
+	p.Remove(victim)
+	return victim, true
+}
+
+// victim returns the least recent key of the probation segment, or of the protected segment
+// or the window when the previous ones are empty.
+func (p *wtinylfuPolicy[K]) victim() (K, bool) {
+	for _, segment := range []wtinylfuSegment{wtinylfuProbation, wtinylfuProtected, wtinylfuWindow} {
+		if e := p.segments[segment].Back(); e != nil {
+			return e.Value.(K), true
+		}
+	}
+
+	var empty K
+	return empty, false
+}
+
+func (p *wtinylfuPolicy[K]) Reset() {
+	for _, s := range p.segments {
+		s.Init()
+	}
+	p.items = make(map[K]*wtinylfuEntry)
+	p.sketch.reset()
+	p.hasCandidate = false
+}
+
+// countMinSketchDepth is the number of rows of the countMinSketch.
+const countMinSketchDepth = 4
+
+// countMinSketchMaxCount is the maximum value of the 4-bit counters of the countMinSketch.
+const countMinSketchMaxCount = 15
+
+// countMinSketch estimates the frequency of the keys using 4-bit saturated counters.
+// The counters are halved after a number of increments ten times the capacity, so the
+// frequencies age and the sketch adapts to the changes of the workload.
+type countMinSketch struct {
+	rows       [countMinSketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+// newCountMinSketch returns a new countMinSketch sized for the given number of keys.
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+
+	s := &countMinSketch{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * capacity,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// index returns the position of the hash in the given row.
+func (s *countMinSketch) index(hash uint64, row int) uint64 {
+	h := hash + uint64(row)*((hash>>32)|1)
+	return mix64(h) & s.mask
+}
+
+// increment increments the counters of the hash.
+func (s *countMinSketch) increment(hash uint64) {
+	for i := range s.rows {
+		idx := s.index(hash, i)
+		if s.rows[i][idx] < countMinSketchMaxCount {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.age()
+	}
+}
+
+// estimate returns the estimated frequency of the hash.
+func (s *countMinSketch) estimate(hash uint64) uint8 {
+	estimate := uint8(countMinSketchMaxCount)
+	for i := range s.rows {
+		if v := s.rows[i][s.index(hash, i)]; v < estimate {
+			estimate = v
+		}
+	}
+	return estimate
+}
+
+// age halves all the counters.
+func (s *countMinSketch) age() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// reset sets all the counters to zero.
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] = 0
+		}
+	}
+	s.additions = 0
+}
@@ -5,5 +5,11 @@ Package r9e provides a collection of memory store containers.
 
 * [MapKeyValue[K comparable, T any]](https://pkg.go.dev/github.com/slashdevops/r9e#MapKeyValue) using sync.RWMutex
 * [SMapKeyValue[K comparable, T any]](https://pkg.go.dev/github.com/slashdevops/r9e#SMapKeyValue) using sync.Map
+* [ShardedMapKeyValue[K comparable, T any]](https://pkg.go.dev/github.com/slashdevops/r9e#ShardedMapKeyValue) using several MapKeyValue shards
+* [OrderedMapKeyValue[K comparable, T any]](https://pkg.go.dev/github.com/slashdevops/r9e#OrderedMapKeyValue) using a map and a doubly linked list, keeping the insertion order
+* [SortedMapKeyValue[K comparable, T any]](https://pkg.go.dev/github.com/slashdevops/r9e#SortedMapKeyValue) using a skip list, keeping the keys sorted
+* [RadixKeyValue[T any]](https://pkg.go.dev/github.com/slashdevops/r9e#RadixKeyValue) using a radix tree, with string keys and prefix queries
+
+All the containers implement the KeyValue interface, so they can be switched easily.
 */
 package r9e
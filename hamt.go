@@ -0,0 +1,298 @@
+package r9e
+
+import (
+	"math/bits"
+	"slices"
+)
+
+const (
+	// hamtBits is the number of bits of the hash consumed by each level of the trie.
+	hamtBits = 5
+
+	// hamtMask selects the position of a key in a node, there are 1<<hamtBits positions.
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamt is a persistent hash array mapped trie: a trie indexed by the bits of the key hashes,
+// where each node stores only its present children, so it is compact and O(log32 n) deep.
+// The changes copy the path from the root to the key and share the rest of the trie, so a copy
+// of a hamt value is an immutable version that the changes of the original don't modify.
+// The nodes created while the hamt has an owner are modified in place by the next changes, until
+// freeze gives them away, so a batch of changes copies each node at most once.
+// It is not thread-safe.
+type hamt[K comparable, T any] struct {
+	root  *hamtNode[K, T]
+	size  int
+	hash  Hasher[K]
+	owner *hamtOwner
+}
+
+// hamtOwner identifies the nodes that a hamt can modify in place.
+// It is not empty because the pointers to distinct zero-size values may be equal.
+type hamtOwner struct {
+	_ byte
+}
+
+// hamtNode is a node of the trie. bitmap has a bit set for each present position, and slots
+// holds their content ordered by position. The nodes below the last level of the hash hold
+// the keys whose hashes collide, in no particular order and with an empty bitmap.
+type hamtNode[K comparable, T any] struct {
+	owner  *hamtOwner
+	bitmap uint32
+	slots  []hamtSlot[K, T]
+}
+
+// hamtSlot is either a key-value pair, with the hash of the key, or a child node.
+type hamtSlot[K comparable, T any] struct {
+	child *hamtNode[K, T]
+	hash  uint64
+	key   K
+	value T
+}
+
+// newHAMT returns a new empty hamt using hash. If transient is true, the hamt modifies in place
+// the nodes it creates.
+func newHAMT[K comparable, T any](hash Hasher[K], transient bool) hamt[K, T] {
+	h := hamt[K, T]{hash: hash}
+	if transient {
+		h.owner = &hamtOwner{}
+	}
+	return h
+}
+
+// get returns the value of the key and true if it exist.
+func (h *hamt[K, T]) get(key K) (T, bool) {
+	var empty T
+	if h.root == nil {
+		return empty, false
+	}
+
+	hash := h.hash(key)
+	for n, shift := h.root, uint(0); n != nil; shift += hamtBits {
+		if shift >= 64 {
+			for i := range n.slots {
+				if n.slots[i].key == key {
+					return n.slots[i].value, true
+				}
+			}
+			break
+		}
+
+		bit := uint32(1) << (hash >> shift & hamtMask)
+		if n.bitmap&bit == 0 {
+			break
+		}
+
+		s := &n.slots[bits.OnesCount32(n.bitmap&(bit-1))]
+		if s.child == nil {
+			if s.hash == hash && s.key == key {
+				return s.value, true
+			}
+			break
+		}
+		n = s.child
+	}
+
+	return empty, false
+}
+
+// set stores the value of the key and returns true if the key was added.
+func (h *hamt[K, T]) set(key K, value T) bool {
+	s := hamtSlot[K, T]{hash: h.hash(key), key: key, value: value}
+	if h.root == nil {
+		h.root = &hamtNode[K, T]{owner: h.owner}
+	}
+
+	var added bool
+	h.root, added = h.root.set(h.owner, 0, s)
+	if added {
+		h.size++
+	}
+	return added
+}
+
+// delete removes the key and returns its value and true if it exist.
+func (h *hamt[K, T]) delete(key K) (T, bool) {
+	if h.root == nil {
+		var empty T
+		return empty, false
+	}
+
+	root, value, ok := h.root.delete(h.owner, 0, h.hash(key), key)
+	if !ok {
+		return value, false
+	}
+
+	if len(root.slots) == 0 {
+		root = nil
+	}
+	h.root = root
+	h.size--
+	return value, true
+}
+
+// all calls yield for each key-value pair, in no particular order, until it returns false.
+// Returns false if the iteration was stopped.
+func (h *hamt[K, T]) all(yield func(key K, value T) bool) bool {
+	if h.root == nil {
+		return true
+	}
+	return h.root.all(yield)
+}
+
+// freeze returns an immutable version of the hamt. If the hamt is transient, it gets a new owner
+// so the nodes shared with the returned version are copied by the next changes.
+// It runs in constant time.
+func (h *hamt[K, T]) freeze() hamt[K, T] {
+	frozen := *h
+	frozen.owner = nil
+	if h.owner != nil {
+		h.owner = &hamtOwner{}
+	}
+	return frozen
+}
+
+// editable returns the node if it can be modified in place by owner, or a copy owned by owner.
+func (n *hamtNode[K, T]) editable(owner *hamtOwner) *hamtNode[K, T] {
+	if owner != nil && n.owner == owner {
+		return n
+	}
+	return &hamtNode[K, T]{owner: owner, bitmap: n.bitmap, slots: slices.Clone(n.slots)}
+}
+
+// set stores the key-value pair s in the subtree of the node at the given shift of the hash,
+// and returns the new subtree and true if the key was added.
+func (n *hamtNode[K, T]) set(owner *hamtOwner, shift uint, s hamtSlot[K, T]) (*hamtNode[K, T], bool) {
+	if shift >= 64 {
+		for i := range n.slots {
+			if n.slots[i].key == s.key {
+				m := n.editable(owner)
+				m.slots[i] = s
+				return m, false
+			}
+		}
+
+		m := n.editable(owner)
+		m.slots = append(m.slots, s)
+		return m, true
+	}
+
+	bit := uint32(1) << (s.hash >> shift & hamtMask)
+	i := bits.OnesCount32(n.bitmap & (bit - 1))
+	if n.bitmap&bit == 0 {
+		m := n.editable(owner)
+		m.bitmap |= bit
+		m.slots = slices.Insert(m.slots, i, s)
+		return m, true
+	}
+
+	cur := n.slots[i]
+	switch {
+	case cur.child != nil:
+		child, added := cur.child.set(owner, shift+hamtBits, s)
+		m := n.editable(owner)
+		m.slots[i].child = child
+		return m, added
+
+	case cur.hash == s.hash && cur.key == s.key:
+		m := n.editable(owner)
+		m.slots[i] = s
+		return m, false
+
+	default:
+		// two keys in the same position, they are moved to a new node
+		m := n.editable(owner)
+		m.slots[i] = hamtSlot[K, T]{child: newHAMTPair(owner, shift+hamtBits, cur, s)}
+		return m, true
+	}
+}
+
+// newHAMTPair returns a new subtree at the given shift of the hash holding the key-value pairs a and b.
+func newHAMTPair[K comparable, T any](owner *hamtOwner, shift uint, a, b hamtSlot[K, T]) *hamtNode[K, T] {
+	if shift >= 64 {
+		return &hamtNode[K, T]{owner: owner, slots: []hamtSlot[K, T]{a, b}}
+	}
+
+	ia, ib := a.hash>>shift&hamtMask, b.hash>>shift&hamtMask
+	switch {
+	case ia == ib:
+		child := newHAMTPair(owner, shift+hamtBits, a, b)
+		return &hamtNode[K, T]{owner: owner, bitmap: 1 << ia, slots: []hamtSlot[K, T]{{child: child}}}
+	case ia > ib:
+		a, b = b, a
+	}
+	return &hamtNode[K, T]{owner: owner, bitmap: 1<<ia | 1<<ib, slots: []hamtSlot[K, T]{a, b}}
+}
+
+// delete removes the key from the subtree of the node at the given shift of the hash, and returns
+// the new subtree, the value of the key and true if it exist. The node is returned unchanged if
+// the key doesn't exist.
+func (n *hamtNode[K, T]) delete(owner *hamtOwner, shift uint, hash uint64, key K) (*hamtNode[K, T], T, bool) {
+	var empty T
+
+	if shift >= 64 {
+		for i := range n.slots {
+			if n.slots[i].key == key {
+				value := n.slots[i].value
+				m := n.editable(owner)
+				m.slots = slices.Delete(m.slots, i, i+1)
+				return m, value, true
+			}
+		}
+		return n, empty, false
+	}
+
+	bit := uint32(1) << (hash >> shift & hamtMask)
+	if n.bitmap&bit == 0 {
+		return n, empty, false
+	}
+
+	i := bits.OnesCount32(n.bitmap & (bit - 1))
+	cur := n.slots[i]
+	if cur.child == nil {
+		if cur.hash != hash || cur.key != key {
+			return n, empty, false
+		}
+
+		m := n.editable(owner)
+		m.bitmap &^= bit
+		m.slots = slices.Delete(m.slots, i, i+1)
+		return m, cur.value, true
+	}
+
+	child, value, ok := cur.child.delete(owner, shift+hamtBits, hash, key)
+	if !ok {
+		return n, empty, false
+	}
+
+	m := n.editable(owner)
+	switch {
+	case len(child.slots) == 0:
+		m.bitmap &^= bit
+		m.slots = slices.Delete(m.slots, i, i+1)
+	case len(child.slots) == 1 && child.slots[0].child == nil:
+		// a single key-value pair doesn't need its own node
+		m.slots[i] = child.slots[0]
+	default:
+		m.slots[i].child = child
+	}
+	return m, value, true
+}
+
+// all calls yield for each key-value pair of the subtree of the node until it returns false.
+// Returns false if the iteration was stopped.
+func (n *hamtNode[K, T]) all(yield func(key K, value T) bool) bool {
+	for i := range n.slots {
+		s := &n.slots[i]
+		if s.child != nil {
+			if !s.child.all(yield) {
+				return false
+			}
+			continue
+		}
+		if !yield(s.key, s.value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package r9e
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkHAMT compares the hamt with the expected map.
+func checkHAMT(t *testing.T, h *hamt[int, int], expected map[int]int) {
+	t.Helper()
+
+	if h.size != len(expected) {
+		t.Fatalf("Expected size to be %v, got %v", len(expected), h.size)
+	}
+	for key, value := range expected {
+		if v, ok := h.get(key); !ok || v != value {
+			t.Fatalf("Expected value of key %v to be %v, got %v %v", key, value, v, ok)
+		}
+	}
+
+	seen := 0
+	h.all(func(key, value int) bool {
+		seen++
+		if expected[key] != value {
+			t.Fatalf("Expected value of key %v to be %v, got %v", key, expected[key], value)
+		}
+		return true
+	})
+	if seen != len(expected) {
+		t.Fatalf("Expected iterated keys to be %v, got %v", len(expected), seen)
+	}
+}
+
+func TestHAMT(t *testing.T) {
+	hashers := map[string]Hasher[int]{
+		"default":    NewHasher[int](),
+		"collisions": func(key int) uint64 { return uint64(key % 4) },
+	}
+
+	for name, hash := range hashers {
+		for _, transient := range []bool{false, true} {
+			t.Run("test random changes with "+name+" hasher", func(t *testing.T) {
+				h := newHAMT[int, int](hash, transient)
+				expected := make(map[int]int)
+
+				rnd := rand.New(rand.NewSource(1))
+				for i := 0; i < 5000; i++ {
+					key := rnd.Intn(500)
+					if rnd.Intn(3) == 0 {
+						_, exists := expected[key]
+						if _, ok := h.delete(key); ok != exists {
+							t.Fatalf("Expected delete of key %v to be %v, got %v", key, exists, ok)
+						}
+						delete(expected, key)
+						continue
+					}
+
+					_, exists := expected[key]
+					if added := h.set(key, i); added == exists {
+						t.Fatalf("Expected set of key %v to add it %v, got %v", key, !exists, added)
+					}
+					expected[key] = i
+				}
+
+				checkHAMT(t, &h, expected)
+			})
+		}
+	}
+
+	t.Run("test the frozen versions don't change", func(t *testing.T) {
+		h := newHAMT[int, int](NewHasher[int](), true)
+		expected := make(map[int]int)
+		for i := 0; i < 1000; i++ {
+			h.set(i, i)
+			expected[i] = i
+		}
+
+		frozen := h.freeze()
+		for i := 0; i < 1000; i += 2 {
+			h.delete(i)
+		}
+		for i := 1; i < 1000; i += 2 {
+			h.set(i, -i)
+		}
+
+		checkHAMT(t, &frozen, expected)
+		if h.size != 500 {
+			t.Errorf("Expected size to be %v, got %v", 500, h.size)
+		}
+	})
+
+	t.Run("test delete of every key empties the hamt", func(t *testing.T) {
+		h := newHAMT[int, int](func(key int) uint64 { return uint64(key % 2) }, false)
+		for i := 0; i < 10; i++ {
+			h.set(i, i)
+		}
+		for i := 0; i < 10; i++ {
+			h.delete(i)
+		}
+
+		if h.root != nil || h.size != 0 {
+			t.Errorf("Expected hamt to be empty, got size %v", h.size)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package r9e
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// Hasher returns the hash of a key. Equal keys must return the same hash.
+type Hasher[K comparable] func(key K) uint64
+
+// NewHasher returns the default Hasher for keys of type K.
+// Keys of the predeclared string, integer and float types are hashed from their value without
+// allocating. Keys of a named type of those kinds are hashed from their value through reflect,
+// which is slower. Any other comparable key is hashed walking its fields, elements or dynamic
+// value through reflect, which is slower still, so use a custom Hasher for the struct, array or
+// interface keys in hot paths.
+func NewHasher[K comparable]() Hasher[K] {
+	seed := maphash.MakeSeed()
+
+	var zero K
+	switch any(zero).(type) {
+	case string:
+		return func(key K) uint64 {
+			return maphash.String(seed, any(key).(string))
+		}
+	case int:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(int)))
+		}
+	case int8:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(int8)))
+		}
+	case int16:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(int16)))
+		}
+	case int32:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(int32)))
+		}
+	case int64:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(int64)))
+		}
+	case uint:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(uint)))
+		}
+	case uint8:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(uint8)))
+		}
+	case uint16:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(uint16)))
+		}
+	case uint32:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(uint32)))
+		}
+	case uint64:
+		return func(key K) uint64 {
+			return mix64(any(key).(uint64))
+		}
+	case uintptr:
+		return func(key K) uint64 {
+			return mix64(uint64(any(key).(uintptr)))
+		}
+	case float32:
+		return func(key K) uint64 {
+			return hashFloat(float64(any(key).(float32)))
+		}
+	case float64:
+		return func(key K) uint64 {
+			return hashFloat(any(key).(float64))
+		}
+	}
+
+	typ := reflect.TypeOf(zero)
+	if typ == nil {
+		return func(key K) uint64 {
+			return hashReflect(seed, reflect.ValueOf(key))
+		}
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return func(key K) uint64 {
+			return maphash.String(seed, reflect.ValueOf(key).String())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(key K) uint64 {
+			return mix64(uint64(reflect.ValueOf(key).Int()))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(key K) uint64 {
+			return mix64(reflect.ValueOf(key).Uint())
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(key K) uint64 {
+			return hashFloat(reflect.ValueOf(key).Float())
+		}
+	default:
+		return func(key K) uint64 {
+			return hashReflect(seed, reflect.ValueOf(key))
+		}
+	}
+}
+
+// hashReflect returns the hash of a key of any comparable type.
+func hashReflect(seed maphash.Seed, v reflect.Value) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	writeValue(&h, v)
+	return h.Sum64()
+}
+
+// writeValue writes the value to the hash, walking the elements of the arrays, the fields of the
+// structs and the dynamic value of the interfaces, so equal values write the same bytes.
+func writeValue(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		h.WriteByte(0)
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint64(h, floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeUint64(h, floatBits(real(c)))
+		writeUint64(h, floatBits(imag(c)))
+	case reflect.String:
+		// the length keeps apart the strings split differently between the fields
+		writeUint64(h, uint64(v.Len()))
+		h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint64(h, uint64(v.Pointer()))
+	case reflect.Array:
+		for i := range v.Len() {
+			writeValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range v.NumField() {
+			// the blank fields are ignored by the comparison
+			if t.Field(i).Name != "_" {
+				writeValue(h, v.Field(i))
+			}
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+		} else {
+			writeValue(h, v.Elem())
+		}
+	default:
+		// slices, maps and functions are not comparable, comparing them as keys panics
+	}
+}
+
+// writeUint64 writes the 8 bytes of x to the hash.
+func writeUint64(h *maphash.Hash, x uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	h.Write(b[:])
+}
+
+// hashFloat returns the hash of a float key.
+func hashFloat(f float64) uint64 {
+	return mix64(floatBits(f))
+}
+
+// floatBits returns the bits of a float key, the same for +0 and -0, which are equal keys.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// mix64 is the splitmix64 finalizer, used to spread the bits of integer keys.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,104 @@
+package r9e
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewHasher(t *testing.T) {
+	t.Run("test NewHasher returns the same hash for equal keys", func(t *testing.T) {
+		type name string
+		type point struct {
+			X, Y int
+		}
+
+		hs := NewHasher[string]()
+		if hs("r9e") != hs("r9e") || hs("r9e") == hs("r9f") {
+			t.Errorf("Expected string hashes to be consistent")
+		}
+
+		hn := NewHasher[name]()
+		if hn("r9e") != hn(name("r9e")) {
+			t.Errorf("Expected named string hashes to be equal")
+		}
+
+		hi := NewHasher[int64]()
+		if hi(42) != hi(42) || hi(42) == hi(43) {
+			t.Errorf("Expected int64 hashes to be consistent")
+		}
+
+		hu := NewHasher[uint8]()
+		if hu(7) != hu(7) {
+			t.Errorf("Expected uint8 hashes to be equal")
+		}
+
+		hf := NewHasher[float64]()
+		if hf(0) != hf(math.Copysign(0, -1)) {
+			t.Errorf("Expected +0 and -0 hashes to be equal")
+		}
+
+		hp := NewHasher[point]()
+		if hp(point{1, 2}) != hp(point{1, 2}) || hp(point{1, 2}) == hp(point{2, 1}) {
+			t.Errorf("Expected struct hashes to be consistent")
+		}
+	})
+
+	t.Run("test NewHasher returns the same hash for the predeclared and named types", func(t *testing.T) {
+		type id int32
+		type ratio float32
+
+		if NewHasher[id]()(-7) != NewHasher[int32]()(-7) {
+			t.Errorf("Expected named int32 hash to be equal to the int32 one")
+		}
+		hr := NewHasher[ratio]()
+		if hr(1.5) != NewHasher[float32]()(1.5) || hr(0) != hr(ratio(math.Copysign(0, -1))) {
+			t.Errorf("Expected named float32 hashes to be equal to the float32 ones")
+		}
+		if NewHasher[uintptr]()(42) != NewHasher[uint64]()(42) {
+			t.Errorf("Expected uintptr hash to be equal to the uint64 one")
+		}
+	})
+
+	t.Run("test NewHasher returns the same hash for equal struct, array and interface keys", func(t *testing.T) {
+		type vector struct {
+			X, Y float64
+			Tag  any
+			_    int
+		}
+
+		negZero := math.Copysign(0, -1)
+		hv := NewHasher[vector]()
+		if hv(vector{X: 0, Tag: 1}) != hv(vector{X: negZero, Tag: 1}) {
+			t.Errorf("Expected the hashes of the structs with +0 and -0 fields to be equal")
+		}
+		if hv(vector{X: 1, Tag: "a"}) == hv(vector{X: 1, Tag: "b"}) {
+			t.Errorf("Expected the hashes of the structs with different fields to be different")
+		}
+
+		ha := NewHasher[[2]float32]()
+		if ha([2]float32{0, 1}) != ha([2]float32{float32(negZero), 1}) {
+			t.Errorf("Expected the hashes of the arrays with +0 and -0 elements to be equal")
+		}
+
+		hi := NewHasher[any]()
+		if hi(0.0) != hi(negZero) || hi(nil) != hi(nil) || hi("r9e") != hi("r9e") {
+			t.Errorf("Expected the hashes of the equal interface keys to be equal")
+		}
+		if hi(vector{Y: 0}) != hi(vector{Y: negZero}) {
+			t.Errorf("Expected the hashes of the interface keys holding equal structs to be equal")
+		}
+	})
+
+	t.Run("test NewHasher doesn't allocate for the predeclared types", func(t *testing.T) {
+		hs, hi, hf := NewHasher[string](), NewHasher[int](), NewHasher[float64]()
+		allocs := testing.AllocsPerRun(100, func() {
+			hs("r9e")
+			hi(42)
+			hf(4.2)
+		})
+
+		if allocs != 0 {
+			t.Errorf("Expected allocations to be %v, got %v", 0, allocs)
+		}
+	})
+}
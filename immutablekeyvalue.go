@@ -0,0 +1,352 @@
+package r9e
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+var (
+	_ Reader[string, any]   = (*ImmutableKeyValue[string, any])(nil)
+	_ Iterable[string, any] = (*ImmutableKeyValue[string, any])(nil)
+)
+
+type immutableKeyValueOptions struct {
+	hasher any
+}
+
+// ImmutableKeyValueOptions are the options for ImmutableKeyValue container.
+type ImmutableKeyValueOptions func(*immutableKeyValueOptions)
+
+// WithImmutableHasher sets the Hasher used to place the keys in the trie of the ImmutableKeyValue container.
+// The default is NewHasher.
+func WithImmutableHasher[K comparable](hasher Hasher[K]) ImmutableKeyValueOptions {
+	return func(kv *immutableKeyValueOptions) {
+		kv.hasher = hasher
+	}
+}
+
+// ImmutableKeyValue is a generic, persistent and immutable key-value container.
+// This use a hash array mapped trie as underlying data structure. The changes, like With or Without,
+// return a new version in O(log n) that shares the unchanged parts of the trie with the previous one,
+// instead of copying it like Clone, and the previous version is not modified.
+// It is safe for concurrent use without locks. The values are not copied, so the values holding
+// pointers must not be modified. Use ImmutableKeyValueBuilder to make many changes at once.
+type ImmutableKeyValue[K comparable, T any] struct {
+	tree hamt[K, T]
+}
+
+// ImmutableKeyValueBuilder builds an ImmutableKeyValue container making the changes in place,
+// so a batch of changes copies each node of the trie at most once, instead of once per change.
+// It is not thread-safe.
+type ImmutableKeyValueBuilder[K comparable, T any] struct {
+	tree hamt[K, T]
+}
+
+// NewImmutableKeyValue returns a new empty ImmutableKeyValue container.
+func NewImmutableKeyValue[K comparable, T any](options ...ImmutableKeyValueOptions) *ImmutableKeyValue[K, T] {
+	kvo := immutableKeyValueOptions{}
+	for _, opt := range options {
+		opt(&kvo)
+	}
+
+	hash := NewHasher[K]()
+	if kvo.hasher != nil {
+		h, ok := kvo.hasher.(Hasher[K])
+		if !ok {
+			var key K
+			panic(fmt.Sprintf("r9e: the hasher doesn't match the container key type %T", key))
+		}
+		hash = h
+	}
+
+	return &ImmutableKeyValue[K, T]{tree: newHAMT[K, T](hash, false)}
+}
+
+// NewImmutableKeyValueBuilder returns a new ImmutableKeyValueBuilder of an empty container.
+func NewImmutableKeyValueBuilder[K comparable, T any](options ...ImmutableKeyValueOptions) *ImmutableKeyValueBuilder[K, T] {
+	return NewImmutableKeyValue[K, T](options...).Builder()
+}
+
+// With returns a new version of the container where the key is associated with the value.
+func (r *ImmutableKeyValue[K, T]) With(key K, value T) *ImmutableKeyValue[K, T] {
+	tree := r.tree
+	tree.set(key, value)
+	return &ImmutableKeyValue[K, T]{tree: tree}
+}
+
+// Without returns a new version of the container without the key.
+// If the key doesn't exist, the container is returned.
+func (r *ImmutableKeyValue[K, T]) Without(key K) *ImmutableKeyValue[K, T] {
+	tree := r.tree
+	if _, ok := tree.delete(key); !ok {
+		return r
+	}
+	return &ImmutableKeyValue[K, T]{tree: tree}
+}
+
+// Builder returns a new ImmutableKeyValueBuilder starting from the key-value pairs of the container.
+// It runs in constant time, the container is not modified by the builder.
+func (r *ImmutableKeyValue[K, T]) Builder() *ImmutableKeyValueBuilder[K, T] {
+	tree := r.tree
+	tree.owner = &hamtOwner{}
+	return &ImmutableKeyValueBuilder[K, T]{tree: tree}
+}
+
+// Set sets the value associated with the key.
+func (b *ImmutableKeyValueBuilder[K, T]) Set(key K, value T) *ImmutableKeyValueBuilder[K, T] {
+	b.tree.set(key, value)
+	return b
+}
+
+// Delete deletes the value associated with the key.
+func (b *ImmutableKeyValueBuilder[K, T]) Delete(key K) *ImmutableKeyValueBuilder[K, T] {
+	b.tree.delete(key)
+	return b
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (b *ImmutableKeyValueBuilder[K, T]) GetAndCheck(key K) (T, bool) {
+	return b.tree.get(key)
+}
+
+// Size returns the number of key-value pairs in the builder.
+func (b *ImmutableKeyValueBuilder[K, T]) Size() int {
+	return b.tree.size
+}
+
+// Build returns the ImmutableKeyValue container with the key-value pairs of the builder.
+// It runs in constant time, and the builder can still be used to build another version.
+func (b *ImmutableKeyValueBuilder[K, T]) Build() *ImmutableKeyValue[K, T] {
+	return &ImmutableKeyValue[K, T]{tree: b.tree.freeze()}
+}
+
+// empty returns a new ImmutableKeyValueBuilder of an empty container using the same hasher.
+func (r *ImmutableKeyValue[K, T]) empty() *ImmutableKeyValueBuilder[K, T] {
+	return &ImmutableKeyValueBuilder[K, T]{tree: newHAMT[K, T](r.tree.hash, true)}
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (r *ImmutableKeyValue[K, T]) GetAndCheck(key K) (T, bool) {
+	return r.tree.get(key)
+}
+
+// Get returns the value associated with the key.
+// If the key does not exist, return zero value of the type.
+func (r *ImmutableKeyValue[K, T]) Get(key K) T {
+	value, _ := r.tree.get(key)
+	return value
+}
+
+// Size returns the number of key-value pairs stored in the container.
+func (r *ImmutableKeyValue[K, T]) Size() int {
+	return r.tree.size
+}
+
+// IsEmpty returns true if the container is empty.
+func (r *ImmutableKeyValue[K, T]) IsEmpty() bool {
+	return r.Size() == 0
+}
+
+// IsFull returns true if the container has elements.
+func (r *ImmutableKeyValue[K, T]) IsFull() bool {
+	return r.Size() != 0
+}
+
+// ContainsKey returns true if the key is in the container.
+func (r *ImmutableKeyValue[K, T]) ContainsKey(key K) bool {
+	_, ok := r.tree.get(key)
+	return ok
+}
+
+// ContainsValue returns true if the value is in the container.
+func (r *ImmutableKeyValue[K, T]) ContainsValue(value T) bool {
+	for _, v := range r.All() {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the key value associated with the key.
+func (r *ImmutableKeyValue[K, T]) Key(key K) K {
+	if _, ok := r.tree.get(key); ok {
+		return key
+	}
+	var empty K
+	return empty
+}
+
+// Keys returns all keys stored in the container.
+func (r *ImmutableKeyValue[K, T]) Keys() []K {
+	keys := make([]K, 0, r.tree.size)
+	for key := range r.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns all values stored in the container.
+func (r *ImmutableKeyValue[K, T]) Values() []T {
+	values := make([]T, 0, r.tree.size)
+	for _, value := range r.All() {
+		values = append(values, value)
+	}
+	return values
+}
+
+// ForEach calls the given function for each key-value pair in the container.
+func (r *ImmutableKeyValue[K, T]) ForEach(fn func(key K, value T)) {
+	for key, value := range r.All() {
+		fn(key, value)
+	}
+}
+
+// ForEachKey calls the given function for each key in the container.
+func (r *ImmutableKeyValue[K, T]) ForEachKey(fn func(key K)) {
+	for key := range r.All() {
+		fn(key)
+	}
+}
+
+// ForEachValue calls the given function for each value in the container.
+func (r *ImmutableKeyValue[K, T]) ForEachValue(fn func(value T)) {
+	for _, value := range r.All() {
+		fn(value)
+	}
+}
+
+// DeepEqual returns true if the given kv is deep equal to the ImmutableKeyValue container.
+func (r *ImmutableKeyValue[K, T]) DeepEqual(kv *ImmutableKeyValue[K, T]) bool {
+	if r.Size() != kv.Size() {
+		return false
+	}
+	if r.tree.root == kv.tree.root {
+		// the same version, or both are empty
+		return true
+	}
+
+	for key, value := range r.All() {
+		v, ok := kv.GetAndCheck(key)
+		if !ok || !reflect.DeepEqual(v, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Map returns a new ImmutableKeyValue after applying the given function fn to each key-value pair.
+func (r *ImmutableKeyValue[K, T]) Map(fn func(key K, value T) (newKey K, newValue T)) *ImmutableKeyValue[K, T] {
+	b := r.empty()
+	for key, value := range r.All() {
+		b.Set(fn(key, value))
+	}
+	return b.Build()
+}
+
+// MapKey returns a new ImmutableKeyValue after applying the given function fn to each key.
+func (r *ImmutableKeyValue[K, T]) MapKey(fn func(key K) K) *ImmutableKeyValue[K, T] {
+	return r.Map(func(key K, value T) (K, T) {
+		return fn(key), value
+	})
+}
+
+// MapValue returns a new ImmutableKeyValue after applying the given function fn to each value.
+// The keys don't change, so the new container is built on the trie of the container.
+func (r *ImmutableKeyValue[K, T]) MapValue(fn func(value T) T) *ImmutableKeyValue[K, T] {
+	b := r.Builder()
+	for key, value := range r.All() {
+		b.Set(key, fn(value))
+	}
+	return b.Build()
+}
+
+// Filter returns a new ImmutableKeyValue after applying the given function fn to each key-value pair.
+// The key-value pairs not satisfying fn are removed from a version of the container, so the new
+// container shares the parts of the trie left unchanged.
+func (r *ImmutableKeyValue[K, T]) Filter(fn func(key K, value T) bool) *ImmutableKeyValue[K, T] {
+	b := r.Builder()
+	for key, value := range r.All() {
+		if !fn(key, value) {
+			b.Delete(key)
+		}
+	}
+	return b.Build()
+}
+
+// FilterKey returns a new ImmutableKeyValue after applying the given function fn to each key.
+func (r *ImmutableKeyValue[K, T]) FilterKey(fn func(key K) bool) *ImmutableKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// FilterValue returns a new ImmutableKeyValue after applying the given function fn to each value.
+func (r *ImmutableKeyValue[K, T]) FilterValue(fn func(value T) bool) *ImmutableKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// Partition returns two new ImmutableKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each element.
+func (r *ImmutableKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, others *ImmutableKeyValue[K, T]) {
+	m, o := r.Builder(), r.Builder()
+	for key, value := range r.All() {
+		if fn(key, value) {
+			o.Delete(key)
+		} else {
+			m.Delete(key)
+		}
+	}
+	return m.Build(), o.Build()
+}
+
+// PartitionKey returns two new ImmutableKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each key.
+func (r *ImmutableKeyValue[K, T]) PartitionKey(fn func(key K) bool) (match, others *ImmutableKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// PartitionValue returns two new ImmutableKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each value.
+func (r *ImmutableKeyValue[K, T]) PartitionValue(fn func(value T) bool) (match, others *ImmutableKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// SortKeys returns a []*K (keys) after sorting the keys using the given sortFn function.
+func (r *ImmutableKeyValue[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
+	keys := r.Keys()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return sortFn(keys[i], keys[j])
+	})
+
+	m := make([]*K, len(keys))
+	for i := range keys {
+		m[i] = &keys[i]
+	}
+	return m
+}
+
+// SortValues returns a []*T (values) after sorting the values using given function sortFn.
+func (r *ImmutableKeyValue[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
+	values := r.Values()
+
+	sort.Slice(values, func(i, j int) bool {
+		return sortFn(values[i], values[j])
+	})
+
+	m := make([]*T, len(values))
+	for i := range values {
+		m[i] = &values[i]
+	}
+	return m
+}
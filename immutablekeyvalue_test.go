@@ -0,0 +1,211 @@
+package r9e
+
+import (
+	"maps"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWith_ImmutableKeyValue(t *testing.T) {
+	t.Run("test With and Without return new versions", func(t *testing.T) {
+		empty := NewImmutableKeyValue[string, int]()
+		one := empty.With("one", 1)
+		two := one.With("two", 2)
+		updated := two.With("one", 10)
+		removed := updated.Without("two")
+
+		if !empty.IsEmpty() {
+			t.Errorf("Expected size to be %v, got %v", 0, empty.Size())
+		}
+		if one.Size() != 1 || one.Get("one") != 1 || one.ContainsKey("two") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one"}, one.Keys())
+		}
+		if two.Size() != 2 || two.Get("one") != 1 || two.Get("two") != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one", "two"}, two.Keys())
+		}
+		if updated.Get("one") != 10 || updated.Size() != 2 {
+			t.Errorf("Expected value to be %v, got %v", 10, updated.Get("one"))
+		}
+		if removed.Size() != 1 || removed.ContainsKey("two") || removed.Get("one") != 10 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one"}, removed.Keys())
+		}
+	})
+
+	t.Run("test Without a missing key returns the container", func(t *testing.T) {
+		kv := NewImmutableKeyValue[string, int]().With("one", 1)
+
+		if kv.Without("two") != kv {
+			t.Errorf("Expected Without to return the container")
+		}
+	})
+
+	t.Run("test the versions are safe for concurrent use", func(t *testing.T) {
+		base := NewImmutableKeyValue[int, int]()
+		for i := 0; i < 100; i++ {
+			base = base.With(i, i)
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				kv := base
+				for i := 0; i < 100; i++ {
+					kv = kv.With(i, w).Without(i + 50)
+				}
+				if kv.Get(10) != w {
+					t.Errorf("Expected value to be %v, got %v", w, kv.Get(10))
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		for i := 0; i < 100; i++ {
+			if base.Get(i) != i {
+				t.Fatalf("Expected value to be %v, got %v", i, base.Get(i))
+			}
+		}
+	})
+
+	t.Run("test colliding hashes", func(t *testing.T) {
+		kv := NewImmutableKeyValue[string, int](WithImmutableHasher(func(key string) uint64 { return 42 }))
+		for i := 0; i < 10; i++ {
+			kv = kv.With(strconv.Itoa(i), i)
+		}
+		kv = kv.Without("3")
+
+		if kv.Size() != 9 || kv.ContainsKey("3") || kv.Get("7") != 7 {
+			t.Errorf("Expected size to be %v, got %v", 9, kv.Size())
+		}
+	})
+
+	t.Run("test WithImmutableHasher with a different key type", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected NewImmutableKeyValue to panic")
+			}
+		}()
+		NewImmutableKeyValue[string, int](WithImmutableHasher(func(key int) uint64 { return 0 }))
+	})
+}
+
+func TestBuilder_ImmutableKeyValue(t *testing.T) {
+	t.Run("test Build returns the key-value pairs of the builder", func(t *testing.T) {
+		b := NewImmutableKeyValueBuilder[int, int]()
+		for i := 0; i < 1000; i++ {
+			b.Set(i, i)
+		}
+		b.Delete(0)
+
+		kv := b.Build()
+		if kv.Size() != 999 || kv.ContainsKey(0) || kv.Get(999) != 999 {
+			t.Errorf("Expected size to be %v, got %v", 999, kv.Size())
+		}
+	})
+
+	t.Run("test the builder doesn't modify the built versions", func(t *testing.T) {
+		base := NewImmutableKeyValue[string, int]().With("one", 1).With("two", 2)
+
+		b := base.Builder()
+		b.Set("one", 10).Delete("two").Set("three", 3)
+		first := b.Build()
+		b.Set("one", 100).Set("four", 4)
+		second := b.Build()
+
+		if base.Size() != 2 || base.Get("one") != 1 || base.Get("two") != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one", "two"}, base.Keys())
+		}
+		if first.Size() != 2 || first.Get("one") != 10 || first.Get("three") != 3 || first.ContainsKey("four") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one", "three"}, first.Keys())
+		}
+		if second.Size() != 3 || second.Get("one") != 100 || second.Get("four") != 4 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one", "three", "four"}, second.Keys())
+		}
+	})
+
+	t.Run("test NewImmutableKeyValueFromSeq", func(t *testing.T) {
+		kv := NewImmutableKeyValueFromSeq(maps.All(map[string]int{"one": 1, "two": 2}))
+
+		if kv.Size() != 2 || kv.Get("two") != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one", "two"}, kv.Keys())
+		}
+	})
+}
+
+func TestFilterMapPartition_ImmutableKeyValue(t *testing.T) {
+	b := NewImmutableKeyValueBuilder[int, int]()
+	for i := 0; i < 100; i++ {
+		b.Set(i, i)
+	}
+	kv := b.Build()
+
+	t.Run("test Filter", func(t *testing.T) {
+		even := kv.FilterValue(func(value int) bool {
+			return value%2 == 0
+		})
+
+		if even.Size() != 50 || even.ContainsKey(1) || !even.ContainsKey(2) {
+			t.Errorf("Expected size to be %v, got %v", 50, even.Size())
+		}
+		if kv.Size() != 100 {
+			t.Errorf("Expected size to be %v, got %v", 100, kv.Size())
+		}
+	})
+
+	t.Run("test Map", func(t *testing.T) {
+		doubled := kv.MapValue(func(value int) int {
+			return value * 2
+		})
+		shifted := kv.MapKey(func(key int) int {
+			return key + 1000
+		})
+
+		if doubled.Get(10) != 20 || kv.Get(10) != 10 {
+			t.Errorf("Expected value to be %v, got %v", 20, doubled.Get(10))
+		}
+		if shifted.Size() != 100 || shifted.Get(1010) != 10 || shifted.ContainsKey(10) {
+			t.Errorf("Expected value to be %v, got %v", 10, shifted.Get(1010))
+		}
+	})
+
+	t.Run("test Partition", func(t *testing.T) {
+		small, big := kv.PartitionKey(func(key int) bool {
+			return key < 10
+		})
+
+		if small.Size() != 10 || big.Size() != 90 || small.ContainsKey(10) || big.ContainsKey(9) {
+			t.Errorf("Expected sizes to be %v and %v, got %v and %v", 10, 90, small.Size(), big.Size())
+		}
+	})
+
+	t.Run("test DeepEqual", func(t *testing.T) {
+		if !kv.DeepEqual(kv.MapValue(func(value int) int { return value })) {
+			t.Errorf("Expected DeepEqual to be %v, got %v", true, false)
+		}
+		if kv.DeepEqual(kv.With(0, -1)) {
+			t.Errorf("Expected DeepEqual to be %v, got %v", false, true)
+		}
+	})
+
+	t.Run("test SortKeys and SortValues", func(t *testing.T) {
+		keys := kv.SortKeys(func(key1, key2 int) bool {
+			return key1 > key2
+		})
+		values := kv.SortValues(func(value1, value2 int) bool {
+			return value1 < value2
+		})
+
+		if *keys[0] != 99 || *values[0] != 0 || len(keys) != 100 {
+			t.Errorf("Expected first key and value to be %v and %v, got %v and %v", 99, 0, *keys[0], *values[0])
+		}
+
+		sorted := kv.Keys()
+		sort.Ints(sorted)
+		if sorted[99] != 99 {
+			t.Errorf("Expected key to be %v, got %v", 99, sorted[99])
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package r9e
+
+import (
+	"iter"
+	"maps"
+)
+
+// iterChunkSize is the number of key-value pairs copied at a time by the iterators of MapKeyValue
+// and the ordered containers, which release the lock between the chunks.
+const iterChunkSize = 64
+
+// chunks returns an iterator over the key-value pairs returned by next, which copies at most
+// limit of them holding the lock of the container, starting after the key after, or from the
+// beginning if it is nil. The loop runs without holding the lock, and it only copies the chunks
+// it reaches.
+func chunks[K comparable, T any](next func(after *K, limit int) []kv[K, T]) iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		var after *K
+		for {
+			chunk := next(after, iterChunkSize)
+			for _, pair := range chunk {
+				if !yield(pair.key, pair.value) {
+					return
+				}
+			}
+
+			if len(chunk) < iterChunkSize {
+				return
+			}
+			after = &chunk[len(chunk)-1].key
+		}
+	}
+}
+
+// NewMapKeyValueFromSeq returns a new MapKeyValue container with the key-value pairs of seq.
+// If a key is repeated, the last value is kept.
+func NewMapKeyValueFromSeq[K comparable, T any](seq iter.Seq2[K, T], options ...MapKeyValueOptions) *MapKeyValue[K, T] {
+	kv := NewMapKeyValue[K, T](options...)
+	for key, value := range seq {
+		kv.Set(key, value)
+	}
+	return kv
+}
+
+// NewSMapKeyValueFromSeq returns a new SMapKeyValue container with the key-value pairs of seq.
+// If a key is repeated, the last value is kept.
+func NewSMapKeyValueFromSeq[K comparable, T any](seq iter.Seq2[K, T], options ...SMapKeyValueOptions) *SMapKeyValue[K, T] {
+	kv := NewSMapKeyValue[K, T](options...)
+	for key, value := range seq {
+		kv.Set(key, value)
+	}
+	return kv
+}
+
+// NewShardedMapKeyValueFromSeq returns a new ShardedMapKeyValue container with the key-value pairs of seq.
+// If a key is repeated, the last value is kept.
+func NewShardedMapKeyValueFromSeq[K comparable, T any](seq iter.Seq2[K, T], options ...ShardedMapKeyValueOptions) *ShardedMapKeyValue[K, T] {
+	kv := NewShardedMapKeyValue[K, T](options...)
+	for key, value := range seq {
+		kv.Set(key, value)
+	}
+	return kv
+}
+
+// NewImmutableKeyValueFromSeq returns a new ImmutableKeyValue container with the key-value pairs of seq.
+// If a key is repeated, the last value is kept.
+func NewImmutableKeyValueFromSeq[K comparable, T any](seq iter.Seq2[K, T], options ...ImmutableKeyValueOptions) *ImmutableKeyValue[K, T] {
+	b := NewImmutableKeyValueBuilder[K, T](options...)
+	for key, value := range seq {
+		b.Set(key, value)
+	}
+	return b.Build()
+}
+
+// All returns an iterator over the key-value pairs of the container, in no particular order.
+// The iteration copies iterChunkSize key-value pairs at a time holding the read lock, and the loop
+// runs without holding it, so it can modify the container, and a loop that stops early doesn't
+// copy the rest. It has the same guarantees as range over a map: each key stored during the
+// whole iteration is visited once, the keys added or removed meanwhile may or may not be visited,
+// and Clear stops the iteration. When the container maintains the persistent copy used by Snapshot,
+// see WithSnapshots, the iteration runs on a Snapshot instead, so the changes are not seen.
+func (r *MapKeyValue[K, T]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		r.mu.RLock()
+		data, resets, snapshots := r.data, r.resets, r.tree != nil
+		r.mu.RUnlock()
+
+		if snapshots {
+			r.Snapshot().All()(yield)
+			return
+		}
+
+		next, stop := iter.Pull2(maps.All(data))
+		defer stop()
+
+		for {
+			chunk, more := r.chunk(next, resets)
+			for _, pair := range chunk {
+				if !yield(pair.key, pair.value) {
+					return
+				}
+			}
+
+			if !more {
+				return
+			}
+		}
+	}
+}
+
+// chunk copies the next iterChunkSize key-value pairs returned by next, which walks the map of the
+// container, holding the read lock. It returns false when the map is walked, or when the container
+// was cleared since resets, which replaced the map.
+func (r *MapKeyValue[K, T]) chunk(next func() (K, T, bool), resets uint64) ([]kv[K, T], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.resets != resets {
+		return nil, false
+	}
+
+	pairs := make([]kv[K, T], 0, iterChunkSize)
+	for len(pairs) < iterChunkSize {
+		key, value, ok := next()
+		if !ok {
+			return pairs, false
+		}
+		if !r.expired(key) {
+			pairs = append(pairs, kv[K, T]{key, value})
+		}
+	}
+	return pairs, true
+}
+
+// KeysSeq returns an iterator over the keys of the container, in no particular order.
+// It has the same guarantees as All.
+func (r *MapKeyValue[K, T]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range r.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the container, in no particular order.
+// It has the same guarantees as All.
+func (r *MapKeyValue[K, T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, value := range r.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the key-value pairs of the container, in no particular order.
+// The iteration doesn't lock the container and it has the same guarantees as sync.Map.Range:
+// each key is visited once, but the changes done during the iteration may or may not be seen.
+// The loop can modify the container. Use Snapshot().All() for a point-in-time iteration.
+func (r *SMapKeyValue[K, T]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		r.data().Range(func(key, value any) bool {
+			return yield(key.(K), *value.(*T))
+		})
+	}
+}
+
+// KeysSeq returns an iterator over the keys of the container, in no particular order.
+// It has the same guarantees as All.
+func (r *SMapKeyValue[K, T]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		r.data().Range(func(key, value any) bool {
+			return yield(key.(K))
+		})
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the container, in no particular order.
+// It has the same guarantees as All.
+func (r *SMapKeyValue[K, T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		r.data().Range(func(key, value any) bool {
+			return yield(*value.(*T))
+		})
+	}
+}
+
+// All returns an iterator over the key-value pairs of the container, in no particular order.
+// The shards are iterated one after another, each one on a snapshot taken when its iteration
+// starts, so the loop can modify the container. Each shard is seen consistently, but the
+// container can change between shards.
+func (r *ShardedMapKeyValue[K, T]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		for _, shard := range r.shards {
+			for key, value := range shard.All() {
+				if !yield(key, value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// KeysSeq returns an iterator over the keys of the container, in no particular order.
+// It has the same guarantees as All.
+func (r *ShardedMapKeyValue[K, T]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range r.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the container, in no particular order.
+// It has the same guarantees as All.
+func (r *ShardedMapKeyValue[K, T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, value := range r.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the key-value pairs of the container, in no particular order.
+// The container is immutable, so every iteration sees the same key-value pairs.
+func (r *ImmutableKeyValue[K, T]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		r.tree.all(yield)
+	}
+}
+
+// KeysSeq returns an iterator over the keys of the container, in no particular order.
+func (r *ImmutableKeyValue[K, T]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range r.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the container, in no particular order.
+func (r *ImmutableKeyValue[K, T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, value := range r.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
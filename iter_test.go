@@ -0,0 +1,265 @@
+package r9e
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestIterators(t *testing.T) {
+	m := map[string]int{"one": 1, "two": 2, "three": 3, "four": 4}
+
+	containers := []struct {
+		name string
+		kv   interface {
+			All() iter.Seq2[string, int]
+			KeysSeq() iter.Seq[string]
+			ValuesSeq() iter.Seq[int]
+		}
+	}{
+		{"MapKeyValue", NewMapKeyValueFromSeq(maps.All(m))},
+		{"SMapKeyValue", NewSMapKeyValueFromSeq(maps.All(m))},
+		{"ShardedMapKeyValue", NewShardedMapKeyValueFromSeq(maps.All(m), WithShards(2))},
+	}
+
+	for _, c := range containers {
+		t.Run("test All, KeysSeq and ValuesSeq for "+c.name, func(t *testing.T) {
+			if got := maps.Collect(c.kv.All()); !maps.Equal(got, m) {
+				t.Errorf("Expected All to be %v, got %v", m, got)
+			}
+
+			keys := slices.Sorted(c.kv.KeysSeq())
+			if expected := slices.Sorted(maps.Keys(m)); !slices.Equal(keys, expected) {
+				t.Errorf("Expected KeysSeq to be %v, got %v", expected, keys)
+			}
+
+			values := slices.Sorted(c.kv.ValuesSeq())
+			if expected := []int{1, 2, 3, 4}; !slices.Equal(values, expected) {
+				t.Errorf("Expected ValuesSeq to be %v, got %v", expected, values)
+			}
+		})
+
+		t.Run("test All, KeysSeq and ValuesSeq stop early for "+c.name, func(t *testing.T) {
+			count := 0
+			for range c.kv.All() {
+				count++
+				break
+			}
+			for range c.kv.KeysSeq() {
+				count++
+				break
+			}
+			for range c.kv.ValuesSeq() {
+				count++
+				break
+			}
+
+			if count != 3 {
+				t.Errorf("Expected count to be %v, got %v", 3, count)
+			}
+		})
+	}
+
+	t.Run("test All of MapKeyValue skips the expired keys", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.Set("one", 1)
+		kv.SetWithTTL("two", 2, 1)
+		clock.Advance(2)
+
+		if got := maps.Collect(kv.All()); !maps.Equal(got, map[string]int{"one": 1}) {
+			t.Errorf("Expected All to be %v, got %v", map[string]int{"one": 1}, got)
+		}
+	})
+
+	t.Run("test MapKeyValue can be modified during the iteration", func(t *testing.T) {
+		kv := NewMapKeyValueFromSeq(maps.All(m))
+
+		visited := make(map[string]int)
+		for key := range kv.All() {
+			visited[key]++
+			if _, ok := m[key]; ok {
+				kv.Delete(key)
+				kv.Set(key+"+", 0)
+			}
+		}
+
+		for key := range m {
+			if visited[key] != 1 {
+				t.Errorf("Expected key %v to be visited once, got %v", key, visited[key])
+			}
+		}
+		if kv.Size() != len(m) {
+			t.Errorf("Expected size to be %v, got %v", len(m), kv.Size())
+		}
+	})
+
+	t.Run("test All of MapKeyValue stops when the container is cleared", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int]()
+		for i := 0; i < 3*iterChunkSize; i++ {
+			kv.Set(i, i)
+		}
+
+		count := 0
+		for range kv.All() {
+			count++
+			kv.Clear()
+			kv.Set(-1, -1)
+		}
+
+		if count != iterChunkSize {
+			t.Errorf("Expected count to be %v, got %v", iterChunkSize, count)
+		}
+	})
+
+	t.Run("test All of MapKeyValue with concurrent writers", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int]()
+		for i := 0; i < 4*iterChunkSize; i++ {
+			kv.Set(i, i)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 1000; i++ {
+				kv.Set(i%(8*iterChunkSize), i)
+				kv.Delete((i + 1) % (8 * iterChunkSize))
+			}
+		}()
+
+		for range 10 {
+			for range kv.All() {
+			}
+		}
+		<-done
+	})
+
+	t.Run("test All of MapKeyValue doesn't create the persistent copy used by Snapshot", func(t *testing.T) {
+		kv := NewMapKeyValueFromSeq(maps.All(m))
+
+		for range kv.All() {
+		}
+
+		if kv.tree != nil {
+			t.Errorf("Expected the persistent copy not to be created by All")
+		}
+
+		kv = NewMapKeyValueFromSeq(maps.All(m), WithSnapshots())
+		if got := maps.Collect(kv.All()); !maps.Equal(got, m) {
+			t.Errorf("Expected All to be %v, got %v", m, got)
+		}
+	})
+
+	t.Run("test SMapKeyValue can be modified during the iteration", func(t *testing.T) {
+		kv := NewSMapKeyValueFromSeq(maps.All(m))
+
+		for key := range kv.KeysSeq() {
+			kv.Delete(key)
+		}
+
+		if kv.ContainsKey("one") {
+			t.Errorf("Expected keys to be deleted, got %v", kv.Keys())
+		}
+	})
+}
+
+func TestIterators_Chunks(t *testing.T) {
+	const n = 5*iterChunkSize + 3
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%04d", i)
+	}
+
+	containers := []struct {
+		name string
+		kv   interface {
+			All() iter.Seq2[string, int]
+			Set(key string, value int)
+			Delete(key string)
+			Size() int
+		}
+	}{
+		{"OrderedMapKeyValue", NewOrderedMapKeyValue[string, int]()},
+		{"SortedMapKeyValue", NewSortedMapKeyValue[string, int]()},
+		{"RadixKeyValue", NewRadixKeyValue[int]()},
+	}
+
+	for _, c := range containers {
+		t.Run("test All visits each key once while the loop modifies the container for "+c.name, func(t *testing.T) {
+			for i, key := range keys {
+				c.kv.Set(key, i)
+			}
+
+			var visited []string
+			for key, value := range c.kv.All() {
+				if keys[value] != key {
+					t.Fatalf("Expected value of key %v to be %v, got %v", key, key, keys[value])
+				}
+				visited = append(visited, key)
+
+				// the deleted key is the position of the iteration, the new key is before it
+				c.kv.Delete(key)
+				c.kv.Set("-"+key, value)
+			}
+
+			if !slices.Equal(visited, keys) {
+				t.Errorf("Expected visited keys to be %v, got %v", len(keys), len(visited))
+			}
+			if c.kv.Size() != n {
+				t.Errorf("Expected size to be %v, got %v", n, c.kv.Size())
+			}
+		})
+	}
+
+	t.Run("test All of OrderedMapKeyValue visits the moved keys once", func(t *testing.T) {
+		kv := NewOrderedMapKeyValue[string, int]()
+		for i, key := range keys {
+			kv.Set(key, i)
+		}
+
+		var visited []string
+		for key := range kv.All() {
+			visited = append(visited, key)
+			kv.MoveToBack(key)
+			kv.MoveToFront(keys[n-1])
+		}
+
+		if len(visited) != n-1 || !slices.Equal(visited, keys[:n-1]) {
+			t.Errorf("Expected visited keys to be %v, got %v", n-1, len(visited))
+		}
+	})
+
+	t.Run("test Backward and Range of SortedMapKeyValue", func(t *testing.T) {
+		kv := NewSortedMapKeyValue[string, int]()
+		for i, key := range keys {
+			kv.Set(key, i)
+		}
+
+		var backward []string
+		for key := range kv.Backward() {
+			backward = append(backward, key)
+			kv.Delete(key)
+		}
+		slices.Reverse(backward)
+		if !slices.Equal(backward, keys) {
+			t.Errorf("Expected visited keys to be %v, got %v", len(keys), len(backward))
+		}
+
+		for i, key := range keys {
+			kv.Set(key, i)
+		}
+		var ranged []string
+		for key := range kv.Range(keys[10], keys[n-10]) {
+			ranged = append(ranged, key)
+		}
+		if !slices.Equal(ranged, keys[10:n-10]) {
+			t.Errorf("Expected visited keys to be %v, got %v", n-20, len(ranged))
+		}
+	})
+}
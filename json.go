@@ -0,0 +1,70 @@
+package r9e
+
+import (
+	"encoding/json"
+)
+
+// MarshalJSON implements json.Marshaler. The container is encoded as a JSON object, the same as a map[K]T,
+// so the keys must be strings, integers or implement encoding.TextMarshaler.
+// The keys are sorted, so the encoding is deterministic. Expired key-value pairs are not encoded.
+func (r *MapKeyValue[K, T]) MarshalJSON() ([]byte, error) {
+	r.mu.RLock()
+	m := make(map[K]T, len(r.data))
+	for key, value := range r.data {
+		if r.expired(key) {
+			continue
+		}
+		m[key] = value
+	}
+	r.mu.RUnlock()
+
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The content of the container is replaced with the key-value
+// pairs of the JSON object, the same as a map[K]T. The container is not modified if the data is invalid.
+// The zero value of MapKeyValue can be used as destination.
+func (r *MapKeyValue[K, T]) UnmarshalJSON(data []byte) error {
+	var m map[K]T
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reset()
+	expireAt := r.deadline(r.defaultTTL)
+	for key, value := range m {
+		r.set(key, value, expireAt)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The container is encoded as a JSON object, the same as a map[K]T,
+// so the keys must be strings, integers or implement encoding.TextMarshaler.
+// The keys are sorted, so the encoding is deterministic.
+func (r *SMapKeyValue[K, T]) MarshalJSON() ([]byte, error) {
+	m := make(map[K]T, r.Size())
+	r.ForEach(func(key K, value T) {
+		m[key] = value
+	})
+
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The content of the container is replaced with the key-value
+// pairs of the JSON object, the same as a map[K]T. The container is not modified if the data is invalid.
+// The zero value of SMapKeyValue can be used as destination.
+func (r *SMapKeyValue[K, T]) UnmarshalJSON(data []byte) error {
+	var m map[K]T
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	// the content is replaced at once, so the readers don't see it partially decoded
+	r.Swap(m)
+	return nil
+}
@@ -0,0 +1,204 @@
+package r9e
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// point is a key type encoded as JSON object key through encoding.TextMarshaler.
+type point struct {
+	X, Y int
+}
+
+func (p point) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func (p *point) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestJSON_MapKeyValue(t *testing.T) {
+	t.Run("test MarshalJSON for MapKeyValue[string, struct] sorts the keys", func(t *testing.T) {
+		kv := NewMapKeyValue[string, snapshotTestStruct]()
+		kv.Set("pi", snapshotTestStruct{"Archimedes' constant", 3.141592})
+		kv.Set("e", snapshotTestStruct{"Euler number, Napier's constant", 2.718281})
+
+		data, err := json.Marshal(kv)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		expected := `{"e":{"Name":"Euler number, Napier's constant","Value":2.718281},"pi":{"Name":"Archimedes' constant","Value":3.141592}}`
+		if string(data) != expected {
+			t.Errorf("Expected JSON to be %v, got %v", expected, string(data))
+		}
+	})
+
+	t.Run("test MarshalJSON and UnmarshalJSON for MapKeyValue[int, string]", func(t *testing.T) {
+		kv := NewMapKeyValue[int, string]()
+		kv.Set(10, "ten")
+		kv.Set(2, "two")
+		kv.Set(-1, "minus one")
+
+		data, err := json.Marshal(kv)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		expected := `{"-1":"minus one","10":"ten","2":"two"}`
+		if string(data) != expected {
+			t.Errorf("Expected JSON to be %v, got %v", expected, string(data))
+		}
+
+		var decoded MapKeyValue[int, string]
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if !kv.DeepEqual(&decoded) {
+			t.Errorf("Expected decoded container to be equal to the original")
+		}
+	})
+
+	t.Run("test MarshalJSON and UnmarshalJSON for MapKeyValue with encoding.TextMarshaler keys", func(t *testing.T) {
+		kv := NewMapKeyValue[point, string]()
+		kv.Set(point{1, 2}, "a")
+		kv.Set(point{0, 5}, "b")
+
+		data, err := json.Marshal(kv)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		expected := `{"0,5":"b","1,2":"a"}`
+		if string(data) != expected {
+			t.Errorf("Expected JSON to be %v, got %v", expected, string(data))
+		}
+
+		decoded := NewMapKeyValue[point, string]()
+		if err := json.Unmarshal(data, decoded); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if decoded.Get(point{1, 2}) != "a" {
+			t.Errorf("Expected value to be %v, got %v", "a", decoded.Get(point{1, 2}))
+		}
+	})
+
+	t.Run("test UnmarshalJSON replaces the content of the container", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("old", 1)
+
+		if err := json.Unmarshal([]byte(`{"new":2}`), kv); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if kv.ContainsKey("old") || kv.Get("new") != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"new"}, kv.Keys())
+		}
+	})
+
+	t.Run("test UnmarshalJSON with invalid data doesn't modify the container", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("old", 1)
+
+		if err := json.Unmarshal([]byte(`{"new":"two"}`), kv); err == nil {
+			t.Errorf("Expected UnmarshalJSON to fail")
+		}
+
+		if kv.Size() != 1 || kv.Get("old") != 1 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"old"}, kv.Keys())
+		}
+	})
+
+	t.Run("test MarshalJSON with unsupported key type", func(t *testing.T) {
+		kv := NewMapKeyValue[TestStruct, int]()
+		kv.Set(TestStruct{a: "a", b: 1}, 1)
+
+		if _, err := json.Marshal(kv); err == nil {
+			t.Errorf("Expected MarshalJSON to fail")
+		}
+	})
+}
+
+func TestJSON_SMapKeyValue(t *testing.T) {
+	t.Run("test MarshalJSON and UnmarshalJSON for SMapKeyValue[string, int]", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+		kv.Set("c", 3)
+		kv.Set("a", 1)
+		kv.Set("b", 2)
+
+		data, err := json.Marshal(kv)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		expected := `{"a":1,"b":2,"c":3}`
+		if string(data) != expected {
+			t.Errorf("Expected JSON to be %v, got %v", expected, string(data))
+		}
+
+		var decoded SMapKeyValue[string, int]
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if decoded.Size() != 3 || decoded.Get("b") != 2 {
+			t.Errorf("Expected values to be %v, got %v", kv.Values(), decoded.Values())
+		}
+	})
+
+	t.Run("test UnmarshalJSON as field of a struct", func(t *testing.T) {
+		var config struct {
+			Limits *SMapKeyValue[string, int] `json:"limits"`
+		}
+
+		if err := json.Unmarshal([]byte(`{"limits":{"cpu":2,"memory":512}}`), &config); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if config.Limits.Get("memory") != 512 {
+			t.Errorf("Expected value to be %v, got %v", 512, config.Limits.Get("memory"))
+		}
+	})
+
+	t.Run("test UnmarshalJSON replaces the content at once", func(t *testing.T) {
+		kv := NewSMapKeyValue[int, int]()
+		m := make(map[int]int)
+		for i := 0; i < 100; i++ {
+			kv.Set(i+100, i)
+			m[i] = i
+		}
+
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				if err := json.Unmarshal(data, kv); err != nil {
+					t.Errorf("Expected error to be %v, got %v", nil, err)
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				if kv.ContainsKey(100) || kv.Get(42) != 42 {
+					t.Errorf("Expected keys to be %v, got %v", 100, kv.Size())
+				}
+				return
+			default:
+			}
+			if kv.Size() != 100 {
+				t.Fatalf("Expected size to be %v, got %v", 100, kv.Size())
+			}
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package r9e
+
+import (
+	"iter"
+)
+
+// Reader is implemented by the containers to read key-value pairs.
+type Reader[K comparable, T any] interface {
+	Get(key K) T
+	GetAndCheck(key K) (T, bool)
+	ContainsKey(key K) bool
+	ContainsValue(value T) bool
+	Key(key K) K
+	Size() int
+	IsEmpty() bool
+	IsFull() bool
+}
+
+// Writer is implemented by the containers to modify key-value pairs.
+type Writer[K comparable, T any] interface {
+	Set(key K, value T)
+	GetAnDelete(key K) (T, bool)
+	Delete(key K)
+	Clear()
+}
+
+// Iterable is implemented by the containers to iterate over their key-value pairs.
+type Iterable[K comparable, T any] interface {
+	Keys() []K
+	Values() []T
+	ForEach(fn func(key K, value T))
+	ForEachKey(fn func(key K))
+	ForEachValue(fn func(value T))
+	All() iter.Seq2[K, T]
+	KeysSeq() iter.Seq[K]
+	ValuesSeq() iter.Seq[T]
+}
+
+// KeyValue is implemented by every key-value container, so the code using it can switch
+// between them. The methods returning a new container, like Clone or Filter, are not
+// included because their result type depends on the container.
+type KeyValue[K comparable, T any] interface {
+	Reader[K, T]
+	Writer[K, T]
+	Iterable[K, T]
+}
+
+var (
+	_ KeyValue[string, any] = (*MapKeyValue[string, any])(nil)
+	_ KeyValue[string, any] = (*SMapKeyValue[string, any])(nil)
+	_ KeyValue[string, any] = (*ShardedMapKeyValue[string, any])(nil)
+	_ KeyValue[string, any] = (*OrderedMapKeyValue[string, any])(nil)
+	_ KeyValue[string, any] = (*SortedMapKeyValue[string, any])(nil)
+	_ KeyValue[string, any] = (*RadixKeyValue[any])(nil)
+)
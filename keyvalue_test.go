@@ -0,0 +1,1613 @@
+package r9e
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// constant is the value type used by the KeyValue conformance tests.
+type constant struct {
+	Name  string
+	value float64
+}
+
+var constants = map[string]constant{
+	"Archimedes":   {"This is Archimedes' Constant (Pi)", 3.1415},
+	"Euler":        {"This is Euler's Number (e)", 2.7182},
+	"Golden Ratio": {"This is The Golden Ratio", 1.6180},
+}
+
+// conformant is implemented by the containers checked by the conformance tests: the KeyValue
+// methods, and the methods returning a new container of the same type C.
+type conformant[C any] interface {
+	KeyValue[string, constant]
+	Clone() C
+	CloneAndClear() C
+	DeepEqual(kv C) bool
+	Map(fn func(key string, value constant) (newKey string, newValue constant)) C
+	MapKey(fn func(key string) string) C
+	MapValue(fn func(value constant) constant) C
+	Filter(fn func(key string, value constant) bool) C
+	FilterKey(fn func(key string) bool) C
+	FilterValue(fn func(value constant) bool) C
+	Partition(fn func(key string, value constant) bool) (match, others C)
+	PartitionKey(fn func(key string) bool) (match, others C)
+	PartitionValue(fn func(value constant) bool) (match, others C)
+	SortKeys(sortFn func(key1, key2 string) bool) []*string
+	SortValues(sortFn func(value1, value2 constant) bool) []*constant
+}
+
+func TestKeyValue(t *testing.T) {
+	t.Run("MapKeyValue", func(t *testing.T) {
+		testKeyValue(t, func() *MapKeyValue[string, constant] { return NewMapKeyValue[string, constant]() })
+	})
+	t.Run("SMapKeyValue", func(t *testing.T) {
+		testKeyValue(t, func() *SMapKeyValue[string, constant] { return NewSMapKeyValue[string, constant]() })
+	})
+	t.Run("ShardedMapKeyValue", func(t *testing.T) {
+		testKeyValue(t, func() *ShardedMapKeyValue[string, constant] { return NewShardedMapKeyValue[string, constant]() })
+	})
+	t.Run("OrderedMapKeyValue", func(t *testing.T) {
+		testKeyValue(t, func() *OrderedMapKeyValue[string, constant] { return NewOrderedMapKeyValue[string, constant]() })
+	})
+	t.Run("SortedMapKeyValue", func(t *testing.T) {
+		testKeyValue(t, func() *SortedMapKeyValue[string, constant] { return NewSortedMapKeyValue[string, constant]() })
+	})
+	t.Run("RadixKeyValue", func(t *testing.T) {
+		testKeyValue(t, func() *RadixKeyValue[constant] { return NewRadixKeyValue[constant]() })
+	})
+}
+
+// testKeyValue runs the conformance tests on the containers returned by newKV, so every
+// container is checked by the same assertions.
+func testKeyValue[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("Set", func(t *testing.T) { testSet(t, newKV) })
+	t.Run("GetAndCheck", func(t *testing.T) { testGetAndCheck(t, newKV) })
+	t.Run("Get", func(t *testing.T) { testGet(t, newKV) })
+	t.Run("GetAnDelete", func(t *testing.T) { testGetAnDelete(t, newKV) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newKV) })
+	t.Run("Clear", func(t *testing.T) { testClear(t, newKV) })
+	t.Run("Size", func(t *testing.T) { testSize(t, newKV) })
+	t.Run("IsEmpty", func(t *testing.T) { testIsEmpty(t, newKV) })
+	t.Run("IsFull", func(t *testing.T) { testIsFull(t, newKV) })
+	t.Run("ContainsKey", func(t *testing.T) { testContainsKey(t, newKV) })
+	t.Run("ContainsValue", func(t *testing.T) { testContainsValue(t, newKV) })
+	t.Run("Key", func(t *testing.T) { testKey(t, newKV) })
+	t.Run("Keys", func(t *testing.T) { testKeys(t, newKV) })
+	t.Run("Values", func(t *testing.T) { testValues(t, newKV) })
+	t.Run("Each", func(t *testing.T) { testEach(t, newKV) })
+	t.Run("EachKey", func(t *testing.T) { testEachKey(t, newKV) })
+	t.Run("EachValue", func(t *testing.T) { testEachValue(t, newKV) })
+	t.Run("All", func(t *testing.T) { testAll(t, newKV) })
+	t.Run("Clone", func(t *testing.T) { testClone(t, newKV) })
+	t.Run("CloneAndClear", func(t *testing.T) { testCloneAndClear(t, newKV) })
+	t.Run("DeepEqual", func(t *testing.T) { testDeepEqual(t, newKV) })
+	t.Run("Map", func(t *testing.T) { testMap(t, newKV) })
+	t.Run("MapKey", func(t *testing.T) { testMapKey(t, newKV) })
+	t.Run("MapValue", func(t *testing.T) { testMapValue(t, newKV) })
+	t.Run("Filter", func(t *testing.T) { testFilter(t, newKV) })
+	t.Run("FilterKey", func(t *testing.T) { testFilterKey(t, newKV) })
+	t.Run("FilterValue", func(t *testing.T) { testFilterValue(t, newKV) })
+	t.Run("Partition", func(t *testing.T) { testPartition(t, newKV) })
+	t.Run("PartitionKey", func(t *testing.T) { testPartitionKey(t, newKV) })
+	t.Run("PartitionValue", func(t *testing.T) { testPartitionValue(t, newKV) })
+	t.Run("SortKeys", func(t *testing.T) { testSortKeys(t, newKV) })
+	t.Run("SortValues", func(t *testing.T) { testSortValues(t, newKV) })
+}
+
+// testSet checks Set on the containers returned by newKV.
+func testSet[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Set key exist", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		value := kv.Get("Archimedes")
+
+		if value.Name != "This is Archimedes' Constant (Pi)" {
+			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
+		}
+		if value.value != 3.1415 {
+			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
+		}
+	})
+}
+
+// testGetAndCheck checks GetAndCheck on the containers returned by newKV.
+func testGetAndCheck[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test GetAndCheck key exist", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		value, ok := kv.GetAndCheck("Archimedes")
+		if !ok {
+			t.Errorf("Expected GetAndCheck to return true, got %v", ok)
+		}
+
+		if value.Name != "This is Archimedes' Constant (Pi)" {
+			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
+		}
+		if value.value != 3.1415 {
+			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
+		}
+	})
+
+	t.Run("test GetAndCheck key doesn't exist", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		_, ok := kv.GetAndCheck("Euler")
+		if ok {
+			t.Errorf("Expected GetAndCheck to return true, got %v", ok)
+		}
+	})
+}
+
+// testGet checks Get on the containers returned by newKV.
+func testGet[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Get key exist", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		value := kv.Get("Archimedes")
+
+		if value.Name != "This is Archimedes' Constant (Pi)" {
+			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
+		}
+		if value.value != 3.1415 {
+			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
+		}
+	})
+
+	t.Run("test Get key doesn't exist", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		value := kv.Get("Euler")
+		if value.value != 0 {
+			t.Errorf("Expected value to be %v, got %v", nil, value)
+		}
+	})
+}
+
+// testGetAnDelete checks GetAnDelete on the containers returned by newKV.
+func testGetAnDelete[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test GetAnDelete key exist", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		value, ok := kv.GetAnDelete("Archimedes")
+		if !ok {
+			t.Errorf("Expected GetAnDelete to return true, got %v", ok)
+		}
+
+		if value.Name != "This is Archimedes' Constant (Pi)" {
+			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
+		}
+		if value.value != 3.1415 {
+			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
+		}
+
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+	})
+
+	t.Run("test GetAnDelete key doesn't exist", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		value, ok := kv.GetAnDelete("Euler")
+		if ok {
+			t.Errorf("Expected GetAnDelete to return true, got %v", ok)
+		}
+
+		if value.value != 0 {
+			t.Errorf("Expected value to be %v, got %v", nil, value)
+		}
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+	})
+}
+
+// testDelete checks Delete on the containers returned by newKV.
+func testDelete[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Delete with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		value := kv.Get("Archimedes")
+
+		if value.Name != "This is Archimedes' Constant (Pi)" {
+			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
+		}
+		if value.value != 3.1415 {
+			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
+		}
+
+		kv.Delete("Archimedes")
+
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+
+		value = kv.Get("Golden Ratio")
+
+		if value.Name != "This is The Golden Ratio" {
+			t.Errorf("Expected value to be %s, got %s", "This is The Golden Ratio", value.Name)
+		}
+		if value.value != 1.6180 {
+			t.Errorf("Expected value to be %v, got %v", 1.6180, value.value)
+		}
+	})
+
+	t.Run("test Delete without keys", func(t *testing.T) {
+		kv := newKV()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+
+		kv.Delete("Archimedes")
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+}
+
+// testClear checks Clear on the containers returned by newKV.
+func testClear[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Clear with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		value := kv.Get("Archimedes")
+
+		if value.Name != "This is Archimedes' Constant (Pi)" {
+			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
+		}
+		if value.value != 3.1415 {
+			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
+		}
+
+		kv.Clear()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+
+	t.Run("test Clear without keys", func(t *testing.T) {
+		kv := newKV()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+
+		kv.Clear()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+}
+
+// testSize checks Size on the containers returned by newKV.
+func testSize[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Size with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		kv.Delete("Archimedes")
+
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+
+		kv.Delete("Euler")
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		kv.Clear()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+
+	t.Run("test Size without keys", func(t *testing.T) {
+		kv := newKV()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+
+		kv.Delete("Euler")
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+
+	t.Run("test Size after overwrite", func(t *testing.T) {
+		kv := newKV()
+		for key, value := range constants {
+			kv.Set(key, value)
+		}
+
+		for key := range constants {
+			kv.Set(key, constant{"overwritten", 0})
+		}
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+		if len(kv.Keys()) != 3 {
+			t.Errorf("Expected keys to be %v, got %v", 3, len(kv.Keys()))
+		}
+	})
+}
+
+// testIsEmpty checks IsEmpty on the containers returned by newKV.
+func testIsEmpty[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test IsEmpty with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		if kv.IsEmpty() != false {
+			t.Errorf("Expected IsEmpty to be %v, got %v", false, kv.IsEmpty())
+		}
+
+		kv.Clear()
+
+		if kv.IsEmpty() != true {
+			t.Errorf("Expected IsEmpty to be %v, got %v", true, kv.IsEmpty())
+		}
+	})
+}
+
+// testIsFull checks IsFull on the containers returned by newKV.
+func testIsFull[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test IsFull with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		if kv.IsFull() != true {
+			t.Errorf("Expected IsFull to be %v, got %v", false, kv.IsFull())
+		}
+
+		kv.Clear()
+
+		if kv.IsFull() != false {
+			t.Errorf("Expected IsFull to be %v, got %v", true, kv.IsFull())
+		}
+	})
+}
+
+// testContainsKey checks ContainsKey on the containers returned by newKV.
+func testContainsKey[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test ContainsKey with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		if kv.ContainsKey("Archimedes") != true {
+			t.Errorf("Expected key to be %v, got %v", true, kv.ContainsKey("Archimedes"))
+		}
+
+		if kv.ContainsKey("Do not exist") != false {
+			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsKey("Do not exist"))
+		}
+	})
+
+	t.Run("test ContainsKey without keys", func(t *testing.T) {
+		kv := newKV()
+
+		if kv.ContainsKey("Do not exist") != false {
+			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsKey("Do not exist"))
+		}
+	})
+}
+
+// testContainsValue checks ContainsValue on the containers returned by newKV.
+func testContainsValue[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test ContainsValue with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		if kv.ContainsValue(constant{"This is Archimedes' Constant (Pi)", 3.1415}) != true {
+			t.Errorf("Expected key to be %v, got %v", true, kv.ContainsValue(constant{"This is Archimedes' Constant (Pi)", 3.1415}))
+		}
+
+		if kv.ContainsValue(constant{"This is other constant", 0.00000}) != false {
+			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsValue(constant{"This is other constant", 0.00000}))
+		}
+	})
+
+	t.Run("test ContainsValue without keys", func(t *testing.T) {
+		kv := newKV()
+
+		if kv.ContainsValue(constant{"This is other constant", 0.00000}) != false {
+			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsValue(constant{"This is other constant", 0.00000}))
+		}
+	})
+}
+
+// testKey checks Key on the containers returned by newKV.
+func testKey[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Key with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		if kv.Key("Archimedes") != "Archimedes" {
+			t.Errorf("Expected key to be %v, got %v", "Archimedes", kv.Key("Archimedes"))
+		}
+
+		if kv.Key("Do Not Exist") != "" {
+			t.Errorf("Expected key to be %v, got %v", "Archimedes", kv.Key("Do Not Exist"))
+		}
+	})
+}
+
+// testKeys checks Keys on the containers returned by newKV.
+func testKeys[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Keys with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		keys := kv.Keys()
+
+		if len(keys) != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, len(keys))
+		}
+
+		for _, key := range keys {
+			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
+				t.Errorf("Expected key to be %v, got %v", true, key)
+			}
+		}
+	})
+
+	t.Run("test Keys without keys", func(t *testing.T) {
+		kv := newKV()
+
+		keys := kv.Keys()
+
+		if len(keys) != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, len(keys))
+		}
+	})
+}
+
+// testValues checks Values on the containers returned by newKV.
+func testValues[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Values with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		values := kv.Values()
+
+		if len(values) != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, len(values))
+		}
+
+		for _, val := range values {
+			if val.Name != "This is Archimedes' Constant (Pi)" && val.Name != "This is Euler's Number (e)" && val.Name != "This is The Golden Ratio" {
+				t.Errorf("Expected value to be %v, got %v", true, val)
+			}
+
+			if val.value != 3.1415 && val.value != 2.7182 && val.value != 1.6180 {
+				t.Errorf("Expected value to be %v, got %v", true, val)
+			}
+		}
+	})
+
+	t.Run("test Values without keys", func(t *testing.T) {
+		kv := newKV()
+
+		values := kv.Values()
+
+		if len(values) != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, len(values))
+		}
+	})
+}
+
+// testEach checks ForEach on the containers returned by newKV.
+func testEach[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Each with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		kv.ForEach(func(key string, value constant) {
+			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
+				t.Errorf("Expected key to be %v, got %v", true, key)
+			}
+
+			if value.Name != "This is Archimedes' Constant (Pi)" && value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
+				t.Errorf("Expected value to be %v, got %v", true, value)
+			}
+
+			if value.value != 3.1415 && value.value != 2.7182 && value.value != 1.6180 {
+				t.Errorf("Expected value to be %v, got %v", true, value)
+			}
+		})
+	})
+
+	t.Run("test Each without keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.ForEach(func(key string, value constant) {
+			t.Errorf("Expected Each to not be called, got %v", true)
+		})
+	})
+}
+
+// testEachKey checks ForEachKey on the containers returned by newKV.
+func testEachKey[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test EachKey with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		kv.ForEachKey(func(key string) {
+			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
+				t.Errorf("Expected key to be %v, got %v", true, key)
+			}
+		})
+	})
+
+	t.Run("test EachKey without keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.ForEachKey(func(key string) {
+			t.Errorf("Expected EachKey to not be called, got %v", true)
+		})
+	})
+}
+
+// testEachValue checks ForEachValue on the containers returned by newKV.
+func testEachValue[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test EachValue with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		kv.ForEachValue(func(value constant) {
+			if value.Name != "This is Archimedes' Constant (Pi)" && value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
+				t.Errorf("Expected value to be %v, got %v", true, value)
+			}
+
+			if value.value != 3.1415 && value.value != 2.7182 && value.value != 1.6180 {
+				t.Errorf("Expected value to be %v, got %v", true, value)
+			}
+		})
+	})
+
+	t.Run("test EachValue without keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.ForEachValue(func(value constant) {
+			t.Errorf("Expected EachValue to not be called, got %v", true)
+		})
+	})
+}
+
+// testAll checks All, KeysSeq and ValuesSeq on the containers returned by newKV.
+func testAll[C conformant[C]](t *testing.T, newKV func() C) {
+	filled := func() C {
+		kv := newKV()
+		for key, value := range constants {
+			kv.Set(key, value)
+		}
+		return kv
+	}
+
+	t.Run("test All, KeysSeq and ValuesSeq", func(t *testing.T) {
+		kv := filled()
+
+		seen := make(map[string]constant)
+		for key, value := range kv.All() {
+			seen[key] = value
+		}
+		if len(seen) != 3 || seen["Golden Ratio"] != constants["Golden Ratio"] {
+			t.Errorf("Expected All to visit %v, got %v", constants, seen)
+		}
+
+		keys, values := 0, 0
+		for range kv.KeysSeq() {
+			keys++
+		}
+		for range kv.ValuesSeq() {
+			values++
+		}
+		if keys != 3 || values != 3 {
+			t.Errorf("Expected keys and values to be %v and %v, got %v and %v", 3, 3, keys, values)
+		}
+	})
+
+	t.Run("test ForEach and All can modify the container", func(t *testing.T) {
+		kv := filled()
+
+		withoutDeadlock(t, func() {
+			kv.ForEach(func(key string, value constant) {
+				kv.Delete(key)
+				kv.Set(key+" copy", value)
+			})
+		})
+		if kv.Size() != 3 || !kv.ContainsKey("Euler copy") || kv.ContainsKey("Euler") {
+			t.Errorf("Expected keys to be copies, got %v", kv.Keys())
+		}
+
+		withoutDeadlock(t, func() {
+			for key := range kv.All() {
+				kv.Delete(key)
+			}
+		})
+		if !kv.IsEmpty() {
+			t.Errorf("Expected container to be empty, got %v", kv.Keys())
+		}
+	})
+}
+
+// testClone checks Clone on the containers returned by newKV.
+func testClone[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Clone with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		kvClone := kv.Clone()
+
+		if kvClone.Size() != kv.Size() {
+			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
+		}
+
+		if kv.DeepEqual(kvClone) == false {
+			t.Errorf("Expected Clone to be equal to original, got %v", true)
+		}
+
+		kvKeys := kv.Keys()
+		kvValues := kv.Values()
+
+		kvCloneKeys := kvClone.Keys()
+
+		sort.Strings(kvKeys)
+		sort.Strings(kvCloneKeys)
+
+		if reflect.DeepEqual(kvKeys, kvCloneKeys) == false {
+			t.Errorf("Expected keys to be equal, got %v", true)
+		}
+
+		kvSortedValues := kv.SortValues(func(value1, value2 constant) bool {
+			return value1.value < value2.value
+		})
+		kvCloneSortedValues := kvClone.SortValues(func(value1, value2 constant) bool {
+			return value1.value < value2.value
+		})
+
+		if reflect.DeepEqual(kvSortedValues, kvCloneSortedValues) == false {
+			t.Errorf("Expected values to be equal, got %v", true)
+		}
+
+		for _, kvValue := range kvValues {
+			if kvClone.ContainsValue(kvValue) == false {
+				t.Errorf("Expected Clone to contain value, got %v", true)
+			}
+		}
+	})
+
+	t.Run("test Clone without keys", func(t *testing.T) {
+		kv := newKV()
+
+		kvClone := kv.Clone()
+
+		if kvClone.Size() != kv.Size() {
+			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
+		}
+
+		if kv.DeepEqual(kvClone) == false {
+			t.Errorf("Expected Clone to be equal to original, got %v", true)
+		}
+	})
+}
+
+// testCloneAndClear checks CloneAndClear on the containers returned by newKV.
+func testCloneAndClear[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test CloneAndClear with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		kvClone := kv.CloneAndClear()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+
+		if kvClone.Size() == kv.Size() {
+			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
+		}
+
+		if kv.DeepEqual(kvClone) == true {
+			t.Errorf("Expected Clone to be not equal to original, got %v", true)
+		}
+
+		kvKeys := kv.Keys()
+		kvCloneKeys := kvClone.Keys()
+
+		sort.Strings(kvKeys)
+		sort.Strings(kvCloneKeys)
+
+		if reflect.DeepEqual(kvKeys, kvCloneKeys) == true {
+			t.Errorf("Expected keys to be not equal, got %v", true)
+		}
+	})
+
+	t.Run("test CloneAndClear without keys", func(t *testing.T) {
+		kv := newKV()
+
+		kvClone := kv.CloneAndClear()
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+
+		if kvClone.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
+		}
+
+		if kv.DeepEqual(kvClone) == false {
+			t.Errorf("Expected Clone to be equal to original, got %v", true)
+		}
+	})
+}
+
+// testDeepEqual checks DeepEqual on the containers returned by newKV.
+func testDeepEqual[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test DeepEqual with keys disordered and same size", func(t *testing.T) {
+		kv1 := newKV()
+		kv2 := newKV()
+
+		kv1.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv1.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv1.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		kv2.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+		kv2.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv2.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+
+		if kv1.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
+		}
+		if kv2.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv2.Size())
+		}
+
+		// the OrderedMapKeyValue containers are only equal with the keys in the same order
+		_, ordered := any(kv1).(*OrderedMapKeyValue[string, constant])
+		if kv1.DeepEqual(kv2) == ordered {
+			t.Errorf("Expected DeepEqual to be %v, got %v", !ordered, kv1.DeepEqual(kv2))
+		}
+
+		if kv2.DeepEqual(kv1) == ordered {
+			t.Errorf("Expected DeepEqual to be %v, got %v", !ordered, kv2.DeepEqual(kv1))
+		}
+	})
+
+	t.Run("test DeepEqual with keys and it is not equal same size", func(t *testing.T) {
+		kv1 := newKV()
+		kv2 := newKV()
+
+		kv1.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv1.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv1.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		kv2.Set("key 1", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv2.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv2.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv1.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
+		}
+		if kv2.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv2.Size())
+		}
+
+		if kv1.DeepEqual(kv2) == true {
+			t.Errorf("Expected DeepEqual to be different, got %v", kv1.DeepEqual(kv2))
+		}
+
+		if kv2.DeepEqual(kv1) == true {
+			t.Errorf("Expected DeepEqual to be different, got %v", kv2.DeepEqual(kv1))
+		}
+	})
+
+	t.Run("test DeepEqual with keys and it is different and different size", func(t *testing.T) {
+		kv1 := newKV()
+		kv2 := newKV()
+
+		kv1.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv1.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv1.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		kv2.Set("key 1", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+
+		if kv1.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
+		}
+		if kv2.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv2.Size())
+		}
+
+		if kv1.DeepEqual(kv2) == true {
+			t.Errorf("Expected DeepEqual to be equal, got %v", true)
+		}
+
+		if kv2.DeepEqual(kv1) == true {
+			t.Errorf("Expected DeepEqual to be equal, got %v", true)
+		}
+	})
+
+	t.Run("test DeepEqual without keys", func(t *testing.T) {
+		kv1 := newKV()
+		kv2 := newKV()
+
+		if kv1.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv1.Size())
+		}
+		if kv2.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv2.Size())
+		}
+
+		if kv1.DeepEqual(kv2) == false {
+			t.Errorf("Expected DeepEqual to be equal, got %v", true)
+		}
+		if kv2.DeepEqual(kv1) == false {
+			t.Errorf("Expected DeepEqual to be equal, got %v", true)
+		}
+	})
+}
+
+// testMap checks Map on the containers returned by newKV.
+func testMap[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Map with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		newKv := kv.Map(func(key string, value constant) (newKey string, newValue constant) {
+			newKey = key
+			newValue.Name = strings.ToUpper(value.Name)
+			newValue.value = value.value * 2
+			return
+		})
+
+		newKv.ForEach(func(key string, value constant) {
+			if kv.Key(key) != key {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", strings.ToUpper(key), key)
+			}
+			if strings.ToUpper(kv.Get(key).Name) != value.Name {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", strings.ToUpper(kv.Get(key).Name), value.Name)
+			}
+			if kv.Get(key).value*2 != value.value {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", value.value*2, value.value)
+			}
+		})
+	})
+
+	t.Run("test Map without keys", func(t *testing.T) {
+		kv := newKV()
+
+		newKv := kv.Map(func(key string, value constant) (newKey string, newValue constant) {
+			newKey = strings.ToUpper(key)
+			newValue = value
+			return
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if newKv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		}
+	})
+}
+
+// testMapKey checks MapKey on the containers returned by newKV.
+func testMapKey[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test MapKey with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		newKv := kv.MapKey(func(key string) string {
+			return strings.ToUpper(key)
+		})
+
+		newKv.ForEach(func(key string, value constant) {
+			if strings.ToUpper(kv.Key(strings.Title(strings.ToLower(key)))) != key {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(strings.Title(strings.ToLower(key))), key)
+			}
+			if kv.Get(strings.Title(strings.ToLower(key))).Name != value.Name {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", kv.Get(strings.Title(strings.ToLower(key))).Name, value.Name)
+			}
+			if kv.Get(strings.Title(strings.ToLower(key))).value != value.value {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(strings.Title(strings.ToLower(key))).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test MapKey without keys", func(t *testing.T) {
+		kv := newKV()
+
+		newKv := kv.MapKey(func(key string) string {
+			return strings.ToUpper(key)
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if newKv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		}
+	})
+}
+
+// testMapValue checks MapValue on the containers returned by newKV.
+func testMapValue[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test MapValue with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		newKv := kv.MapValue(func(value constant) constant {
+			value.Name = strings.ToUpper(value.Name)
+			value.value = value.value * 2
+			return value
+		})
+
+		newKv.ForEach(func(key string, value constant) {
+			if kv.Key(key) != key {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if strings.ToUpper(kv.Get(key).Name) != value.Name {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", kv.Get(key).Name, value.Name)
+			}
+			if kv.Get(key).value*2 != value.value {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test MapValue without keys", func(t *testing.T) {
+		kv := newKV()
+
+		newKv := kv.MapValue(func(value constant) constant {
+			value.Name = strings.ToUpper(value.Name)
+			value.value = value.value * 2
+			return value
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if newKv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		}
+	})
+}
+
+// testFilter checks Filter on the containers returned by newKV.
+func testFilter[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Filter with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		newKv := kv.Filter(func(key string, value constant) bool {
+			return strings.Contains(value.Name, "Constant")
+		})
+
+		newKv.ForEach(func(key string, value constant) {
+			if key != "Archimedes" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Archimedes' Constant (Pi)" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+			}
+			if value.value != 3.1415 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test Filter without keys", func(t *testing.T) {
+		kv := newKV()
+
+		newKv := kv.Filter(func(key string, value constant) bool {
+			return strings.Contains(value.Name, "Constant")
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if newKv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		}
+	})
+}
+
+// testFilterKey checks FilterKey on the containers returned by newKV.
+func testFilterKey[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test FilterKey with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		newKv := kv.FilterKey(func(key string) bool {
+			return strings.Contains(key, "chime")
+		})
+
+		newKv.ForEach(func(key string, value constant) {
+			if key != "Archimedes" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Archimedes' Constant (Pi)" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+			}
+			if value.value != 3.1415 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test FilterKey without keys", func(t *testing.T) {
+		kv := newKV()
+
+		newKv := kv.FilterKey(func(key string) bool {
+			return strings.Contains(key, "chime")
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if newKv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		}
+	})
+}
+
+// testFilterValue checks FilterValue on the containers returned by newKV.
+func testFilterValue[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test FilterValue with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		newKv := kv.FilterValue(func(value constant) bool {
+			return value.value > 3
+		})
+
+		if newKv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, newKv.Size())
+		}
+
+		newKv.ForEach(func(key string, value constant) {
+			if key != "Archimedes" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Archimedes' Constant (Pi)" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+			}
+			if value.value != 3.1415 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test FilterValue without keys", func(t *testing.T) {
+		kv := newKV()
+
+		newKv := kv.FilterValue(func(value constant) bool {
+			return value.value > 3
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if newKv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		}
+	})
+}
+
+// testPartition checks Partition on the containers returned by newKV.
+func testPartition[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test Partition with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		grp1Kv, grp2Kv := kv.Partition(func(key string, value constant) bool {
+			return value.value > 3
+		})
+
+		if grp1Kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
+		}
+		if grp2Kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
+		}
+
+		grp1Kv.ForEach(func(key string, value constant) {
+			if key != "Archimedes" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Archimedes' Constant (Pi)" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+			}
+			if value.value != 3.1415 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+
+		grp2Kv.ForEach(func(key string, value constant) {
+			if key != "Euler" && key != "Golden Ratio" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
+			}
+			if value.value != 2.7182 && value.value != 1.6180 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test Partition without keys", func(t *testing.T) {
+		kv := newKV()
+
+		grp1Kv, grp2Kv := kv.Partition(func(key string, value constant) bool {
+			return value.value > 3
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if grp1Kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
+		}
+
+		if grp2Kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
+		}
+	})
+}
+
+// testPartitionKey checks PartitionKey on the containers returned by newKV.
+func testPartitionKey[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test PartitionKey with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		grp1Kv, grp2Kv := kv.PartitionKey(func(key string) bool {
+			return key == "Archimedes"
+		})
+
+		if grp1Kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
+		}
+		if grp2Kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
+		}
+
+		grp1Kv.ForEach(func(key string, value constant) {
+			if key != "Archimedes" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Archimedes' Constant (Pi)" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+			}
+			if value.value != 3.1415 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+
+		grp2Kv.ForEach(func(key string, value constant) {
+			if key != "Euler" && key != "Golden Ratio" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
+			}
+			if value.value != 2.7182 && value.value != 1.6180 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test PartitionKey without keys", func(t *testing.T) {
+		kv := newKV()
+
+		grp1Kv, grp2Kv := kv.PartitionKey(func(key string) bool {
+			return key == "Archimedes"
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if grp1Kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
+		}
+
+		if grp2Kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
+		}
+	})
+}
+
+// testPartitionValue checks PartitionValue on the containers returned by newKV.
+func testPartitionValue[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test PartitionValue with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		grp1Kv, grp2Kv := kv.PartitionValue(func(value constant) bool {
+			return value.value > 3
+		})
+
+		if grp1Kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
+		}
+		if grp2Kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
+		}
+
+		grp1Kv.ForEach(func(key string, value constant) {
+			if key != "Archimedes" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Archimedes' Constant (Pi)" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+			}
+			if value.value != 3.1415 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+
+		grp2Kv.ForEach(func(key string, value constant) {
+			if key != "Euler" && key != "Golden Ratio" {
+				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+			}
+			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
+				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
+			}
+			if value.value != 2.7182 && value.value != 1.6180 {
+				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+			}
+		})
+	})
+
+	t.Run("test PartitionValue without keys", func(t *testing.T) {
+		kv := newKV()
+
+		grp1Kv, grp2Kv := kv.PartitionValue(func(value constant) bool {
+			return value.value > 3
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if grp1Kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
+		}
+
+		if grp2Kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
+		}
+	})
+}
+
+// testSortKeys checks SortKeys on the containers returned by newKV.
+func testSortKeys[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test SortKeys with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		kSorted := kv.SortKeys(func(key1 string, key2 string) bool {
+			return key1 < key2
+		})
+
+		if len(kSorted) != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, len(kSorted))
+		}
+
+		if *kSorted[0] != "Archimedes" {
+			t.Errorf("Expected key to be uppercase, want: %v, got %v", "Archimedes", *kSorted[0])
+		}
+	})
+
+	t.Run("test SortKeys without keys", func(t *testing.T) {
+		kv := newKV()
+
+		kSorted := kv.SortKeys(func(key1 string, key2 string) bool {
+			return key1 < key2
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if len(kSorted) != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, len(kSorted))
+		}
+	})
+}
+
+// testSortValues checks SortValues on the containers returned by newKV.
+func testSortValues[C conformant[C]](t *testing.T, newKV func() C) {
+	t.Run("test SortValues with keys", func(t *testing.T) {
+		kv := newKV()
+
+		kv.Set("Archimedes", constant{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Golden Ratio", constant{"This is The Golden Ratio", 1.6180})
+		kv.Set("Euler", constant{"This is Euler's Number (e)", 2.7182})
+
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		vSorted := kv.SortValues(func(value1 constant, value2 constant) bool {
+			return value1.value < value2.value
+		})
+
+		if len(vSorted) != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, len(vSorted))
+		}
+
+		if vSorted[0].Name != "This is The Golden Ratio" {
+			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is The Golden Ratio", vSorted[0].Name)
+		}
+		if vSorted[1].Name != "This is Euler's Number (e)" {
+			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is Euler's Number (e)", vSorted[1].Name)
+		}
+		if vSorted[2].Name != "This is Archimedes' Constant (Pi)" {
+			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", vSorted[2].Name)
+		}
+	})
+
+	t.Run("test SortValues without keys", func(t *testing.T) {
+		kv := newKV()
+
+		vSorted := kv.SortValues(func(value1 constant, value2 constant) bool {
+			return value1.value < value2.value
+		})
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if len(vSorted) != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, len(vSorted))
+		}
+	})
+}
+
+// withoutDeadlock runs fn and fails the test if it doesn't return in a few seconds.
+func withoutDeadlock(t *testing.T, fn func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the function to return, it is deadlocked")
+	}
+}
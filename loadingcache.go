@@ -0,0 +1,387 @@
+package r9e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by the loaders of LoadingCache when the key doesn't exist in the
+// source, and it is returned by LoadingCache when the key is in the negative cache.
+var ErrNotFound = errors.New("r9e: key not found")
+
+// Loader loads the value of the key from the source of a LoadingCache.
+// It returns ErrNotFound, or an error wrapping it, when the key doesn't exist.
+type Loader[K comparable, T any] func(ctx context.Context, key K) (T, error)
+
+// BulkLoader loads the values of several keys from the source of a LoadingCache at once.
+// The keys missing in the returned map don't exist in the source.
+type BulkLoader[K comparable, T any] func(ctx context.Context, keys []K) (map[K]T, error)
+
+type loadingCacheOptions struct {
+	bulkLoader  any
+	negativeTTL time.Duration
+	options     []MapKeyValueOptions
+}
+
+// LoadingCacheOptions are the options for LoadingCache.
+type LoadingCacheOptions func(*loadingCacheOptions)
+
+// WithBulkLoader sets the BulkLoader used by GetAll to load the missing keys at once,
+// instead of calling the Loader for each key.
+func WithBulkLoader[K comparable, T any](loader BulkLoader[K, T]) LoadingCacheOptions {
+	return func(lc *loadingCacheOptions) {
+		lc.bulkLoader = loader
+	}
+}
+
+// WithNegativeCache caches for the given ttl the keys that don't exist in the source,
+// so they aren't loaded again meanwhile. By default, the missing keys are not cached.
+func WithNegativeCache(ttl time.Duration) LoadingCacheOptions {
+	return func(lc *loadingCacheOptions) {
+		lc.negativeTTL = ttl
+	}
+}
+
+// WithCacheOptions sets the options of the MapKeyValue container storing the loaded values,
+// like WithDefaultTTL or WithMaxEntries.
+func WithCacheOptions(options ...MapKeyValueOptions) LoadingCacheOptions {
+	return func(lc *loadingCacheOptions) {
+		lc.options = append(lc.options, options...)
+	}
+}
+
+// LoadingCache is a read-through cache built on MapKeyValue. The values missing in the cache
+// are loaded from the source, and the concurrent loads of the same key are deduplicated,
+// so the source is called only once.
+type LoadingCache[K comparable, T any] struct {
+	kv       *MapKeyValue[K, T]
+	negative *MapKeyValue[K, struct{}]
+
+	loader      Loader[K, T]
+	bulkLoader  BulkLoader[K, T]
+	negativeTTL time.Duration
+
+	// mu protects the loads in progress.
+	mu    sync.Mutex
+	calls map[K]*loadCall[K, T]
+}
+
+// loadCall is the load in progress of a key.
+type loadCall[K comparable, T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+	group *loadGroup[K]
+}
+
+// loadGroup are the loads started by the same caller. The context of the loader is cancelled
+// when all the callers waiting for them are gone.
+type loadGroup[K comparable] struct {
+	keys    []K
+	waiters int
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewLoadingCache returns a new LoadingCache loading the values using loader.
+// The loader can be nil if a BulkLoader is set using WithBulkLoader.
+func NewLoadingCache[K comparable, T any](loader Loader[K, T], options ...LoadingCacheOptions) *LoadingCache[K, T] {
+	lco := loadingCacheOptions{}
+	for _, opt := range options {
+		opt(&lco)
+	}
+
+	c := &LoadingCache[K, T]{
+		kv:          NewMapKeyValue[K, T](lco.options...),
+		loader:      loader,
+		negativeTTL: lco.negativeTTL,
+		calls:       make(map[K]*loadCall[K, T]),
+	}
+
+	if lco.bulkLoader != nil {
+		bulkLoader, ok := lco.bulkLoader.(BulkLoader[K, T])
+		if !ok {
+			panic(fmt.Sprintf("r9e: the bulk loader %T doesn't match the cache type %T", lco.bulkLoader, bulkLoader))
+		}
+		c.bulkLoader = bulkLoader
+	}
+	if c.negativeTTL > 0 {
+		c.negative = NewMapKeyValue[K, struct{}](WithDefaultTTL(c.negativeTTL))
+	}
+	if c.loader == nil && c.bulkLoader == nil {
+		panic("r9e: LoadingCache needs a loader")
+	}
+	return c
+}
+
+// Get returns the value associated with the key, loading it if it is not in the cache.
+// If the key doesn't exist in the source, ErrNotFound is returned.
+// If the context is done before the value is loaded, the context error is returned, and the
+// load is cancelled when no other caller is waiting for it.
+func (c *LoadingCache[K, T]) Get(ctx context.Context, key K) (T, error) {
+	if value, ok := c.kv.GetAndCheck(key); ok {
+		return value, nil
+	}
+	if c.negative != nil && c.negative.ContainsKey(key) {
+		var empty T
+		return empty, ErrNotFound
+	}
+
+	calls, owned := c.register(ctx, []K{key})
+	if len(owned) > 0 {
+		go c.load(owned, []*loadCall[K, T]{calls[key]})
+	}
+	return c.wait(ctx, calls[key])
+}
+
+// GetAll returns the values associated with the keys, loading the ones that are not in the cache,
+// at once if a BulkLoader was set. The keys that don't exist in the source are not included.
+// If a load fails, the error is returned together with the values found.
+func (c *LoadingCache[K, T]) GetAll(ctx context.Context, keys []K) (map[K]T, error) {
+	values := make(map[K]T, len(keys))
+
+	var missing []K
+	for _, key := range keys {
+		if value, ok := c.kv.GetAndCheck(key); ok {
+			values[key] = value
+		} else if c.negative == nil || !c.negative.ContainsKey(key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	calls, owned := c.register(ctx, missing)
+	if len(owned) > 0 && c.bulkLoader != nil {
+		loads := make([]*loadCall[K, T], len(owned))
+		for i, key := range owned {
+			loads[i] = calls[key]
+		}
+		go c.load(owned, loads)
+	} else {
+		for _, key := range owned {
+			go c.load([]K{key}, []*loadCall[K, T]{calls[key]})
+		}
+	}
+
+	var firstErr error
+	for _, key := range missing {
+		call, ok := calls[key]
+		if !ok {
+			continue
+		}
+
+		value, err := c.wait(ctx, call)
+		delete(calls, key)
+
+		switch {
+		case err == nil:
+			values[key] = value
+		case ctx.Err() != nil:
+			// the remaining calls are not waited anymore
+			for _, call := range calls {
+				c.release(call)
+			}
+			return values, ctx.Err()
+		case !errors.Is(err, ErrNotFound) && firstErr == nil:
+			firstErr = err
+		}
+	}
+	return values, firstErr
+}
+
+// Set stores the value associated with the key in the cache, without calling the loader.
+// The load of the key in progress, if any, is not cached when it completes.
+func (c *LoadingCache[K, T]) Set(key K, value T) {
+	c.kv.Update(func(tx *Tx[K, T]) error {
+		c.forget(key)
+		return tx.Set(key, value)
+	})
+	if c.negative != nil {
+		c.negative.Delete(key)
+	}
+}
+
+// Invalidate removes the key from the cache, so it is loaded again by the next Get.
+// The load of the key in progress, if any, is not cached when it completes.
+func (c *LoadingCache[K, T]) Invalidate(key K) {
+	c.kv.Update(func(tx *Tx[K, T]) error {
+		c.forget(key)
+		return tx.Delete(key)
+	})
+	if c.negative != nil {
+		c.negative.Delete(key)
+	}
+}
+
+// InvalidateAll removes all the keys from the cache.
+// The loads in progress are not cached when they complete.
+func (c *LoadingCache[K, T]) InvalidateAll() {
+	c.mu.Lock()
+	clear(c.calls)
+	c.mu.Unlock()
+
+	if c.negative != nil {
+		c.negative.Clear()
+	}
+	c.kv.Clear()
+}
+
+// forget forgets the load in progress of the key, if any, so its result is not cached.
+// It is called by the transactions of the containers, so the loads completed after
+// it can't be cached in between.
+func (c *LoadingCache[K, T]) forget(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.calls, key)
+}
+
+// Cache returns the MapKeyValue container storing the loaded values.
+func (c *LoadingCache[K, T]) Cache() *MapKeyValue[K, T] {
+	return c.kv
+}
+
+// Close stops the goroutines of the containers used by the cache.
+func (c *LoadingCache[K, T]) Close() error {
+	if c.negative != nil {
+		c.negative.Close()
+	}
+	return c.kv.Close()
+}
+
+// register returns the load in progress of every key, creating the missing ones, and the keys
+// of the loads created, which must be started by the caller. The caller is counted as waiter.
+func (c *LoadingCache[K, T]) register(ctx context.Context, keys []K) (map[K]*loadCall[K, T], []K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	calls := make(map[K]*loadCall[K, T], len(keys))
+	group := &loadGroup[K]{}
+
+	for _, key := range keys {
+		if _, ok := calls[key]; ok {
+			continue
+		}
+
+		call, ok := c.calls[key]
+		if !ok {
+			call = &loadCall[K, T]{done: make(chan struct{}), group: group}
+			c.calls[key] = call
+			group.keys = append(group.keys, key)
+		}
+
+		call.group.waiters++
+		calls[key] = call
+	}
+
+	if len(group.keys) > 0 {
+		// the loads keep the values of the context, but not its cancellation,
+		// because other callers can wait for them
+		group.ctx, group.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	}
+	return calls, group.keys
+}
+
+// wait returns the result of the load, or the context error if it is done before.
+func (c *LoadingCache[K, T]) wait(ctx context.Context, call *loadCall[K, T]) (T, error) {
+	select {
+	case <-call.done:
+		c.release(call)
+		return call.value, call.err
+	case <-ctx.Done():
+		c.release(call)
+		var empty T
+		return empty, ctx.Err()
+	}
+}
+
+// release removes a waiter of the load. When there are no more waiters, the loads of the group
+// are cancelled and forgotten, so the next callers start new loads.
+func (c *LoadingCache[K, T]) release(call *loadCall[K, T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	group := call.group
+	group.waiters--
+	if group.waiters > 0 {
+		return
+	}
+
+	group.cancel()
+	for _, key := range group.keys {
+		if c.calls[key] != nil && c.calls[key].group == group {
+			delete(c.calls, key)
+		}
+	}
+}
+
+// load calls the loader for the keys and completes their loads.
+func (c *LoadingCache[K, T]) load(keys []K, calls []*loadCall[K, T]) {
+	ctx := calls[0].group.ctx
+
+	if c.loader != nil && len(keys) == 1 {
+		value, err := c.loader(ctx, keys[0])
+		c.complete(keys[0], calls[0], value, err)
+		return
+	}
+
+	values, err := c.bulkLoader(ctx, keys)
+	for i, key := range keys {
+		switch value, ok := values[key]; {
+		case err != nil:
+			c.complete(key, calls[i], value, err)
+		case ok:
+			c.complete(key, calls[i], value, nil)
+		default:
+			c.complete(key, calls[i], value, ErrNotFound)
+		}
+	}
+}
+
+// complete caches the result of the load and wakes up the waiters.
+// The result is not cached if the load was cancelled, or if it is stale because the key was
+// set or invalidated while it was loaded, which forgets the load.
+// The load is checked inside a transaction of the container, so Set and Invalidate can't run in
+// between, but the cache lock is not held while the container is written, so the watchers of the
+// container can use the cache.
+func (c *LoadingCache[K, T]) complete(key K, call *loadCall[K, T], value T, err error) {
+	switch {
+	case err == nil:
+		c.kv.Update(func(tx *Tx[K, T]) error {
+			if !c.finish(key, call) {
+				return nil
+			}
+			return tx.Set(key, value)
+		})
+	case errors.Is(err, ErrNotFound) && c.negative != nil:
+		c.negative.Update(func(tx *Tx[K, struct{}]) error {
+			if !c.finish(key, call) {
+				return nil
+			}
+			return tx.Set(key, struct{}{})
+		})
+	default:
+		c.finish(key, call)
+	}
+
+	call.value, call.err = value, err
+	close(call.done)
+}
+
+// finish removes the load of the key if it is still in progress, and returns true if its result
+// must be cached: the load was not cancelled, and the key was not set or invalidated meanwhile.
+func (c *LoadingCache[K, T]) finish(key K, call *loadCall[K, T]) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := c.calls[key] == call && call.group.ctx.Err() == nil
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+	return current
+}
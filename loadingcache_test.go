@@ -0,0 +1,370 @@
+package r9e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGet_LoadingCache(t *testing.T) {
+	t.Run("test Get loads the missing keys once", func(t *testing.T) {
+		var loads atomic.Int32
+		c := NewLoadingCache(func(ctx context.Context, key string) (int, error) {
+			loads.Add(1)
+			return len(key), nil
+		})
+		defer c.Close()
+
+		for i := 0; i < 3; i++ {
+			value, err := c.Get(context.Background(), "three")
+			if err != nil || value != 5 {
+				t.Errorf("Expected value to be %v, got %v %v", 5, value, err)
+			}
+		}
+
+		if loads.Load() != 1 {
+			t.Errorf("Expected loads to be %v, got %v", 1, loads.Load())
+		}
+	})
+
+	t.Run("test Get deduplicates the concurrent loads", func(t *testing.T) {
+		var loads atomic.Int32
+		release := make(chan struct{})
+
+		c := NewLoadingCache(func(ctx context.Context, key int) (int, error) {
+			loads.Add(1)
+			<-release
+			return key * 10, nil
+		})
+		defer c.Close()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 100)
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if value, err := c.Get(context.Background(), 7); err != nil || value != 70 {
+					errs <- fmt.Errorf("got %v %v", value, err)
+				}
+			}()
+		}
+
+		// waits until every goroutine is waiting for the load
+		for c.waiters(7) < 100 {
+			time.Sleep(time.Millisecond)
+		}
+		close(release)
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Errorf("Expected value to be %v, %v", 70, err)
+		}
+		if loads.Load() != 1 {
+			t.Errorf("Expected loads to be %v, got %v", 1, loads.Load())
+		}
+	})
+
+	t.Run("test Get doesn't cache the errors", func(t *testing.T) {
+		var loads atomic.Int32
+		failure := errors.New("database is down")
+
+		c := NewLoadingCache(func(ctx context.Context, key string) (string, error) {
+			if loads.Add(1) == 1 {
+				return "", failure
+			}
+			return "value", nil
+		})
+		defer c.Close()
+
+		if _, err := c.Get(context.Background(), "key"); !errors.Is(err, failure) {
+			t.Errorf("Expected error to be %v, got %v", failure, err)
+		}
+		if value, err := c.Get(context.Background(), "key"); err != nil || value != "value" {
+			t.Errorf("Expected value to be %v, got %v %v", "value", value, err)
+		}
+	})
+
+	t.Run("test Get with negative cache", func(t *testing.T) {
+		var loads atomic.Int32
+		c := NewLoadingCache(func(ctx context.Context, key string) (string, error) {
+			loads.Add(1)
+			return "", fmt.Errorf("user %v: %w", key, ErrNotFound)
+		}, WithNegativeCache(time.Hour))
+		defer c.Close()
+
+		for i := 0; i < 3; i++ {
+			if _, err := c.Get(context.Background(), "ghost"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected error to be %v, got %v", ErrNotFound, err)
+			}
+		}
+		if loads.Load() != 1 {
+			t.Errorf("Expected loads to be %v, got %v", 1, loads.Load())
+		}
+
+		c.Invalidate("ghost")
+		c.Get(context.Background(), "ghost")
+		if loads.Load() != 2 {
+			t.Errorf("Expected loads to be %v, got %v", 2, loads.Load())
+		}
+	})
+
+	t.Run("test Get without negative cache loads the missing keys again", func(t *testing.T) {
+		var loads atomic.Int32
+		c := NewLoadingCache(func(ctx context.Context, key string) (string, error) {
+			loads.Add(1)
+			return "", ErrNotFound
+		})
+		defer c.Close()
+
+		c.Get(context.Background(), "ghost")
+		c.Get(context.Background(), "ghost")
+		if loads.Load() != 2 {
+			t.Errorf("Expected loads to be %v, got %v", 2, loads.Load())
+		}
+	})
+
+	t.Run("test Get propagates the context cancellation", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		c := NewLoadingCache(func(ctx context.Context, key string) (string, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return "", ctx.Err()
+		})
+		defer c.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if _, err := c.Get(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected error to be %v, got %v", context.DeadlineExceeded, err)
+		}
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Errorf("Expected the load to be cancelled")
+		}
+	})
+
+	t.Run("test Get keeps loading while other callers wait", func(t *testing.T) {
+		release := make(chan struct{})
+		c := NewLoadingCache(func(ctx context.Context, key string) (string, error) {
+			select {
+			case <-release:
+				return "value", nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		})
+		defer c.Close()
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := c.Get(context.Background(), "key")
+			result <- err
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			// the second caller joins the load and leaves
+			for c.waiters("key") < 2 {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+		}()
+		c.Get(ctx, "key")
+
+		close(release)
+		if err := <-result; err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+	})
+
+	for name, write := range map[string]func(c *LoadingCache[string, int]){
+		"Set":           func(c *LoadingCache[string, int]) { c.Set("key", 2) },
+		"Invalidate":    func(c *LoadingCache[string, int]) { c.Invalidate("key") },
+		"InvalidateAll": func(c *LoadingCache[string, int]) { c.InvalidateAll() },
+	} {
+		t.Run("test Get doesn't cache a load finished after "+name, func(t *testing.T) {
+			var loads atomic.Int32
+			release := make(chan struct{})
+			c := NewLoadingCache(func(ctx context.Context, key string) (int, error) {
+				if loads.Add(1) == 1 {
+					<-release
+					return 1, nil
+				}
+				return 3, nil
+			})
+			defer c.Close()
+
+			result := make(chan int, 1)
+			go func() {
+				value, _ := c.Get(context.Background(), "key")
+				result <- value
+			}()
+			for c.waiters("key") < 1 {
+				time.Sleep(time.Millisecond)
+			}
+
+			write(c)
+			close(release)
+			if value := <-result; value != 1 {
+				t.Errorf("Expected value to be %v, got %v", 1, value)
+			}
+
+			expected := 3
+			if name == "Set" {
+				expected = 2
+			}
+			if value, err := c.Get(context.Background(), "key"); err != nil || value != expected {
+				t.Errorf("Expected value to be %v, got %v %v", expected, value, err)
+			}
+		})
+	}
+}
+
+// waiters returns the number of callers waiting for the load of the key.
+func (c *LoadingCache[K, T]) waiters(key K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if call, ok := c.calls[key]; ok {
+		return call.group.waiters
+	}
+	return 0
+}
+
+func TestGetAll_LoadingCache(t *testing.T) {
+	t.Run("test GetAll with a bulk loader", func(t *testing.T) {
+		var loads atomic.Int32
+		c := NewLoadingCache[int, string](nil, WithBulkLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+			loads.Add(1)
+			values := make(map[int]string, len(keys))
+			for _, key := range keys {
+				if key%2 == 0 {
+					values[key] = fmt.Sprint(key)
+				}
+			}
+			return values, nil
+		}), WithNegativeCache(time.Hour))
+		defer c.Close()
+
+		c.Set(100, "cached")
+
+		values, err := c.GetAll(context.Background(), []int{1, 2, 3, 4, 100, 2})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if len(values) != 3 || values[2] != "2" || values[4] != "4" || values[100] != "cached" {
+			t.Errorf("Expected values to be %v, got %v", map[int]string{2: "2", 4: "4", 100: "cached"}, values)
+		}
+		if loads.Load() != 1 {
+			t.Errorf("Expected loads to be %v, got %v", 1, loads.Load())
+		}
+
+		// the loaded and the missing keys are cached
+		c.GetAll(context.Background(), []int{1, 2, 3, 4})
+		if _, err := c.Get(context.Background(), 3); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error to be %v, got %v", ErrNotFound, err)
+		}
+		if loads.Load() != 1 {
+			t.Errorf("Expected loads to be %v, got %v", 1, loads.Load())
+		}
+	})
+
+	t.Run("test GetAll without a bulk loader", func(t *testing.T) {
+		var loads atomic.Int32
+		c := NewLoadingCache(func(ctx context.Context, key int) (int, error) {
+			loads.Add(1)
+			return key * key, nil
+		})
+		defer c.Close()
+
+		values, err := c.GetAll(context.Background(), []int{1, 2, 3})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if len(values) != 3 || values[3] != 9 {
+			t.Errorf("Expected values to be %v, got %v", map[int]int{1: 1, 2: 4, 3: 9}, values)
+		}
+		if loads.Load() != 3 {
+			t.Errorf("Expected loads to be %v, got %v", 3, loads.Load())
+		}
+	})
+
+	t.Run("test GetAll returns the loader error", func(t *testing.T) {
+		failure := errors.New("database is down")
+		c := NewLoadingCache[int, int](nil, WithBulkLoader(func(ctx context.Context, keys []int) (map[int]int, error) {
+			return nil, failure
+		}))
+		defer c.Close()
+
+		if _, err := c.GetAll(context.Background(), []int{1, 2}); !errors.Is(err, failure) {
+			t.Errorf("Expected error to be %v, got %v", failure, err)
+		}
+		if c.Cache().Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, c.Cache().Size())
+		}
+	})
+	t.Run("test WithBulkLoader with different type parameters panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected NewLoadingCache to panic")
+			}
+		}()
+
+		NewLoadingCache[int, int](nil, WithBulkLoader(func(ctx context.Context, keys []string) (map[string]int, error) {
+			return nil, nil
+		}))
+	})
+}
+
+func TestWatch_LoadingCache(t *testing.T) {
+	t.Run("test a blocked watcher doesn't block the cache while a load is cached", func(t *testing.T) {
+		var loaded atomic.Bool
+		c := NewLoadingCache(func(ctx context.Context, key string) (int, error) {
+			loaded.Store(true)
+			return len(key), nil
+		})
+		defer c.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := c.Cache().Watch(ctx, nil, WithWatchBufferSize(1), WithSlowConsumerPolicy(SlowConsumerBlock))
+
+		// the first event fills the channel, so caching the load waits until it is received
+		c.Set("one", 1)
+		go c.Get(context.Background(), "three")
+		for !loaded.Load() {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		// a Get of another key needs the cache lock, but not the container
+		done := make(chan error, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, err := c.Get(ctx, "four")
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected error to be %v, got %v", context.DeadlineExceeded, err)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Expected Get not to wait for the blocked watcher")
+		}
+
+		<-events
+		<-events
+	})
+}
@@ -0,0 +1,114 @@
+package r9e
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// WithLockDebug makes the MapKeyValue container panic when a goroutine locks it while it already
+// holds the lock, instead of deadlocking. This happens when a function called by the container
+// holding its lock, like the extract function of CreateIndex, the cost function of WithCostFunc,
+// an eviction policy, the function of Compute or a transaction run by Update or View, uses the
+// container. The functions given to the iteration methods, like ForEach or Filter, run without
+// holding the lock, so they can use the container.
+// The check identifies the goroutines parsing their stack, so it is slow and meant for debugging.
+func WithLockDebug() MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.lockDebug = true
+	}
+}
+
+// rwMutex is a sync.RWMutex that detects the re-entrant locks when debug is set.
+type rwMutex struct {
+	sync.RWMutex
+	debug *lockOwners
+}
+
+// Lock locks the mutex for writing.
+func (m *rwMutex) Lock() {
+	m.debug.acquire("Lock")
+	m.RWMutex.Lock()
+}
+
+// Unlock unlocks the mutex for writing.
+func (m *rwMutex) Unlock() {
+	m.debug.release()
+	m.RWMutex.Unlock()
+}
+
+// RLock locks the mutex for reading.
+func (m *rwMutex) RLock() {
+	m.debug.acquire("RLock")
+	m.RWMutex.RLock()
+}
+
+// RUnlock unlocks the mutex for reading.
+func (m *rwMutex) RUnlock() {
+	m.debug.release()
+	m.RWMutex.RUnlock()
+}
+
+// lockOwners tracks the goroutines holding a rwMutex. A nil lockOwners tracks nothing.
+type lockOwners struct {
+	mu     sync.Mutex
+	owners map[uint64]int
+}
+
+// newLockOwners returns a new lockOwners if enabled is true, otherwise nil.
+func newLockOwners(enabled bool) *lockOwners {
+	if !enabled {
+		return nil
+	}
+	return &lockOwners{owners: make(map[uint64]int)}
+}
+
+// acquire records that the current goroutine is locking the mutex, panicking if it already holds it.
+func (o *lockOwners) acquire(op string) {
+	if o == nil {
+		return
+	}
+
+	id := goroutineID()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.owners[id] > 0 {
+		panic(fmt.Sprintf("r9e: re-entrant %s of the container by goroutine %d, which already holds its lock: "+
+			"a function called by the container while it is locked must not use the container", op, id))
+	}
+	o.owners[id]++
+}
+
+// release records that the current goroutine is unlocking the mutex.
+func (o *lockOwners) release() {
+	if o == nil {
+		return
+	}
+
+	id := goroutineID()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.owners[id]--; o.owners[id] <= 0 {
+		delete(o.owners, id)
+	}
+}
+
+// goroutineID returns the identifier of the current goroutine, parsed from the header of its stack:
+// "goroutine 42 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,88 @@
+package r9e
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLockDebug_MapKeyValue(t *testing.T) {
+	// reentered calls fn and returns the message of its panic, or an empty string.
+	reentered := func(fn func()) (msg string) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = r.(string)
+			}
+		}()
+		fn()
+		return ""
+	}
+
+	t.Run("test re-entrant lock panics", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int](WithLockDebug())
+		kv.Set("one", 1)
+
+		msg := reentered(func() {
+			kv.Compute("one", func(old int, exists bool) (int, ComputeAction) {
+				return kv.Get("one") + 1, ComputeSet
+			})
+		})
+		if !strings.Contains(msg, "re-entrant") {
+			t.Errorf("Expected panic message to contain %q, got %q", "re-entrant", msg)
+		}
+	})
+
+	t.Run("test re-entrant lock in a transaction panics", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int](WithLockDebug())
+
+		msg := reentered(func() {
+			kv.Update(func(tx *Tx[string, int]) error {
+				kv.Set("one", 1)
+				return nil
+			})
+		})
+		if !strings.Contains(msg, "re-entrant") {
+			t.Errorf("Expected panic message to contain %q, got %q", "re-entrant", msg)
+		}
+	})
+
+	t.Run("test the callbacks of the iterations don't panic", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int](WithLockDebug())
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+
+		msg := reentered(func() {
+			kv.ForEach(func(key string, value int) {
+				kv.Set(key, value+1)
+			})
+			kv.Filter(func(key string, value int) bool {
+				return kv.ContainsKey(key)
+			})
+			kv.Clone().DeepEqual(kv)
+		})
+		if msg != "" {
+			t.Errorf("Expected no panic, got %q", msg)
+		}
+	})
+
+	t.Run("test concurrent locks don't panic", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithLockDebug())
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					kv.Set(g*1000+i, i)
+					kv.Get(g*1000 + i)
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if kv.Size() != 1600 {
+			t.Errorf("Expected size to be %v, got %v", 1600, kv.Size())
+		}
+	})
+}
@@ -3,11 +3,25 @@ package r9e
 import (
 	"reflect"
 	"sort"
-	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type mapKeyValueOptions struct {
-	size int
+	size            int
+	defaultTTL      time.Duration
+	cleanupInterval time.Duration
+	maxEntries      int
+	newPolicy       any
+	fsyncPolicy     FsyncPolicy
+	logKeyCodec     Codec
+	logValueCodec   Codec
+	stats           bool
+	maxCost         int
+	costFunc        any
+	rejectOversized bool
+	lockDebug       bool
+	snapshots       bool
 }
 
 // MapKeyValueOptions are the options for MapKeyValue container.
@@ -20,15 +34,63 @@ func WithCapacity(size int) MapKeyValueOptions {
 	}
 }
 
+// WithMaxEntries sets the maximum number of key-value pairs stored in the MapKeyValue container.
+// When the limit is reached, Set evicts the key-value pair chosen by the eviction policy,
+// by default the least recently used one. See WithEvictionPolicy.
+// A value less or equal than zero means the container is unbounded.
+func WithMaxEntries(n int) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.maxEntries = n
+	}
+}
+
 // MapKeyValue is a generic key-value store container that is thread-safe.
 // This use a golang native map data structure as underlying data structure and a mutex to
 // protect the data.
 type MapKeyValue[K comparable, T any] struct {
-	mu   sync.RWMutex
+	mu   rwMutex
 	data map[K]T
+
+	// expires holds the expiration time of the keys with a time to live.
+	expires         map[K]time.Time
+	defaultTTL      time.Duration
+	cleanupInterval time.Duration
+	done            chan struct{}
+	closed          bool
+	clock           func() time.Time
+
+	// policy chooses the key-value pairs to evict when the container is bounded.
+	maxEntries int
+	policy     EvictionPolicy[K]
+
+	// costs holds the cost of the keys when the container was created using WithMaxCost or WithCostFunc.
+	maxCost         int
+	totalCost       int
+	costs           map[K]int
+	costFunc        func(value T) int
+	rejectOversized bool
+
+	// resets counts the replacements of data by Clear, so the iterations stop walking the old map.
+	resets uint64
+
+	// tree is the persistent copy of the key-value pairs shared by the views returned by Snapshot,
+	// created by the first Snapshot or by WithSnapshots.
+	tree *hamt[K, viewEntry[T]]
+
+	// wal logs the mutations when the container was opened using OpenMapKeyValue.
+	wal *wal
+
+	// hub notifies the changes to the watchers, created by the first Watch.
+	hub atomic.Pointer[watchHub[K, T]]
+
+	// indexes are the secondary indexes created by CreateIndex.
+	indexes map[string]*index[K, T]
+
+	// stats counts the operations when the container was created using WithStats.
+	stats *stats
 }
 
-// kv is a helper struct to sort the values of the MapKeyValue container.
+// kv is a helper struct holding a key-value pair of the MapKeyValue container.
 type kv[K comparable, T any] struct {
 	key   K
 	value T
@@ -36,72 +98,130 @@ type kv[K comparable, T any] struct {
 
 // NewMapKeyValue returns a new MapKeyValue container.
 func NewMapKeyValue[K comparable, T any](options ...MapKeyValueOptions) *MapKeyValue[K, T] {
-	kvo := mapKeyValueOptions{}
-	for _, opt := range options {
-		opt(&kvo)
+	kvo := newMapKeyValueOptions(options...)
+
+	kv := &MapKeyValue[K, T]{
+		data:            make(map[K]T, kvo.size),
+		expires:         make(map[K]time.Time),
+		defaultTTL:      kvo.defaultTTL,
+		cleanupInterval: kvo.cleanupInterval,
+		stats:           newStats(kvo.stats),
 	}
+	kv.mu.debug = newLockOwners(kvo.lockDebug)
 
-	return &MapKeyValue[K, T]{
-		data: make(map[K]T, kvo.size),
+	if kvo.maxEntries > 0 {
+		kv.maxEntries = kvo.maxEntries
+	}
+	if kvo.maxCost > 0 {
+		kv.maxCost = kvo.maxCost
+		kv.rejectOversized = kvo.rejectOversized
+	}
+	if kv.maxEntries > 0 || kv.maxCost > 0 {
+		kv.policy = newEvictionPolicy[K](kvo)
+	}
+	if kv.costFunc = newCostFunc[T](kvo); kv.costFunc != nil || kv.maxCost > 0 {
+		kv.costs = make(map[K]int)
 	}
+	if kvo.snapshots {
+		kv.tree = kv.newTree()
+	}
+
+	return kv
+}
+
+// newMapKeyValueOptions returns the MapKeyValue options with the defaults applied.
+func newMapKeyValueOptions(options ...MapKeyValueOptions) mapKeyValueOptions {
+	kvo := mapKeyValueOptions{
+		cleanupInterval: DefaultCleanupInterval,
+		fsyncPolicy:     FsyncEverySecond,
+	}
+	for _, opt := range options {
+		opt(&kvo)
+	}
+	return kvo
 }
 
 // Set sets the value associated with the key.
+// If the container was created using WithDefaultTTL, the key expires after the default time to live.
 func (r *MapKeyValue[K, T]) Set(key K, value T) {
+	defer r.notify()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.data[key] = value
+	r.set(key, value, r.deadline(r.defaultTTL))
 }
 
 // GetAndCheck returns the value associated with the key if this exist also a
 // boolean value if this exist of not.
+// If the container is bounded, the access is notified to the eviction policy.
 func (r *MapKeyValue[K, T]) GetAndCheck(key K) (T, bool) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	defer r.lockAccess()()
 
-	value, ok := r.data[key]
-	return value, ok
+	return r.access(key)
 }
 
 // Get returns the value associated with the key.
 // If the key does not exist, return zero value of the type.
+// If the container is bounded, the access is notified to the eviction policy.
 func (r *MapKeyValue[K, T]) Get(key K) T {
+	defer r.lockAccess()()
+
+	value, _ := r.access(key)
+	return value
+}
+
+// Peek returns the value associated with the key if this exist also a
+// boolean value if this exist of not, without notifying the access to the eviction policy.
+func (r *MapKeyValue[K, T]) Peek(key K) (T, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	return r.data[key]
+	return r.peek(key)
 }
 
 // GetAnDelete returns the value associated with the key and delete it if the key exist
 // if the key doesn't exist return the given key value false
 func (r *MapKeyValue[K, T]) GetAnDelete(key K) (T, bool) {
+	defer r.notify()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if r.expired(key) {
+		r.delete(key, OpExpire)
+		var empty T
+		return empty, false
+	}
+
 	current, loaded := r.data[key]
 	if loaded {
-		delete(r.data, key)
+		r.delete(key, OpDelete)
 	}
 	return current, loaded
 }
 
 // Delete deletes the value associated with the key.
 func (r *MapKeyValue[K, T]) Delete(key K) {
+	defer r.notify()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	delete(r.data, key)
+	r.delete(key, OpDelete)
 }
 
 // Clear deletes all key-value pairs stored in the container.
 func (r *MapKeyValue[K, T]) Clear() {
+	defer r.notify()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.data = make(map[K]T, 0)
+	r.reset()
 }
 
 // Size returns the number of key-value pairs stored in the container.
+// Expired key-value pairs not yet removed by the janitor are included.
 func (r *MapKeyValue[K, T]) Size() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -124,6 +244,10 @@ func (r *MapKeyValue[K, T]) ContainsKey(key K) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	if r.expired(key) {
+		return false
+	}
+
 	_, ok := r.data[key]
 	return ok
 }
@@ -133,7 +257,10 @@ func (r *MapKeyValue[K, T]) ContainsValue(value T) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for _, v := range r.data {
+	for key, v := range r.data {
+		if r.expired(key) {
+			continue
+		}
 		if reflect.DeepEqual(v, value) {
 			return true
 		}
@@ -147,7 +274,7 @@ func (r *MapKeyValue[K, T]) Key(key K) K {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if _, ok := r.data[key]; ok {
+	if _, ok := r.data[key]; ok && !r.expired(key) {
 		return key
 	}
 	var empty K
@@ -161,6 +288,9 @@ func (r *MapKeyValue[K, T]) Keys() []K {
 
 	keys := make([]K, 0, len(r.data))
 	for key := range r.data {
+		if r.expired(key) {
+			continue
+		}
 		keys = append(keys, key)
 	}
 	return keys
@@ -172,78 +302,123 @@ func (r *MapKeyValue[K, T]) Values() []T {
 	defer r.mu.RUnlock()
 
 	values := make([]T, 0, len(r.data))
-	for _, value := range r.data {
+	for key, value := range r.data {
+		if r.expired(key) {
+			continue
+		}
 		values = append(values, value)
 	}
 	return values
 }
 
 // ForEach calls the given function for each key-value pair in the container.
+// The function is called on a snapshot of the container taken without holding the lock,
+// so it can modify the container, but the changes are not seen by the iteration.
 func (r *MapKeyValue[K, T]) ForEach(fn func(key K, value T)) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	for key, value := range r.data {
-		fn(key, value)
+	for _, pair := range r.pairs() {
+		fn(pair.key, pair.value)
 	}
 }
 
 // ForEachKey calls the given function for each key in the container.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) ForEachKey(fn func(key K)) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	for key := range r.data {
-		fn(key)
+	for _, pair := range r.pairs() {
+		fn(pair.key)
 	}
 }
 
 // ForEachValue calls the given function for each value in the container.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) ForEachValue(fn func(value T)) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	for _, value := range r.data {
-		fn(value)
+	for _, pair := range r.pairs() {
+		fn(pair.value)
 	}
 }
 
 // Clone returns a new MapKeyValue with a copy of the underlying data.
 func (r *MapKeyValue[K, T]) Clone() *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	pairs := r.pairs()
 
-	clone := NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key, value := range r.data {
-		clone.Set(key, value)
+	clone := NewMapKeyValue[K, T](WithCapacity(len(pairs)))
+	for _, pair := range pairs {
+		clone.Set(pair.key, pair.value)
 	}
 	return clone
 }
 
 // CloneAndClear returns a new MapKeyValue with a copy of the underlying data and clears the container.
 func (r *MapKeyValue[K, T]) CloneAndClear() *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	defer r.notify()
 
-	clone := NewMapKeyValue[K, T](WithCapacity(r.Size()))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := NewMapKeyValue[K, T](WithCapacity(len(r.data)))
 	for key, value := range r.data {
+		if r.expired(key) {
+			continue
+		}
 		clone.Set(key, value)
 	}
-	r.data = make(map[K]T)
+	r.reset()
 	return clone
 }
 
-// DeepEqual returns true if the given kv is deep equal to the MapKeyValue container
+// Drain atomically replaces the underlying data of the container with an empty map and returns a new
+// MapKeyValue holding the previous one, without copying it. Every concurrent write is either in the
+// returned container or in the container, so none is lost.
+// The returned container doesn't use the options of the container: it keeps the time to live of the
+// keys, without the janitor, but it has no eviction policy, limits, stats or indexes.
+// It runs in constant time, plus the number of keys with a time to live.
+func (r *MapKeyValue[K, T]) Drain() *MapKeyValue[K, T] {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.drain()
+}
+
+// Swap atomically replaces the key-value pairs of the container with a copy of the given newData and
+// returns a new MapKeyValue holding the previous ones, like Drain.
+// If the container was created using WithDefaultTTL, the new keys expire after the default time to live.
+func (r *MapKeyValue[K, T]) Swap(newData map[K]T) *MapKeyValue[K, T] {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.drain()
+	expireAt := r.deadline(r.defaultTTL)
+	for key, value := range newData {
+		r.set(key, value, expireAt)
+	}
+	return old
+}
+
+// DeepEqual returns true if the given kv is deep equal to the MapKeyValue container.
+// The expired key-value pairs are ignored, and the reads are not counted in the stats
+// nor notified to the eviction policy.
 func (r *MapKeyValue[K, T]) DeepEqual(kv *MapKeyValue[K, T]) bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	if r == kv {
+		return true
+	}
 
-	if r.Size() != kv.Size() {
+	// the lock of each container is held only while its pairs are copied, so both
+	// containers can be compared in any order
+	pairs, others := r.pairs(), kv.pairs()
+	if len(pairs) != len(others) {
 		return false
 	}
 
-	for key, value := range r.data {
-		if !reflect.DeepEqual(value, kv.Get(key)) {
+	values := make(map[K]T, len(others))
+	for _, pair := range others {
+		values[pair.key] = pair.value
+	}
+	for _, pair := range pairs {
+		value, ok := values[pair.key]
+		if !ok || !reflect.DeepEqual(pair.value, value) {
 			return false
 		}
 	}
@@ -252,99 +427,77 @@ func (r *MapKeyValue[K, T]) DeepEqual(kv *MapKeyValue[K, T]) bool {
 }
 
 // Map returns a new MapKeyValue after applying the given function fn to each key-value pair.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) Map(fn func(key K, value T) (newKey K, newValue T)) *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	pairs := r.pairs()
 
-	m := NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key, value := range r.data {
-		newKey, newValue := fn(key, value)
+	m := NewMapKeyValue[K, T](WithCapacity(len(pairs)))
+	for _, pair := range pairs {
+		newKey, newValue := fn(pair.key, pair.value)
 		m.Set(newKey, newValue)
 	}
 	return m
 }
 
 // MapKey returns a new MapKeyValue after applying the given function fn to each key.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) MapKey(fn func(key K) K) *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	m := NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key := range r.data {
-		newKey := fn(key)
-		m.Set(newKey, r.data[key])
-	}
-	return m
+	return r.Map(func(key K, value T) (K, T) {
+		return fn(key), value
+	})
 }
 
 // MapValue returns a new MapKeyValue after applying the given function fn to each value.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) MapValue(fn func(value T) T) *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	m := NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key, value := range r.data {
-		newValue := fn(value)
-		m.Set(key, newValue)
-	}
-	return m
+	return r.Map(func(key K, value T) (K, T) {
+		return key, fn(value)
+	})
 }
 
 // Filter returns a new MapKeyValue after applying the given function fn to each key-value pair.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) Filter(fn func(key K, value T) bool) *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	pairs := r.pairs()
 
-	m := NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key, value := range r.data {
-		if fn(key, value) {
-			m.Set(key, value)
+	m := NewMapKeyValue[K, T](WithCapacity(len(pairs)))
+	for _, pair := range pairs {
+		if fn(pair.key, pair.value) {
+			m.Set(pair.key, pair.value)
 		}
 	}
 	return m
 }
 
 // FilterKey returns a new MapKeyValue after applying the given function fn to each key.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) FilterKey(fn func(key K) bool) *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	m := NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key := range r.data {
-		if fn(key) {
-			m.Set(key, r.data[key])
-		}
-	}
-	return m
+	return r.Filter(func(key K, value T) bool {
+		return fn(key)
+	})
 }
 
 // FilterValue returns a new MapKeyValue after applying the given function fn to each value.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) FilterValue(fn func(value T) bool) *MapKeyValue[K, T] {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	m := NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key, value := range r.data {
-		if fn(value) {
-			m.Set(key, value)
-		}
-	}
-	return m
+	return r.Filter(func(key K, value T) bool {
+		return fn(value)
+	})
 }
 
 // Partition returns two new MapKeyValue. One with all the elements that satisfy the predicate and
 // another with the rest. The predicate is applied to each element.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, others *MapKeyValue[K, T]) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	pairs := r.pairs()
 
-	match = NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	others = NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key, value := range r.data {
-		if fn(key, value) {
-			match.Set(key, value)
+	match = NewMapKeyValue[K, T](WithCapacity(len(pairs)))
+	others = NewMapKeyValue[K, T](WithCapacity(len(pairs)))
+	for _, pair := range pairs {
+		if fn(pair.key, pair.value) {
+			match.Set(pair.key, pair.value)
 		} else {
-			others.Set(key, value)
+			others.Set(pair.key, pair.value)
 		}
 	}
 	return
@@ -352,45 +505,25 @@ func (r *MapKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, othe
 
 // PartitionKey returns two new MapKeyValue. One with all the elements that satisfy the predicate and
 // another with the rest. The predicate is applied to each key.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) PartitionKey(fn func(key K) bool) (match, others *MapKeyValue[K, T]) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	match = NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	others = NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key := range r.data {
-		if fn(key) {
-			match.Set(key, r.data[key])
-		} else {
-			others.Set(key, r.data[key])
-		}
-	}
-	return
+	return r.Partition(func(key K, value T) bool {
+		return fn(key)
+	})
 }
 
 // PartitionValue returns two new MapKeyValue. One with all the elements that satisfy the predicate and
 // another with the rest. The predicate is applied to each value.
+// It has the same guarantees as ForEach.
 func (r *MapKeyValue[K, T]) PartitionValue(fn func(value T) bool) (match, others *MapKeyValue[K, T]) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	match = NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	others = NewMapKeyValue[K, T](WithCapacity(r.Size()))
-	for key, value := range r.data {
-		if fn(value) {
-			match.Set(key, value)
-		} else {
-			others.Set(key, value)
-		}
-	}
-	return
+	return r.Partition(func(key K, value T) bool {
+		return fn(value)
+	})
 }
 
 // SortKeys returns a []*K (keys) after sorting the keys using the given sortFn function.
+// The keys are sorted without holding the lock, so sortFn can use the container.
 func (r *MapKeyValue[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	keys := r.Keys()
 
 	sort.Slice(keys, func(i, j int) bool {
@@ -406,23 +539,201 @@ func (r *MapKeyValue[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
 }
 
 // SortValues returns a []*T (values) after sorting the values using given function sortFn.
+// The values are sorted without holding the lock, so sortFn can use the container.
 func (r *MapKeyValue[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
+	values := r.Values()
+
+	sort.Slice(values, func(i, j int) bool {
+		return sortFn(values[i], values[j])
+	})
+
+	m := make([]*T, len(values))
+	for i := range values {
+		m[i] = &values[i]
+	}
+
+	return m
+}
+
+// pairs returns a snapshot of the key-value pairs not expired, used to call the functions given
+// to the iteration methods without holding the lock.
+func (r *MapKeyValue[K, T]) pairs() []kv[K, T] {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	kvs := make([]*kv[K, T], 0, r.Size())
+	pairs := make([]kv[K, T], 0, len(r.data))
 	for key, value := range r.data {
-		kvs = append(kvs, &kv[K, T]{key, value})
+		if r.expired(key) {
+			continue
+		}
+		pairs = append(pairs, kv[K, T]{key, value})
 	}
+	return pairs
+}
 
-	sort.Slice(kvs, func(i, j int) bool {
-		return sortFn(kvs[i].value, kvs[j].value)
-	})
+// lockAccess locks the container to read a key-value pair and returns the unlock function.
+// The write lock is needed when the container is bounded, because the eviction policy is updated.
+func (r *MapKeyValue[K, T]) lockAccess() func() {
+	if r.policy == nil {
+		r.mu.RLock()
+		return r.mu.RUnlock
+	}
 
-	m := make([]*T, len(kvs))
-	for i, pair := range kvs {
-		m[i] = &pair.value
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// peek returns the value associated with the key if this exist and it is not expired.
+// The caller must hold the lock.
+func (r *MapKeyValue[K, T]) peek(key K) (T, bool) {
+	if r.expired(key) {
+		var empty T
+		return empty, false
 	}
 
-	return m
+	value, ok := r.data[key]
+	return value, ok
+}
+
+// access returns the value associated with the key and notifies the access to the eviction policy.
+// The caller must hold the lock returned by lockAccess.
+func (r *MapKeyValue[K, T]) access(key K) (T, bool) {
+	if r.expired(key) {
+		r.stats.get(false)
+		var empty T
+		return empty, false
+	}
+
+	value, ok := r.data[key]
+	r.stats.get(ok)
+	if ok && r.policy != nil {
+		r.policy.Access(key)
+	}
+	return value, ok
+}
+
+// set stores the key-value pair and its expiration, evicting the key-value pairs
+// chosen by the eviction policy when the container is bounded.
+// A zero expireAt means the key-value pair never expires.
+// The value is ignored if it is refused by WithRejectOversized.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) set(key K, value T, expireAt time.Time) {
+	if r.oversized(value) {
+		return
+	}
+
+	old, exists := r.data[key]
+	r.index(key, old, exists, value)
+	r.publish(Event[K, T]{Op: OpSet, Key: key, OldValue: old, HasOldValue: exists && !r.expired(key), NewValue: value})
+	r.data[key] = value
+	r.addCost(key, value)
+	r.stats.set()
+
+	if expireAt.IsZero() {
+		delete(r.expires, key)
+	} else {
+		r.expires[key] = expireAt
+		r.startJanitor()
+	}
+	r.track(key)
+	r.logSet(key, value, expireAt)
+
+	if r.policy == nil {
+		return
+	}
+
+	if exists {
+		r.policy.Access(key)
+	} else {
+		r.policy.Add(key)
+	}
+
+	for r.overflow() {
+		if _, ok := r.data[key]; ok && len(r.data) == 1 {
+			// an oversized value is kept alone
+			break
+		}
+
+		victim, ok := r.policy.Evict()
+		if !ok {
+			break
+		}
+
+		// the policy already forgot the victim, or keeps it as a ghost entry
+		r.publish(Event[K, T]{Op: OpEvict, Key: victim, OldValue: r.data[victim], HasOldValue: true})
+		r.unindex(victim, r.data[victim])
+		r.stats.remove(OpEvict)
+		r.removeCost(victim)
+		r.untrack(victim)
+		delete(r.data, victim)
+		delete(r.expires, victim)
+		r.logDelete(victim)
+	}
+}
+
+// delete removes the key-value pair, its expiration and notifies the eviction policy.
+// The watchers are notified using the given operation.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) delete(key K, op Op) {
+	old, ok := r.data[key]
+	if !ok {
+		return
+	}
+
+	r.publish(Event[K, T]{Op: op, Key: key, OldValue: old, HasOldValue: true})
+	r.unindex(key, old)
+	r.stats.remove(op)
+	r.removeCost(key)
+	r.untrack(key)
+	delete(r.data, key)
+	delete(r.expires, key)
+	r.logDelete(key)
+
+	if r.policy != nil {
+		r.policy.Remove(key)
+	}
+}
+
+// drain clears the container and returns a new MapKeyValue holding its previous data and expirations.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) drain() *MapKeyValue[K, T] {
+	old := &MapKeyValue[K, T]{
+		data:    r.data,
+		expires: r.expires,
+		clock:   r.clock,
+	}
+	for key := range old.expires {
+		if old.expired(key) {
+			delete(old.data, key)
+			delete(old.expires, key)
+		}
+	}
+
+	r.reset()
+	return old
+}
+
+// reset removes all the key-value pairs.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) reset() {
+	if r.hub.Load().watched() {
+		for key, value := range r.data {
+			if !r.expired(key) {
+				r.publish(Event[K, T]{Op: OpClear, Key: key, OldValue: value, HasOldValue: true})
+			}
+		}
+	}
+
+	r.stats.delete(len(r.data))
+	r.data = make(map[K]T, 0)
+	r.resets++
+	r.expires = make(map[K]time.Time)
+	r.resetCost()
+	r.resetTree()
+	r.resetIndexes()
+	r.logClear()
+
+	if r.policy != nil {
+		r.policy.Reset()
+	}
 }
@@ -0,0 +1,139 @@
+package r9e
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCostExceeded is returned by Insert when the container was created using WithRejectOversized
+// and the cost of the value is greater than the maximum cost.
+var ErrCostExceeded = errors.New("r9e: value cost exceeds the maximum cost")
+
+// Sizer is implemented by the values that know their cost, usually their size in bytes.
+// It is used by the containers created using WithMaxCost when no cost function is given.
+type Sizer interface {
+	Size() int
+}
+
+// WithMaxCost sets the maximum total cost of the key-value pairs stored in the MapKeyValue container.
+// When the limit is exceeded, Set evicts the key-value pairs chosen by the eviction policy until
+// the total cost fits again. The cost of each value is given by the function set using WithCostFunc,
+// by its Size method if it implements Sizer, or it is one otherwise.
+// A value whose cost is greater than the maximum cost evicts all the other key-value pairs and is
+// stored alone, exceeding the limit, unless WithRejectOversized is used or the eviction policy rejects it.
+// It can be combined with WithMaxEntries, when only the cost is bounded the eviction policy
+// receives a zero capacity.
+// A value less or equal than zero means the cost is unbounded.
+func WithMaxCost(maxCost int) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.maxCost = maxCost
+	}
+}
+
+// WithCostFunc sets the function returning the cost of the values stored in the MapKeyValue container,
+// used instead of the Sizer interface. The total cost is tracked even if the container is not bounded
+// by WithMaxCost, and it is returned by Cost.
+func WithCostFunc[T any](fn func(value T) int) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.costFunc = fn
+	}
+}
+
+// WithRejectOversized makes the container created using WithMaxCost refuse the values whose cost is
+// greater than the maximum cost, instead of evicting everything else to store them. Set ignores them,
+// keeping the previous value of the key if any, and Insert returns ErrCostExceeded.
+func WithRejectOversized() MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.rejectOversized = true
+	}
+}
+
+// newCostFunc returns the cost function configured in the options, or nil if none was given.
+func newCostFunc[T any](kvo mapKeyValueOptions) func(value T) int {
+	if kvo.costFunc == nil {
+		return nil
+	}
+
+	fn, ok := kvo.costFunc.(func(value T) int)
+	if !ok {
+		var value T
+		panic(fmt.Sprintf("r9e: the cost function doesn't match the container value type %T", value))
+	}
+	return fn
+}
+
+// Cost returns the total cost of the key-value pairs stored in the container.
+// Expired key-value pairs not yet removed by the janitor are included.
+// Returns zero unless the container was created using WithMaxCost or WithCostFunc.
+func (r *MapKeyValue[K, T]) Cost() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.totalCost
+}
+
+// Cost returns the total cost of the key-value pairs stored in the shards.
+// Returns zero unless the shards were created using WithShardOptions(WithMaxCost(n)) or WithCostFunc.
+func (r *ShardedMapKeyValue[K, T]) Cost() int {
+	var cost int
+	for _, shard := range r.shards {
+		cost += shard.Cost()
+	}
+	return cost
+}
+
+// cost returns the cost of the value.
+func (r *MapKeyValue[K, T]) cost(value T) int {
+	if r.costFunc != nil {
+		return r.costFunc(value)
+	}
+	if s, ok := any(value).(Sizer); ok {
+		return s.Size()
+	}
+	return 1
+}
+
+// oversized returns true if the value must be refused because its cost is greater than the maximum cost.
+func (r *MapKeyValue[K, T]) oversized(value T) bool {
+	return r.rejectOversized && r.maxCost > 0 && r.cost(value) > r.maxCost
+}
+
+// addCost tracks the cost of the value stored with the key, replacing the previous one.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) addCost(key K, value T) {
+	if r.costs == nil {
+		return
+	}
+
+	cost := r.cost(value)
+	r.totalCost += cost - r.costs[key]
+	r.costs[key] = cost
+}
+
+// removeCost stops tracking the cost of the key.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) removeCost(key K) {
+	if r.costs == nil {
+		return
+	}
+
+	r.totalCost -= r.costs[key]
+	delete(r.costs, key)
+}
+
+// resetCost stops tracking the cost of all the keys.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) resetCost() {
+	if r.costs == nil {
+		return
+	}
+
+	r.totalCost = 0
+	r.costs = make(map[K]int)
+}
+
+// overflow returns true if the container holds more key-value pairs or more cost than allowed.
+// The caller must hold the lock.
+func (r *MapKeyValue[K, T]) overflow() bool {
+	return (r.maxEntries > 0 && len(r.data) > r.maxEntries) || (r.maxCost > 0 && r.totalCost > r.maxCost)
+}
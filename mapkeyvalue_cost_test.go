@@ -0,0 +1,168 @@
+package r9e
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type costTestBlob []byte
+
+func (b costTestBlob) Size() int {
+	return len(b)
+}
+
+func TestMaxCost_MapKeyValue(t *testing.T) {
+	t.Run("test WithMaxCost evicts until the cost fits", func(t *testing.T) {
+		kv := NewMapKeyValue[string, costTestBlob](WithMaxCost(100))
+
+		kv.Set("a", make(costTestBlob, 40))
+		kv.Set("b", make(costTestBlob, 40))
+		if kv.Cost() != 80 {
+			t.Errorf("Expected cost to be %v, got %v", 80, kv.Cost())
+		}
+
+		kv.Get("a")
+		kv.Set("c", make(costTestBlob, 50)) // evicts b, the least recently used
+
+		if kv.ContainsKey("b") || !kv.ContainsKey("a") || !kv.ContainsKey("c") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"a", "c"}, kv.Keys())
+		}
+		if kv.Cost() != 90 {
+			t.Errorf("Expected cost to be %v, got %v", 90, kv.Cost())
+		}
+	})
+
+	t.Run("test Cost follows the updates and deletes", func(t *testing.T) {
+		kv := NewMapKeyValue[string, costTestBlob](WithMaxCost(100))
+
+		kv.Set("a", make(costTestBlob, 10))
+		kv.Set("a", make(costTestBlob, 30))
+		kv.Set("b", make(costTestBlob, 20))
+		if kv.Cost() != 50 {
+			t.Errorf("Expected cost to be %v, got %v", 50, kv.Cost())
+		}
+
+		kv.Delete("a")
+		if kv.Cost() != 20 {
+			t.Errorf("Expected cost to be %v, got %v", 20, kv.Cost())
+		}
+
+		kv.Clear()
+		if kv.Cost() != 0 {
+			t.Errorf("Expected cost to be %v, got %v", 0, kv.Cost())
+		}
+	})
+
+	t.Run("test WithCostFunc", func(t *testing.T) {
+		kv := NewMapKeyValue[string, string](WithMaxCost(10), WithCostFunc(func(value string) int {
+			return len(value)
+		}))
+
+		kv.Set("a", "hello")
+		kv.Set("b", "world")
+		kv.Set("c", "!")
+
+		if kv.Size() != 2 || kv.Cost() != 6 {
+			t.Errorf("Expected size and cost to be %v and %v, got %v and %v", 2, 6, kv.Size(), kv.Cost())
+		}
+	})
+
+	t.Run("test WithCostFunc without WithMaxCost", func(t *testing.T) {
+		kv := NewMapKeyValue[string, string](WithCostFunc(func(value string) int {
+			return len(value)
+		}))
+		kv.Set("a", strings.Repeat("x", 1000))
+
+		if kv.Size() != 1 || kv.Cost() != 1000 {
+			t.Errorf("Expected size and cost to be %v and %v, got %v and %v", 1, 1000, kv.Size(), kv.Cost())
+		}
+	})
+
+	t.Run("test the values without Sizer cost one", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithMaxCost(3))
+		for i := 0; i < 10; i++ {
+			kv.Set(i, i)
+		}
+
+		if kv.Size() != 3 || kv.Cost() != 3 {
+			t.Errorf("Expected size and cost to be %v, got %v and %v", 3, kv.Size(), kv.Cost())
+		}
+	})
+
+	t.Run("test WithMaxCost combined with WithMaxEntries", func(t *testing.T) {
+		kv := NewMapKeyValue[int, costTestBlob](WithMaxCost(100), WithMaxEntries(2))
+		kv.Set(1, make(costTestBlob, 1))
+		kv.Set(2, make(costTestBlob, 1))
+		kv.Set(3, make(costTestBlob, 1))
+
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+	})
+
+	for name, policy := range map[string]func(capacity int) EvictionPolicy[string]{
+		"LRU":      NewLRUPolicy[string],
+		"FIFO":     NewFIFOPolicy[string],
+		"LFU":      NewLFUPolicy[string],
+		"ARC":      NewARCPolicy[string],
+		"S3FIFO":   NewS3FIFOPolicy[string],
+		"WTinyLFU": NewWTinyLFUPolicy[string],
+	} {
+		t.Run("test an oversized value evicts everything else with "+name, func(t *testing.T) {
+			kv := NewMapKeyValue[string, costTestBlob](WithMaxCost(100), WithEvictionPolicy(policy))
+			kv.Set("a", make(costTestBlob, 10))
+			kv.Set("b", make(costTestBlob, 10))
+			kv.Set("huge", make(costTestBlob, 200))
+
+			if kv.Size() != 1 || kv.Cost() != 200 || !kv.ContainsKey("huge") {
+				t.Errorf("Expected keys to be %v, got %v", []string{"huge"}, kv.Keys())
+			}
+
+			kv.Set("c", make(costTestBlob, 10))
+			if kv.Size() != 1 || kv.Cost() != 10 || !kv.ContainsKey("c") {
+				t.Errorf("Expected keys to be %v, got %v", []string{"c"}, kv.Keys())
+			}
+		})
+	}
+
+	t.Run("test WithRejectOversized", func(t *testing.T) {
+		kv := NewMapKeyValue[string, costTestBlob](WithMaxCost(100), WithRejectOversized())
+		kv.Set("a", make(costTestBlob, 10))
+		kv.Set("a", make(costTestBlob, 200))
+
+		if value := kv.Get("a"); len(value) != 10 {
+			t.Errorf("Expected value size to be %v, got %v", 10, len(value))
+		}
+
+		if err := kv.Insert("huge", make(costTestBlob, 101)); !errors.Is(err, ErrCostExceeded) {
+			t.Errorf("Expected error to be %v, got %v", ErrCostExceeded, err)
+		}
+		if err := kv.Insert("b", make(costTestBlob, 90)); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+		if kv.Cost() != 100 {
+			t.Errorf("Expected cost to be %v, got %v", 100, kv.Cost())
+		}
+	})
+
+	t.Run("test WithCostFunc with a different value type", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected NewMapKeyValue to panic")
+			}
+		}()
+		NewMapKeyValue[string, int](WithCostFunc(func(value string) int { return 1 }))
+	})
+
+	t.Run("test Cost of ShardedMapKeyValue", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[int, costTestBlob](WithShards(4), WithShardOptions(WithMaxCost(1000)))
+		for i := 0; i < 10; i++ {
+			kv.Set(i, make(costTestBlob, 10))
+		}
+
+		if kv.Cost() != 100 {
+			t.Errorf("Expected cost to be %v, got %v", 100, kv.Cost())
+		}
+	})
+}
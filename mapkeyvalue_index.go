@@ -0,0 +1,263 @@
+package r9e
+
+import (
+	"errors"
+)
+
+var (
+	// ErrIndexExists is returned by CreateIndex when the container already has an index with the same name.
+	ErrIndexExists = errors.New("r9e: index already exists")
+
+	// ErrIndexConflict is returned when a value would have the same key of a unique index than
+	// another value stored in the container.
+	ErrIndexConflict = errors.New("r9e: unique index conflict")
+)
+
+type indexOptions struct {
+	unique bool
+}
+
+// IndexOptions are the options for CreateIndex.
+type IndexOptions func(*indexOptions)
+
+// WithUniqueIndex makes the index unique, so at most one key-value pair is stored for each index key.
+// Set replaces the key-value pair that has the same index key, deleting it, and Insert
+// returns ErrIndexConflict instead.
+func WithUniqueIndex() IndexOptions {
+	return func(ido *indexOptions) {
+		ido.unique = true
+	}
+}
+
+// index is a secondary index of a MapKeyValue, mapping the index keys extracted from the values
+// to the keys of the container.
+type index[K comparable, T any] struct {
+	extract func(value T) any
+	unique  bool
+	keys    map[any]map[K]struct{}
+}
+
+// add indexes the key-value pair.
+func (idx *index[K, T]) add(key K, value T) {
+	ik := idx.extract(value)
+
+	keys, ok := idx.keys[ik]
+	if !ok {
+		keys = make(map[K]struct{}, 1)
+		idx.keys[ik] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// remove removes the key-value pair from the index.
+func (idx *index[K, T]) remove(key K, value T) {
+	ik := idx.extract(value)
+
+	keys := idx.keys[ik]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(idx.keys, ik)
+	}
+}
+
+// Index is a secondary index created by CreateIndex, used by GetBy and GetKeysBy to find the
+// values by their index key of type IK.
+type Index[K comparable, T any, IK comparable] struct {
+	kv   *MapKeyValue[K, T]
+	name string
+	idx  *index[K, T]
+}
+
+// Name returns the name of the index.
+func (i *Index[K, T, IK]) Name() string {
+	return i.name
+}
+
+// CreateIndex registers in the container a secondary index with the given name, using extract
+// to get the index key of each value, and returns it so the values can be found by GetBy.
+// The index is built with the key-value pairs already stored, and it is maintained by every change
+// of the container. If the index is unique and two values have the same index key, the index isn't
+// created and ErrIndexConflict is returned.
+// The containers returned by Clone, Map, Filter and Partition don't have the indexes.
+func CreateIndex[K comparable, T any, IK comparable](kv *MapKeyValue[K, T], name string, extract func(value T) IK, options ...IndexOptions) (*Index[K, T, IK], error) {
+	ido := indexOptions{}
+	for _, opt := range options {
+		opt(&ido)
+	}
+
+	idx := &index[K, T]{
+		extract: func(value T) any {
+			return extract(value)
+		},
+		unique: ido.unique,
+		keys:   make(map[any]map[K]struct{}),
+	}
+	if err := kv.createIndex(name, idx); err != nil {
+		return nil, err
+	}
+	return &Index[K, T, IK]{kv: kv, name: name, idx: idx}, nil
+}
+
+// createIndex builds the index and registers it.
+func (r *MapKeyValue[K, T]) createIndex(name string, idx *index[K, T]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.indexes[name]; ok {
+		return ErrIndexExists
+	}
+
+	for key, value := range r.data {
+		if r.expired(key) {
+			continue
+		}
+		if idx.unique && len(idx.keys[idx.extract(value)]) > 0 {
+			return ErrIndexConflict
+		}
+		idx.add(key, value)
+	}
+
+	if r.indexes == nil {
+		r.indexes = make(map[string]*index[K, T])
+	}
+	r.indexes[name] = idx
+	return nil
+}
+
+// DropIndex removes the index with the given name.
+// Returns false if the index doesn't exist.
+func (r *MapKeyValue[K, T]) DropIndex(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.indexes[name]
+	delete(r.indexes, name)
+	return ok
+}
+
+// GetBy returns the values whose index key, in the given index, is equal to ik.
+// If the index was dropped or there are no matches, returns nil.
+func GetBy[K comparable, T any, IK comparable](i *Index[K, T, IK], ik IK) []T {
+	kv := i.kv
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	var values []T
+	for key := range kv.lookup(i.name, i.idx, ik) {
+		if !kv.expired(key) {
+			values = append(values, kv.data[key])
+		}
+	}
+	return values
+}
+
+// GetKeysBy returns the keys whose value has an index key, in the given index, equal to ik.
+// If the index was dropped or there are no matches, returns nil.
+func GetKeysBy[K comparable, T any, IK comparable](i *Index[K, T, IK], ik IK) []K {
+	kv := i.kv
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+
+	var keys []K
+	for key := range kv.lookup(i.name, i.idx, ik) {
+		if !kv.expired(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// lookup returns the keys of idx whose index key is ik, if idx is still registered with the given name.
+// The caller must hold the lock.
+func (r *MapKeyValue[K, T]) lookup(name string, idx *index[K, T], ik any) map[K]struct{} {
+	if r.indexes[name] != idx {
+		return nil
+	}
+	return idx.keys[ik]
+}
+
+// Insert sets the value associated with the key like Set, unless a unique index has another
+// key-value pair with the same index key. In that case, the container isn't modified and
+// ErrIndexConflict is returned.
+// If the value is refused by WithRejectOversized, ErrCostExceeded is returned.
+func (r *MapKeyValue[K, T]) Insert(key K, value T) error {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.oversized(value) {
+		return ErrCostExceeded
+	}
+	if r.conflict(key, value) {
+		return ErrIndexConflict
+	}
+
+	r.set(key, value, r.deadline(r.defaultTTL))
+	return nil
+}
+
+// conflict returns true if a unique index has a key-value pair, other than key and not expired,
+// with the same index key than value.
+// The caller must hold the lock.
+func (r *MapKeyValue[K, T]) conflict(key K, value T) bool {
+	for _, idx := range r.indexes {
+		if !idx.unique {
+			continue
+		}
+
+		for other := range idx.keys[idx.extract(value)] {
+			if other != key && !r.expired(other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// index updates the indexes for the new value of the key, deleting the key-value pairs
+// with the same key of a unique index.
+// The caller must hold the write lock, and call it before storing the value.
+func (r *MapKeyValue[K, T]) index(key K, old T, exists bool, value T) {
+	if len(r.indexes) == 0 {
+		return
+	}
+
+	if exists {
+		r.unindex(key, old)
+	}
+
+	for _, idx := range r.indexes {
+		if !idx.unique {
+			continue
+		}
+
+		for other := range idx.keys[idx.extract(value)] {
+			if r.expired(other) {
+				r.delete(other, OpExpire)
+			} else {
+				r.delete(other, OpDelete)
+			}
+		}
+	}
+
+	for _, idx := range r.indexes {
+		idx.add(key, value)
+	}
+}
+
+// unindex removes the key-value pair from the indexes.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) unindex(key K, value T) {
+	for _, idx := range r.indexes {
+		idx.remove(key, value)
+	}
+}
+
+// resetIndexes removes all the key-value pairs from the indexes.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) resetIndexes() {
+	for _, idx := range r.indexes {
+		idx.keys = make(map[any]map[K]struct{})
+	}
+}
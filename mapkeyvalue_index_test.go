@@ -0,0 +1,235 @@
+package r9e
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type indexTestUser struct {
+	ID    int
+	Email string
+	Team  string
+}
+
+// newIndexTestKeyValue returns a container with three users, indexed by the unique email index
+// and the team index.
+func newIndexTestKeyValue(t *testing.T) (kv *MapKeyValue[string, indexTestUser], email, team *Index[string, indexTestUser, string]) {
+	kv = NewMapKeyValue[string, indexTestUser]()
+	kv.Set("alice", indexTestUser{1, "alice@example.com", "core"})
+	kv.Set("bob", indexTestUser{2, "bob@example.com", "core"})
+	kv.Set("carol", indexTestUser{3, "carol@example.com", "web"})
+
+	email, err := CreateIndex(kv, "email", func(u indexTestUser) string { return u.Email }, WithUniqueIndex())
+	if err != nil {
+		t.Fatalf("Expected error to be %v, got %v", nil, err)
+	}
+	team, err = CreateIndex(kv, "team", func(u indexTestUser) string { return u.Team })
+	if err != nil {
+		t.Fatalf("Expected error to be %v, got %v", nil, err)
+	}
+	return kv, email, team
+}
+
+func TestCreateIndex_MapKeyValue(t *testing.T) {
+	t.Run("test CreateIndex indexes the existing key-value pairs", func(t *testing.T) {
+		_, email, team := newIndexTestKeyValue(t)
+
+		if users := GetBy(email, "bob@example.com"); len(users) != 1 || users[0].ID != 2 {
+			t.Errorf("Expected users to be %v, got %v", []int{2}, users)
+		}
+
+		keys := GetKeysBy(team, "core")
+		slices.Sort(keys)
+		if !slices.Equal(keys, []string{"alice", "bob"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"alice", "bob"}, keys)
+		}
+	})
+
+	t.Run("test CreateIndex with an existing name", func(t *testing.T) {
+		kv, _, _ := newIndexTestKeyValue(t)
+
+		_, err := CreateIndex(kv, "email", func(u indexTestUser) int { return u.ID })
+		if !errors.Is(err, ErrIndexExists) {
+			t.Errorf("Expected error to be %v, got %v", ErrIndexExists, err)
+		}
+	})
+
+	t.Run("test CreateIndex unique with duplicated index keys", func(t *testing.T) {
+		kv, _, _ := newIndexTestKeyValue(t)
+
+		idx, err := CreateIndex(kv, "unique team", func(u indexTestUser) string { return u.Team }, WithUniqueIndex())
+		if !errors.Is(err, ErrIndexConflict) {
+			t.Errorf("Expected error to be %v, got %v", ErrIndexConflict, err)
+		}
+		if idx != nil {
+			t.Errorf("Expected index to be %v, got %v", nil, idx)
+		}
+		if kv.DropIndex("unique team") {
+			t.Errorf("Expected DropIndex to be %v, got %v", false, true)
+		}
+	})
+
+	t.Run("test DropIndex", func(t *testing.T) {
+		kv, _, team := newIndexTestKeyValue(t)
+
+		if !kv.DropIndex(team.Name()) {
+			t.Errorf("Expected DropIndex to be %v, got %v", true, false)
+		}
+		if kv.DropIndex("team") {
+			t.Errorf("Expected DropIndex to be %v, got %v", false, true)
+		}
+		if users := GetBy(team, "core"); users != nil {
+			t.Errorf("Expected users to be %v, got %v", nil, users)
+		}
+
+		// the dropped index doesn't find the values of a new index with the same name
+		if _, err := CreateIndex(kv, "team", func(u indexTestUser) string { return u.Team }); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if users := GetBy(team, "core"); users != nil {
+			t.Errorf("Expected users to be %v, got %v", nil, users)
+		}
+	})
+}
+
+func TestGetBy_MapKeyValue(t *testing.T) {
+	t.Run("test Set updates the indexes", func(t *testing.T) {
+		kv, email, team := newIndexTestKeyValue(t)
+
+		kv.Set("bob", indexTestUser{2, "bob@example.org", "web"})
+
+		if users := GetBy(email, "bob@example.com"); users != nil {
+			t.Errorf("Expected users to be %v, got %v", nil, users)
+		}
+		if users := GetBy(email, "bob@example.org"); len(users) != 1 {
+			t.Errorf("Expected users to be %v, got %v", 1, len(users))
+		}
+		if keys := GetKeysBy(team, "core"); !slices.Equal(keys, []string{"alice"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"alice"}, keys)
+		}
+		if users := GetBy(team, "web"); len(users) != 2 {
+			t.Errorf("Expected users to be %v, got %v", 2, len(users))
+		}
+	})
+
+	t.Run("test Delete, GetAnDelete and Clear update the indexes", func(t *testing.T) {
+		kv, email, team := newIndexTestKeyValue(t)
+
+		kv.Delete("alice")
+		if users := GetBy(email, "alice@example.com"); users != nil {
+			t.Errorf("Expected users to be %v, got %v", nil, users)
+		}
+
+		kv.GetAnDelete("carol")
+		if users := GetBy(team, "web"); users != nil {
+			t.Errorf("Expected users to be %v, got %v", nil, users)
+		}
+
+		kv.Clear()
+		if users := GetBy(team, "core"); users != nil {
+			t.Errorf("Expected users to be %v, got %v", nil, users)
+		}
+
+		kv.Set("dave", indexTestUser{4, "dave@example.com", "core"})
+		if keys := GetKeysBy(team, "core"); !slices.Equal(keys, []string{"dave"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"dave"}, keys)
+		}
+	})
+
+	t.Run("test GetBy with an int64 index key", func(t *testing.T) {
+		kv := NewMapKeyValue[string, indexTestUser]()
+		id, err := CreateIndex(kv, "id", func(u indexTestUser) int64 { return int64(u.ID) })
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		kv.Set("alice", indexTestUser{1, "alice@example.com", "core"})
+
+		// the untyped constant is converted to the index key type
+		if users := GetBy(id, 1); len(users) != 1 {
+			t.Errorf("Expected users to be %v, got %v", []int{1}, users)
+		}
+	})
+
+	t.Run("test GetBy skips the expired key-value pairs", func(t *testing.T) {
+		kv, email, _ := newIndexTestKeyValue(t)
+		now := time.Now()
+		kv.clock = func() time.Time { return now }
+
+		kv.SetWithTTL("erin", indexTestUser{5, "erin@example.com", "web"}, time.Second)
+		now = now.Add(time.Minute)
+
+		if users := GetBy(email, "erin@example.com"); users != nil {
+			t.Errorf("Expected users to be %v, got %v", nil, users)
+		}
+		if err := kv.Insert("frank", indexTestUser{6, "erin@example.com", "web"}); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+	})
+}
+
+func TestUniqueIndex_MapKeyValue(t *testing.T) {
+	t.Run("test Set replaces the key-value pair with the same unique index key", func(t *testing.T) {
+		kv, email, _ := newIndexTestKeyValue(t)
+		events := kv.Watch(context.Background(), nil)
+
+		kv.Set("robert", indexTestUser{2, "bob@example.com", "core"})
+
+		if kv.ContainsKey("bob") {
+			t.Errorf("Expected key %v to be deleted", "bob")
+		}
+		if keys := GetKeysBy(email, "bob@example.com"); !slices.Equal(keys, []string{"robert"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"robert"}, keys)
+		}
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+
+		if ev := <-events; ev.Op != OpDelete || ev.Key != "bob" {
+			t.Errorf("Expected event to be %v %v, got %v %v", OpDelete, "bob", ev.Op, ev.Key)
+		}
+		if ev := <-events; ev.Op != OpSet || ev.Key != "robert" {
+			t.Errorf("Expected event to be %v %v, got %v %v", OpSet, "robert", ev.Op, ev.Key)
+		}
+	})
+
+	t.Run("test Insert returns an error on unique index conflict", func(t *testing.T) {
+		kv, _, _ := newIndexTestKeyValue(t)
+
+		err := kv.Insert("robert", indexTestUser{2, "bob@example.com", "core"})
+		if !errors.Is(err, ErrIndexConflict) {
+			t.Errorf("Expected error to be %v, got %v", ErrIndexConflict, err)
+		}
+		if kv.ContainsKey("robert") || !kv.ContainsKey("bob") {
+			t.Errorf("Expected the container not to be modified, got %v", kv.Keys())
+		}
+
+		// the same key can keep its unique index key
+		if err := kv.Insert("bob", indexTestUser{2, "bob@example.com", "web"}); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+		if kv.Get("bob").Team != "web" {
+			t.Errorf("Expected team to be %v, got %v", "web", kv.Get("bob").Team)
+		}
+	})
+
+	t.Run("test evictions update the indexes", func(t *testing.T) {
+		kv := NewMapKeyValue[int, indexTestUser](WithMaxEntries(2))
+		team, err := CreateIndex(kv, "team", func(u indexTestUser) string { return u.Team })
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		kv.Set(1, indexTestUser{1, "alice@example.com", "core"})
+		kv.Set(2, indexTestUser{2, "bob@example.com", "core"})
+		kv.Set(3, indexTestUser{3, "carol@example.com", "core"})
+
+		keys := GetKeysBy(team, "core")
+		slices.Sort(keys)
+		if !slices.Equal(keys, []int{2, 3}) {
+			t.Errorf("Expected keys to be %v, got %v", []int{2, 3}, keys)
+		}
+	})
+}
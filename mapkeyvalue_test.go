@@ -5,9 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
-	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -178,1620 +176,176 @@ func TestNewMapKeyValue(t *testing.T) {
 	})
 }
 
-func TestSet_MapKeyValue(t *testing.T) {
-	t.Run("test Set for NewMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-	})
-}
-
-func TestGetAndCheck_MapKeyValue(t *testing.T) {
-	t.Run("test GetAndCheck for NewMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value, ok := kv.GetAndCheck("Archimedes")
-		if !ok {
-			t.Errorf("Expected GetAndCheck to return true, got %v", ok)
-		}
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-	})
-
-	t.Run("test GetAndCheck for NewMapKeyValue[string, struct] key doesn't exist", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		_, ok := kv.GetAndCheck("Euler")
-		if ok {
-			t.Errorf("Expected GetAndCheck to return true, got %v", ok)
-		}
-	})
-}
-
-func TestGet_MapKeyValue(t *testing.T) {
-	t.Run("test Get for NewMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-	})
-
-	t.Run("test Get for NewMapKeyValue[string, struct] key doesn't exist", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Euler")
-		if value.value != 0 {
-			t.Errorf("Expected value to be %v, got %v", nil, value)
-		}
-	})
-}
-
-func TestGetAnDelete_MapKeyValue(t *testing.T) {
-	t.Run("test GetAnDelete for NewMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		value, ok := kv.GetAnDelete("Archimedes")
-		if !ok {
-			t.Errorf("Expected GetAnDelete to return true, got %v", ok)
-		}
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-
-		if kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
-		}
-	})
-
-	t.Run("test GetAnDelete for NewMapKeyValue[string, struct] key doesn't exist", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value, ok := kv.GetAnDelete("Euler")
-		if ok {
-			t.Errorf("Expected GetAnDelete to return true, got %v", ok)
-		}
-
-		if value.value != 0 {
-			t.Errorf("Expected value to be %v, got %v", nil, value)
-		}
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-	})
-}
-
-func TestDelete_MapKeyValue(t *testing.T) {
-	t.Run("test Delete for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-
-		kv.Delete("Archimedes")
-
-		if kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
-		}
-
-		value = kv.Get("Golden Ratio")
-
-		if value.Name != "This is The Golden Ratio" {
-			t.Errorf("Expected value to be %s, got %s", "This is The Golden Ratio", value.Name)
-		}
-		if value.value != 1.6180 {
-			t.Errorf("Expected value to be %v, got %v", 1.6180, value.value)
-		}
-	})
-
-	t.Run("test Delete for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		kv.Delete("Archimedes")
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-}
-
-func TestClear_MapKeyValue(t *testing.T) {
-	t.Run("test Clear for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-
-		kv.Clear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-
-	t.Run("test Clear for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		kv.Clear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-}
-
-func TestSize_MapKeyValue(t *testing.T) {
-	t.Run("test Size for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.Delete("Archimedes")
-
-		if kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
-		}
-
-		kv.Delete("Euler")
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		kv.Clear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-
-	t.Run("test Size for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		kv.Delete("Euler")
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-}
-
-func TestIsEmpty_MapKeyValue(t *testing.T) {
-	t.Run("test IsEmpty for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		if kv.IsEmpty() != false {
-			t.Errorf("Expected IsEmpty to be %v, got %v", false, kv.IsEmpty())
-		}
-
-		kv.Clear()
-
-		if kv.IsEmpty() != true {
-			t.Errorf("Expected IsEmpty to be %v, got %v", true, kv.IsEmpty())
-		}
-	})
-}
-
-func TestIsFull_MapKeyValue(t *testing.T) {
-	t.Run("test IsFull for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		if kv.IsFull() != true {
-			t.Errorf("Expected IsFull to be %v, got %v", false, kv.IsFull())
-		}
-
-		kv.Clear()
-
-		if kv.IsFull() != false {
-			t.Errorf("Expected IsFull to be %v, got %v", true, kv.IsFull())
-		}
-	})
-}
-
-func TestContainsKey_MapKeyValue(t *testing.T) {
-	t.Run("test ContainsKey for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		if kv.ContainsKey("Archimedes") != true {
-			t.Errorf("Expected key to be %v, got %v", true, kv.ContainsKey("Archimedes"))
-		}
-
-		if kv.ContainsKey("Do not exist") != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsKey("Do not exist"))
-		}
-	})
-
-	t.Run("test ContainsKey for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		if kv.ContainsKey("Do not exist") != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsKey("Do not exist"))
-		}
-	})
-}
-
-func TestContainsValue_MapKeyValue(t *testing.T) {
-	t.Run("test ContainsValue for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		if kv.ContainsValue(testStruct{"This is Archimedes' Constant (Pi)", 3.1415}) != true {
-			t.Errorf("Expected key to be %v, got %v", true, kv.ContainsValue(testStruct{"This is Archimedes' Constant (Pi)", 3.1415}))
-		}
-
-		if kv.ContainsValue(testStruct{"This is other constant", 0.00000}) != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsValue(testStruct{"This is other constant", 0.00000}))
-		}
-	})
-
-	t.Run("test ContainsValue for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		if kv.ContainsValue(testStruct{"This is other constant", 0.00000}) != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsValue(testStruct{"This is other constant", 0.00000}))
-		}
-	})
-}
-
-func TestKey_MapKeyValue(t *testing.T) {
-	t.Run("test Key for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		if kv.Key("Archimedes") != "Archimedes" {
-			t.Errorf("Expected key to be %v, got %v", "Archimedes", kv.Key("Archimedes"))
-		}
-
-		if kv.Key("Do Not Exist") != "" {
-			t.Errorf("Expected key to be %v, got %v", "Archimedes", kv.Key("Do Not Exist"))
-		}
-	})
-}
-
-func TestKeys_MapKeyValue(t *testing.T) {
-	t.Run("test Keys for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		keys := kv.Keys()
-
-		if len(keys) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(keys))
-		}
-
-		for _, key := range keys {
-			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be %v, got %v", true, key)
-			}
-		}
-	})
-
-	t.Run("test Keys for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		keys := kv.Keys()
-
-		if len(keys) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(keys))
-		}
-	})
-}
-
-func TestValues_MapKeyValue(t *testing.T) {
-	t.Run("test Values for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		values := kv.Values()
-
-		if len(values) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(values))
-		}
-
-		for _, val := range values {
-			if val.Name != "This is Archimedes' Constant (Pi)" && val.Name != "This is Euler's Number (e)" && val.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value to be %v, got %v", true, val)
-			}
-
-			if val.value != 3.1415 && val.value != 2.7182 && val.value != 1.6180 {
-				t.Errorf("Expected value to be %v, got %v", true, val)
-			}
-		}
-	})
-
-	t.Run("test Values for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		values := kv.Values()
-
-		if len(values) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(values))
-		}
-	})
-}
-
-func TestEach_MapKeyValue(t *testing.T) {
-	t.Run("test Each for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.ForEach(func(key string, value testStruct) {
-			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be %v, got %v", true, key)
-			}
-
-			if value.Name != "This is Archimedes' Constant (Pi)" && value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-
-			if value.value != 3.1415 && value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-		})
-	})
-
-	t.Run("test Each for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.ForEach(func(key string, value testStruct) {
-			t.Errorf("Expected Each to not be called, got %v", true)
-		})
-	})
-}
-
-func TestEachKey_MapKeyValue(t *testing.T) {
-	t.Run("test EachKey for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.ForEachKey(func(key string) {
-			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be %v, got %v", true, key)
-			}
-		})
-	})
-
-	t.Run("test EachKey for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.ForEachKey(func(key string) {
-			t.Errorf("Expected EachKey to not be called, got %v", true)
-		})
-	})
-}
-
-func TestEachValue_MapKeyValue(t *testing.T) {
-	t.Run("test EachValue for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.ForEachValue(func(value testStruct) {
-			if value.Name != "This is Archimedes' Constant (Pi)" && value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-
-			if value.value != 3.1415 && value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-		})
-	})
-
-	t.Run("test EachValue for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.ForEachValue(func(value testStruct) {
-			t.Errorf("Expected EachValue to not be called, got %v", true)
-		})
-	})
-}
-
-func TestClone_MapKeyValue(t *testing.T) {
-	t.Run("test Clone for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kvClone := kv.Clone()
-
-		if kvClone.Size() != kv.Size() {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		if reflect.DeepEqual(kv, kvClone) == false {
-			t.Errorf("Expected Clone to be equal to original, got %v", true)
-		}
-
-		kvKeys := kv.Keys()
-		kvValues := kv.Values()
-
-		kvCloneKeys := kvClone.Keys()
-
-		sort.Strings(kvKeys)
-		sort.Strings(kvCloneKeys)
-
-		if reflect.DeepEqual(kvKeys, kvCloneKeys) == false {
-			t.Errorf("Expected keys to be equal, got %v", true)
-		}
-
-		for _, kvValue := range kvValues {
-			if kvClone.ContainsValue(kvValue) == false {
-				t.Errorf("Expected Clone to contain value, got %v", true)
-			}
-		}
-	})
-
-	t.Run("test Clone for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kvClone := kv.Clone()
-
-		if kvClone.Size() != kv.Size() {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		if reflect.DeepEqual(kv, kvClone) == false {
-			t.Errorf("Expected Clone to be equal to original, got %v", true)
-		}
-	})
-}
-
-func TestCloneAndClear_MapKeyValue(t *testing.T) {
-	t.Run("test CloneAndClear for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kvClone := kv.CloneAndClear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		if kvClone.Size() == kv.Size() {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		if reflect.DeepEqual(kv, kvClone) == true {
-			t.Errorf("Expected Clone to be not equal to original, got %v", true)
-		}
-
-		kvKeys := kv.Keys()
-		kvCloneKeys := kvClone.Keys()
-
-		sort.Strings(kvKeys)
-		sort.Strings(kvCloneKeys)
-
-		if reflect.DeepEqual(kvKeys, kvCloneKeys) == true {
-			t.Errorf("Expected keys to be not equal, got %v", true)
-		}
-	})
-
-	t.Run("test CloneAndClear for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kvClone := kv.CloneAndClear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		if kvClone.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		if reflect.DeepEqual(kv, kvClone) == false {
-			t.Errorf("Expected Clone to be equal to original, got %v", true)
-		}
-	})
-}
-
-func TestDeepEqual_MapKeyValue(t *testing.T) {
-	t.Run("test DeepEqual for NewMapKeyValue[string, struct] with keys disordered and same size", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewMapKeyValue[string, testStruct]()
-		kv2 := NewMapKeyValue[string, testStruct]()
-
-		kv1.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv1.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv1.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		kv2.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-		kv2.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv2.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-
-		if kv1.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
-		}
-		if kv2.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", kv1.DeepEqual(kv2))
-		}
-
-		if kv2.DeepEqual(kv1) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", kv2.DeepEqual(kv1))
-		}
-	})
-
-	t.Run("test DeepEqual for NewMapKeyValue[string, struct] with keys and it is not equal same size", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewMapKeyValue[string, testStruct]()
-		kv2 := NewMapKeyValue[string, testStruct]()
-
-		kv1.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv1.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv1.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		kv2.Set("key 1", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv2.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv2.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv1.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
-		}
-		if kv2.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == true {
-			t.Errorf("Expected DeepEqual to be different, got %v", kv1.DeepEqual(kv2))
-		}
-
-		if kv2.DeepEqual(kv1) == true {
-			t.Errorf("Expected DeepEqual to be different, got %v", kv2.DeepEqual(kv1))
-		}
-	})
-
-	t.Run("test DeepEqual for NewMapKeyValue[string, struct] with keys and it is different and different size", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewMapKeyValue[string, testStruct]()
-		kv2 := NewMapKeyValue[string, testStruct]()
-
-		kv1.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv1.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv1.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		kv2.Set("key 1", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv1.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
-		}
-		if kv2.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == true {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-
-		if kv2.DeepEqual(kv1) == true {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-	})
-
-	t.Run("test DeepEqual for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewMapKeyValue[string, testStruct]()
-		kv2 := NewMapKeyValue[string, testStruct]()
-
-		if kv1.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv1.Size())
-		}
-		if kv2.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-		if kv2.DeepEqual(kv1) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-	})
-}
-
-func TestMap_MapKeyValue(t *testing.T) {
-	t.Run("test Map for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.Map(func(key string, value testStruct) (newKey string, newValue testStruct) {
-			newKey = key
-			newValue.Name = strings.ToUpper(value.Name)
-			newValue.value = value.value * 2
-			return
-		})
-
-		newKv.ForEach(func(key string, value testStruct) {
-			if kv.Key(key) != key {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", strings.ToUpper(key), key)
-			}
-			if strings.ToUpper(kv.Get(key).Name) != value.Name {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", strings.ToUpper(kv.Get(key).Name), value.Name)
-			}
-			if kv.Get(key).value*2 != value.value {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", value.value*2, value.value)
-			}
-		})
-	})
-
-	t.Run("test Map for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		newKv := kv.Map(func(key string, value testStruct) (newKey string, newValue testStruct) {
-			newKey = strings.ToUpper(key)
-			newValue = value
-			return
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
-
-func TestMapKey_MapKeyValue(t *testing.T) {
-	t.Run("test MapKey for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.MapKey(func(key string) string {
-			return strings.ToUpper(key)
-		})
-
-		newKv.ForEach(func(key string, value testStruct) {
-			if strings.ToUpper(kv.Key(strings.Title(strings.ToLower(key)))) != key {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(strings.Title(strings.ToLower(key))), key)
-			}
-			if kv.Get(strings.Title(strings.ToLower(key))).Name != value.Name {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", kv.Get(strings.Title(strings.ToLower(key))).Name, value.Name)
-			}
-			if kv.Get(strings.Title(strings.ToLower(key))).value != value.value {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(strings.Title(strings.ToLower(key))).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test MapKey for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		newKv := kv.MapKey(func(key string) string {
-			return strings.ToUpper(key)
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
-
-func TestMapValue_MapKeyValue(t *testing.T) {
-	t.Run("test MapValue for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.MapValue(func(value testStruct) testStruct {
-			value.Name = strings.ToUpper(value.Name)
-			value.value = value.value * 2
-			return value
-		})
-
-		newKv.ForEach(func(key string, value testStruct) {
-			if kv.Key(key) != key {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if strings.ToUpper(kv.Get(key).Name) != value.Name {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", kv.Get(key).Name, value.Name)
-			}
-			if kv.Get(key).value*2 != value.value {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test MapValue for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		newKv := kv.MapValue(func(value testStruct) testStruct {
-			value.Name = strings.ToUpper(value.Name)
-			value.value = value.value * 2
-			return value
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
+func TestDrain_MapKeyValue(t *testing.T) {
+	t.Run("test Drain doesn't lose the concurrent writes", func(t *testing.T) {
+		const writers, writes = 8, 1000
+		kv := NewMapKeyValue[int, int]()
 
-func TestFilter_MapKeyValue(t *testing.T) {
-	t.Run("test Filter for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < writes; i++ {
+					kv.Set(w*writes+i, i)
+				}
+			}(w)
 		}
-		kv := NewMapKeyValue[string, testStruct]()
 
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
+		stop := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(stop)
+		}()
 
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		seen := make(map[int]int)
+		collect := func(drained *MapKeyValue[int, int]) {
+			drained.ForEachKey(func(key int) {
+				seen[key]++
+			})
 		}
-
-		newKv := kv.Filter(func(key string, value testStruct) bool {
-			return strings.Contains(value.Name, "Constant")
-		})
-
-		newKv.ForEach(func(key string, value testStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+		for done := false; !done; {
+			select {
+			case <-stop:
+				done = true
+			default:
 			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test Filter for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
+			collect(kv.Drain())
 		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		newKv := kv.Filter(func(key string, value testStruct) bool {
-			return strings.Contains(value.Name, "Constant")
-		})
 
-		if kv.Size() != 0 {
+		if !kv.IsEmpty() {
 			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
 		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
-
-func TestFilterKey_MapKeyValue(t *testing.T) {
-	t.Run("test FilterKey for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		if len(seen) != writers*writes {
+			t.Errorf("Expected drained keys to be %v, got %v", writers*writes, len(seen))
 		}
-
-		newKv := kv.FilterKey(func(key string) bool {
-			return strings.Contains(key, "chime")
-		})
-
-		newKv.ForEach(func(key string, value testStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
+		for key, n := range seen {
+			if n != 1 {
+				t.Errorf("Expected key %v to be drained %v time, got %v", key, 1, n)
 			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test FilterKey for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		newKv := kv.FilterKey(func(key string) bool {
-			return strings.Contains(key, "chime")
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
-
-func TestFilterValue_MapKeyValue(t *testing.T) {
-	t.Run("test FilterValue for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
 		}
-
-		newKv := kv.FilterValue(func(value testStruct) bool {
-			return value.value > 3
-		})
-
-		if newKv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, newKv.Size())
-		}
-
-		newKv.ForEach(func(key string, value testStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
 	})
 
-	t.Run("test FilterValue for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
+	t.Run("test Drain returns the previous key-value pairs", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
+		kv.Set("two", 2)
 
-		newKv := kv.FilterValue(func(value testStruct) bool {
-			return value.value > 3
-		})
+		drained := kv.Drain()
+		kv.Set("three", 3)
 
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		if drained.Size() != 2 || drained.Get("one") != 1 || drained.Get("two") != 2 {
+			t.Errorf("Expected drained keys to be %v, got %v", []string{"one", "two"}, drained.Keys())
 		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		if kv.Size() != 1 || !kv.ContainsKey("three") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"three"}, kv.Keys())
 		}
 	})
 }
 
-func TestPartition_MapKeyValue(t *testing.T) {
-	t.Run("test Partition for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		grp1Kv, grp2Kv := kv.Partition(func(key string, value testStruct) bool {
-			return value.value > 3
-		})
-
-		if grp1Kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
-		}
-		if grp2Kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
-		}
-
-		grp1Kv.ForEach(func(key string, value testStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-
-		grp2Kv.ForEach(func(key string, value testStruct) {
-			if key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
-			}
-			if value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test Partition for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
+func TestSwap_MapKeyValue(t *testing.T) {
+	t.Run("test Swap replaces the key-value pairs", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
 
-		grp1Kv, grp2Kv := kv.Partition(func(key string, value testStruct) bool {
-			return value.value > 3
-		})
+		newData := map[string]int{"two": 2, "three": 3}
+		old := kv.Swap(newData)
+		newData["four"] = 4
 
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if grp1Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
+		if old.Size() != 1 || old.Get("one") != 1 {
+			t.Errorf("Expected old keys to be %v, got %v", []string{"one"}, old.Keys())
 		}
-
-		if grp2Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
+		if kv.Size() != 2 || kv.Get("two") != 2 || kv.Get("three") != 3 || kv.ContainsKey("one") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two", "three"}, kv.Keys())
 		}
 	})
 }
 
-func TestPartitionKey_MapKeyValue(t *testing.T) {
-	t.Run("test PartitionKey for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		grp1Kv, grp2Kv := kv.PartitionKey(func(key string) bool {
-			return key == "Archimedes"
-		})
-
-		if grp1Kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
-		}
-		if grp2Kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
+func TestCallbacks_MapKeyValue(t *testing.T) {
+	filled := func() *MapKeyValue[int, int] {
+		kv := NewMapKeyValue[int, int]()
+		for i := 0; i < 10; i++ {
+			kv.Set(i, i)
 		}
+		return kv
+	}
 
-		grp1Kv.ForEach(func(key string, value testStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
+	t.Run("test Filter and Map can modify the container", func(t *testing.T) {
+		kv := filled()
 
-		grp2Kv.ForEach(func(key string, value testStruct) {
-			if key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
-			}
-			if value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
+		var even *MapKeyValue[int, int]
+		withoutDeadlock(t, func() {
+			even = kv.Filter(func(key int, value int) bool {
+				if key%2 != 0 {
+					kv.Delete(key)
+					return false
+				}
+				return true
+			})
 		})
-	})
-
-	t.Run("test PartitionKey for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
+		if even.Size() != 5 || kv.Size() != 5 {
+			t.Errorf("Expected sizes to be %v, got %v and %v", 5, even.Size(), kv.Size())
 		}
-		kv := NewMapKeyValue[string, testStruct]()
 
-		grp1Kv, grp2Kv := kv.PartitionKey(func(key string) bool {
-			return key == "Archimedes"
+		withoutDeadlock(t, func() {
+			kv.Map(func(key int, value int) (int, int) {
+				kv.Set(key, value*10)
+				return key, value
+			})
 		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if grp1Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
-		}
-
-		if grp2Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
+		if kv.Get(4) != 40 {
+			t.Errorf("Expected value to be %v, got %v", 40, kv.Get(4))
 		}
 	})
-}
-
-func TestPartitionValue_MapKeyValue(t *testing.T) {
-	t.Run("test PartitionValue for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
 
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
+	t.Run("test Partition and SortValues can read the container", func(t *testing.T) {
+		kv := filled()
 
-		grp1Kv, grp2Kv := kv.PartitionValue(func(value testStruct) bool {
-			return value.value > 3
+		withoutDeadlock(t, func() {
+			kv.Partition(func(key int, value int) bool {
+				return kv.ContainsKey(key + 1)
+			})
+			kv.SortValues(func(value1, value2 int) bool {
+				return kv.Get(value1) < kv.Get(value2)
+			})
 		})
+	})
 
-		if grp1Kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
-		}
-		if grp2Kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
-		}
+	t.Run("test Clone and DeepEqual with a queued writer", func(t *testing.T) {
+		kv := filled()
 
-		grp1Kv.ForEach(func(key string, value testStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+		// a writer waiting for the lock blocks the new readers, so a re-entrant read lock deadlocks
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					kv.Set(100, 100)
+				}
 			}
-		})
+		}()
 
-		grp2Kv.ForEach(func(key string, value testStruct) {
-			if key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+		withoutDeadlock(t, func() {
+			for i := 0; i < 1000; i++ {
+				kv.Clone()
+				kv.DeepEqual(kv)
 			}
-			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
-			}
-			if value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test PartitionValue for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		grp1Kv, grp2Kv := kv.PartitionValue(func(value testStruct) bool {
-			return value.value > 3
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if grp1Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
-		}
-
-		if grp2Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
-		}
-	})
-}
-
-func TestSortKeys_MapKeyValue(t *testing.T) {
-	t.Run("test SortKeys for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kSorted := kv.SortKeys(func(key1 string, key2 string) bool {
-			return key1 < key2
-		})
-
-		if len(kSorted) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(kSorted))
-		}
-
-		if *kSorted[0] != "Archimedes" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "Archimedes", *kSorted[0])
-		}
-	})
-
-	t.Run("test SortKeys for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kSorted := kv.SortKeys(func(key1 string, key2 string) bool {
-			return key1 < key2
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if len(kSorted) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(kSorted))
-		}
-	})
-}
-
-func TestSortValues_MapKeyValue(t *testing.T) {
-	t.Run("test SortValues for NewMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
-
-		kv.Set("Archimedes", testStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Golden Ratio", testStruct{"This is The Golden Ratio", 1.6180})
-		kv.Set("Euler", testStruct{"This is Euler's Number (e)", 2.7182})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		vSorted := kv.SortValues(func(value1 testStruct, value2 testStruct) bool {
-			return value1.value < value2.value
 		})
-
-		if len(vSorted) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(vSorted))
-		}
-
-		if vSorted[0].Name != "This is The Golden Ratio" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is The Golden Ratio", vSorted[0].Name)
-		}
-		if vSorted[1].Name != "This is Euler's Number (e)" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is Euler's Number (e)", vSorted[1].Name)
-		}
-		if vSorted[2].Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", vSorted[2].Name)
-		}
 	})
 
-	t.Run("test SortValues for NewMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type testStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewMapKeyValue[string, testStruct]()
+	t.Run("test the iteration sees a snapshot", func(t *testing.T) {
+		kv := filled()
 
-		vSorted := kv.SortValues(func(value1 testStruct, value2 testStruct) bool {
-			return value1.value < value2.value
+		visited := 0
+		kv.ForEach(func(key int, value int) {
+			kv.Set(key+100, value)
+			visited++
 		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if len(vSorted) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(vSorted))
+		if visited != 10 || kv.Size() != 20 {
+			t.Errorf("Expected visited and size to be %v and %v, got %v and %v", 10, 20, visited, kv.Size())
 		}
 	})
 }
@@ -1969,3 +523,66 @@ func BenchmarkMapKeyValue_Set_Get_string_struct_concurrent(b *testing.B) {
 
 	wg.Wait()
 }
+
+func BenchmarkMapKeyValue_Set_Get_string_struct_parallel(b *testing.B) {
+	kv := NewMapKeyValue[string, TestStruct](WithCapacity(kvSize))
+
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			keyval := fmt.Sprintf("%x", md5.Sum([]byte(strconv.Itoa(rnd.Intn(kvSize)))))
+			kv.Set(keyval, TestStruct{a: keyval, b: rnd.Intn(kvSize)})
+			kv.Get(keyval)
+		}
+	})
+}
+
+// benchmarkHitRatio runs the keys generated by next against a bounded MapKeyValue for each
+// built-in eviction policy and reports the hit ratio.
+func benchmarkHitRatio(b *testing.B, next func(rnd *rand.Rand) func() int) {
+	const capacity = 1024
+
+	for _, p := range evictionPolicies {
+		b.Run(p.name, func(b *testing.B) {
+			kv := NewMapKeyValue[int, int](WithMaxEntries(capacity), WithEvictionPolicy(p.newPolicy))
+			key := next(rand.New(rand.NewSource(1)))
+
+			hits := 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				k := key()
+				if _, ok := kv.GetAndCheck(k); ok {
+					hits++
+				} else {
+					kv.Set(k, k)
+				}
+			}
+
+			b.ReportMetric(float64(hits)*100/float64(b.N), "hit%")
+		})
+	}
+}
+
+func BenchmarkMapKeyValue_HitRatio_zipf(b *testing.B) {
+	benchmarkHitRatio(b, func(rnd *rand.Rand) func() int {
+		zipf := rand.NewZipf(rnd, 1.01, 1, kvSize*16)
+		return func() int {
+			return int(zipf.Uint64())
+		}
+	})
+}
+
+func BenchmarkMapKeyValue_HitRatio_zipf_scan(b *testing.B) {
+	benchmarkHitRatio(b, func(rnd *rand.Rand) func() int {
+		zipf := rand.NewZipf(rnd, 1.01, 1, kvSize*16)
+		scan := 0
+		return func() int {
+			// one of every four keys is part of a sequential scan never repeated
+			if rnd.Intn(4) == 0 {
+				scan++
+				return -scan
+			}
+			return int(zipf.Uint64())
+		}
+	})
+}
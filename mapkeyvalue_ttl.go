@@ -0,0 +1,185 @@
+package r9e
+
+import (
+	"time"
+)
+
+// DefaultCleanupInterval is the default interval used by the janitor to remove expired key-value pairs.
+const DefaultCleanupInterval = time.Minute
+
+// NoExpiration is returned by TTL when the key exists but it doesn't expire.
+const NoExpiration time.Duration = -1
+
+// WithDefaultTTL sets the time to live used by Set for every key-value pair stored in the MapKeyValue container.
+// A ttl less or equal than zero means the key-value pairs never expire.
+func WithDefaultTTL(ttl time.Duration) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.defaultTTL = ttl
+	}
+}
+
+// WithCleanupInterval sets the interval used by the janitor to remove expired key-value pairs
+// from the MapKeyValue container. The default value is DefaultCleanupInterval.
+func WithCleanupInterval(interval time.Duration) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.cleanupInterval = interval
+	}
+}
+
+// SetWithTTL sets the value associated with the key and expires it after the given ttl.
+// A ttl less or equal than zero means the key never expires.
+func (r *MapKeyValue[K, T]) SetWithTTL(key K, value T, ttl time.Duration) {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.set(key, value, r.deadline(ttl))
+}
+
+// ExpireAt sets the time when the key expires.
+// If the given time is in the past the key is deleted immediately.
+// Returns false if the key doesn't exist.
+func (r *MapKeyValue[K, T]) ExpireAt(key K, at time.Time) bool {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.data[key]; !ok || r.expired(key) {
+		return false
+	}
+
+	if !at.After(r.now()) {
+		r.delete(key, OpExpire)
+		return true
+	}
+
+	r.expires[key] = at
+	r.startJanitor()
+	r.track(key)
+	r.logExpire(key, at)
+	return true
+}
+
+// TTL returns the remaining time to live of the key and true if the key exists.
+// If the key exists but it doesn't expire, return NoExpiration.
+func (r *MapKeyValue[K, T]) TTL(key K) (time.Duration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.data[key]; !ok || r.expired(key) {
+		return 0, false
+	}
+
+	at, ok := r.expires[key]
+	if !ok {
+		return NoExpiration, true
+	}
+	return at.Sub(r.now()), true
+}
+
+// Persist removes the expiration of the key, so it never expires.
+// Returns true if the key exists and the expiration was removed.
+func (r *MapKeyValue[K, T]) Persist(key K) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.expires[key]; !ok || r.expired(key) {
+		return false
+	}
+
+	delete(r.expires, key)
+	r.track(key)
+	r.logExpire(key, time.Time{})
+	return true
+}
+
+// Close stops the janitor of the container.
+// The container can be used after Close, but the expired key-value pairs are not removed anymore
+// from the memory, although they are never returned.
+// If the container was opened using OpenMapKeyValue, the write-ahead log is flushed and closed,
+// and the first error found writing the log is returned.
+func (r *MapKeyValue[K, T]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	r.closed = true
+	if r.done != nil {
+		close(r.done)
+	}
+	if r.wal != nil {
+		return r.wal.close()
+	}
+	return nil
+}
+
+// expired returns true if the key has an expiration time and this is already reached.
+// The caller must hold the lock.
+func (r *MapKeyValue[K, T]) expired(key K) bool {
+	at, ok := r.expires[key]
+	return ok && !at.After(r.now())
+}
+
+// deadline returns the expiration time for the given ttl, or the zero time if the ttl
+// is less or equal than zero.
+func (r *MapKeyValue[K, T]) deadline(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return r.now().Add(ttl)
+}
+
+// now returns the current time used to check the expiration of the keys.
+func (r *MapKeyValue[K, T]) now() time.Time {
+	if r.clock != nil {
+		return r.clock()
+	}
+	return time.Now()
+}
+
+// startJanitor starts only once the goroutine that removes the expired key-value pairs.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) startJanitor() {
+	if r.closed || r.done != nil || r.cleanupInterval <= 0 {
+		return
+	}
+
+	r.done = make(chan struct{})
+	go r.janitor(r.cleanupInterval, r.done)
+}
+
+// janitor removes periodically the expired key-value pairs until the container is closed.
+func (r *MapKeyValue[K, T]) janitor(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.DeleteExpired()
+		case <-done:
+			return
+		}
+	}
+}
+
+// DeleteExpired removes all the expired key-value pairs from the container.
+// This is called periodically by the janitor, but it can be called at any time.
+func (r *MapKeyValue[K, T]) DeleteExpired() {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for key, at := range r.expires {
+		if !at.After(now) {
+			r.delete(key, OpExpire)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package r9e
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manual clock used to control the expiration of the key-value pairs.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestSetWithTTL_MapKeyValue(t *testing.T) {
+	t.Run("test SetWithTTL for NewMapKeyValue[string, int] before and after expiration", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("one", 1, time.Second)
+		kv.Set("two", 2)
+
+		if v, ok := kv.GetAndCheck("one"); !ok || v != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, v)
+		}
+
+		clock.Advance(time.Second)
+
+		if v, ok := kv.GetAndCheck("one"); ok {
+			t.Errorf("Expected key to be expired, got %v", v)
+		}
+		if v := kv.Get("one"); v != 0 {
+			t.Errorf("Expected value to be %v, got %v", 0, v)
+		}
+		if kv.ContainsKey("one") {
+			t.Errorf("Expected ContainsKey to be %v, got %v", false, true)
+		}
+		if kv.ContainsValue(1) {
+			t.Errorf("Expected ContainsValue to be %v, got %v", false, true)
+		}
+		if keys := kv.Keys(); len(keys) != 1 || keys[0] != "two" {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two"}, keys)
+		}
+		if values := kv.Values(); len(values) != 1 || values[0] != 2 {
+			t.Errorf("Expected values to be %v, got %v", []int{2}, values)
+		}
+
+		kv.ForEach(func(key string, value int) {
+			if key == "one" {
+				t.Errorf("Expected ForEach to skip expired key, got %v", key)
+			}
+		})
+
+		if filtered := kv.Filter(func(key string, value int) bool { return true }); filtered.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, filtered.Size())
+		}
+	})
+
+	t.Run("test SetWithTTL for NewMapKeyValue[string, int] with ttl equal zero", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("one", 1, 0)
+		clock.Advance(time.Hour)
+
+		if !kv.ContainsKey("one") {
+			t.Errorf("Expected ContainsKey to be %v, got %v", true, false)
+		}
+	})
+
+	t.Run("test Set removes the previous expiration", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("one", 1, time.Second)
+		kv.Set("one", 11)
+		clock.Advance(time.Hour)
+
+		if v := kv.Get("one"); v != 11 {
+			t.Errorf("Expected value to be %v, got %v", 11, v)
+		}
+	})
+
+	t.Run("test GetAnDelete for expired key", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("one", 1, time.Second)
+		clock.Advance(2 * time.Second)
+
+		if v, ok := kv.GetAnDelete("one"); ok {
+			t.Errorf("Expected key to be expired, got %v", v)
+		}
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+
+	t.Run("test DeepEqual ignores the expired keys", func(t *testing.T) {
+		clock := newFakeClock()
+		kv1 := NewMapKeyValue[string, int]()
+		kv1.clock = clock.Now
+		defer kv1.Close()
+		kv2 := NewMapKeyValue[string, int]()
+		kv2.clock = clock.Now
+		defer kv2.Close()
+
+		kv1.Set("one", 1)
+		kv1.SetWithTTL("two", 2, time.Second)
+		kv2.Set("one", 1)
+		kv2.SetWithTTL("three", 3, time.Second)
+		clock.Advance(time.Second)
+
+		if !kv1.DeepEqual(kv2) || !kv2.DeepEqual(kv1) {
+			t.Errorf("Expected DeepEqual to be %v, got %v", true, false)
+		}
+
+		kv2.Set("three", 3)
+
+		if kv1.DeepEqual(kv2) || kv2.DeepEqual(kv1) {
+			t.Errorf("Expected DeepEqual to be %v, got %v", false, true)
+		}
+	})
+}
+
+func TestWithDefaultTTL_MapKeyValue(t *testing.T) {
+	t.Run("test Set with default ttl", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int](WithDefaultTTL(time.Minute))
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.Set("one", 1)
+		kv.SetWithTTL("two", 2, time.Hour)
+
+		if ttl, ok := kv.TTL("one"); !ok || ttl != time.Minute {
+			t.Errorf("Expected ttl to be %v, got %v", time.Minute, ttl)
+		}
+
+		clock.Advance(time.Minute)
+
+		keys := kv.Keys()
+		sort.Strings(keys)
+		if len(keys) != 1 || keys[0] != "two" {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two"}, keys)
+		}
+	})
+}
+
+func TestExpireAt_MapKeyValue(t *testing.T) {
+	t.Run("test ExpireAt for existing and missing keys", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+
+		if !kv.ExpireAt("one", clock.Now().Add(time.Second)) {
+			t.Errorf("Expected ExpireAt to be %v, got %v", true, false)
+		}
+		if kv.ExpireAt("three", clock.Now().Add(time.Second)) {
+			t.Errorf("Expected ExpireAt to be %v, got %v", false, true)
+		}
+
+		if !kv.ExpireAt("two", clock.Now().Add(-time.Second)) {
+			t.Errorf("Expected ExpireAt to be %v, got %v", true, false)
+		}
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+
+		clock.Advance(time.Second)
+
+		if kv.ContainsKey("one") {
+			t.Errorf("Expected ContainsKey to be %v, got %v", false, true)
+		}
+	})
+}
+
+func TestTTL_MapKeyValue(t *testing.T) {
+	t.Run("test TTL for keys with, without expiration and missing", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("one", 1, 10*time.Second)
+		kv.Set("two", 2)
+
+		clock.Advance(4 * time.Second)
+
+		if ttl, ok := kv.TTL("one"); !ok || ttl != 6*time.Second {
+			t.Errorf("Expected ttl to be %v, got %v", 6*time.Second, ttl)
+		}
+		if ttl, ok := kv.TTL("two"); !ok || ttl != NoExpiration {
+			t.Errorf("Expected ttl to be %v, got %v", NoExpiration, ttl)
+		}
+		if _, ok := kv.TTL("three"); ok {
+			t.Errorf("Expected TTL to be %v, got %v", false, true)
+		}
+
+		clock.Advance(6 * time.Second)
+
+		if _, ok := kv.TTL("one"); ok {
+			t.Errorf("Expected TTL to be %v, got %v", false, true)
+		}
+	})
+}
+
+func TestPersist_MapKeyValue(t *testing.T) {
+	t.Run("test Persist removes the expiration", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("one", 1, time.Second)
+		kv.Set("two", 2)
+
+		if !kv.Persist("one") {
+			t.Errorf("Expected Persist to be %v, got %v", true, false)
+		}
+		if kv.Persist("two") {
+			t.Errorf("Expected Persist to be %v, got %v", false, true)
+		}
+
+		clock.Advance(time.Hour)
+
+		if v := kv.Get("one"); v != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, v)
+		}
+	})
+}
+
+func TestDeleteExpired_MapKeyValue(t *testing.T) {
+	t.Run("test DeleteExpired removes only expired keys", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[int, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		for i := 0; i < 10; i++ {
+			kv.SetWithTTL(i, i, time.Duration(i+1)*time.Second)
+		}
+
+		clock.Advance(5 * time.Second)
+		kv.DeleteExpired()
+
+		if kv.Size() != 5 {
+			t.Errorf("Expected size to be %v, got %v", 5, kv.Size())
+		}
+	})
+}
+
+func TestJanitor_MapKeyValue(t *testing.T) {
+	t.Run("test janitor removes expired keys until Close", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithCleanupInterval(5 * time.Millisecond))
+
+		for i := 0; i < 100; i++ {
+			kv.SetWithTTL(i, i, time.Millisecond)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for kv.Size() != 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+
+		if err := kv.Close(); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+		if err := kv.Close(); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+
+		kv.SetWithTTL(1, 1, time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+		if kv.ContainsKey(1) {
+			t.Errorf("Expected ContainsKey to be %v, got %v", false, true)
+		}
+	})
+}
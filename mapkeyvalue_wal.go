@@ -0,0 +1,559 @@
+package r9e
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// walSnapshotFile is the name of the snapshot file inside the directory of a durable container.
+	walSnapshotFile = "snapshot"
+
+	// walLogFile is the name of the log file inside the directory of a durable container.
+	walLogFile = "log"
+
+	// walHeaderSize is the size of the header of each record:
+	// payload length uint32 | CRC-32C of the length uint32 | CRC-32C of the payload uint32.
+	walHeaderSize = 12
+
+	// maxWALRecordSize is the maximum size of a record payload, used to detect corrupted records.
+	maxWALRecordSize = 2*maxSnapshotFieldSize + 32
+)
+
+// operations stored in the log records.
+const (
+	walOpSet byte = iota + 1
+	walOpDelete
+	walOpClear
+	walOpExpire
+	walOpBatch
+)
+
+// ErrNotDurable is returned by Sync and Compact when the container was not opened using OpenMapKeyValue.
+var ErrNotDurable = errors.New("r9e: container has no write-ahead log")
+
+// FsyncPolicy defines when the write-ahead log is flushed to the disk.
+type FsyncPolicy int
+
+const (
+	// FsyncEverySecond flushes the log once per second. A crash loses at most the last second of writes.
+	// This is the default policy.
+	FsyncEverySecond FsyncPolicy = iota
+
+	// FsyncAlways flushes the log after every mutation. This is the safest and slowest policy.
+	FsyncAlways
+
+	// FsyncNever leaves the operating system to decide when the log is flushed.
+	FsyncNever
+)
+
+// WithFsyncPolicy sets when the write-ahead log of a container opened using OpenMapKeyValue
+// is flushed to the disk. The default value is FsyncEverySecond.
+func WithFsyncPolicy(policy FsyncPolicy) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.fsyncPolicy = policy
+	}
+}
+
+// WithLogCodec sets the codecs used to encode the keys and values in the write-ahead log and
+// the snapshot of a container opened using OpenMapKeyValue. The default is GobCodec.
+func WithLogCodec(keyCodec, valueCodec Codec) MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.logKeyCodec = keyCodec
+		kv.logValueCodec = valueCodec
+	}
+}
+
+// OpenMapKeyValue returns a durable MapKeyValue container stored in the directory dir.
+// The directory holds a snapshot and a write-ahead log where every Set, Delete, GetAnDelete,
+// Clear, expiration and eviction is appended. When the container is opened the snapshot is
+// loaded and the log is replayed, a truncated or corrupted final record is discarded. A corrupted
+// record followed by other records returns an error, leaving the log untouched.
+// Use Compact to fold the log into a fresh snapshot, and Close to flush and close the log.
+func OpenMapKeyValue[K comparable, T any](dir string, options ...MapKeyValueOptions) (*MapKeyValue[K, T], error) {
+	kvo := newMapKeyValueOptions(options...)
+
+	var snapshotOptions []SnapshotOptions
+	if kvo.logKeyCodec != nil {
+		snapshotOptions = append(snapshotOptions, WithKeyCodec(kvo.logKeyCodec))
+	}
+	if kvo.logValueCodec != nil {
+		snapshotOptions = append(snapshotOptions, WithValueCodec(kvo.logValueCodec))
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	kv := NewMapKeyValue[K, T](options...)
+
+	err := LoadSnapshotFile(filepath.Join(dir, walSnapshotFile), kv, snapshotOptions...)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, walLogFile), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &wal{
+		dir:     dir,
+		file:    f,
+		fsync:   kvo.fsyncPolicy,
+		options: snapshotOptions,
+		codecs:  newSnapshotOptions(snapshotOptions...),
+	}
+
+	if err := kv.replay(w); err != nil {
+		// stops the janitor started by the expiring keys of the snapshot or the log
+		kv.Close()
+		f.Close()
+		return nil, err
+	}
+
+	// the statistics count the changes done after the container was opened
+	kv.stats = newStats(kvo.stats)
+
+	kv.wal = w
+	w.start()
+	return kv, nil
+}
+
+// Sync flushes the write-ahead log to the disk.
+// Returns the first error found writing the log, once the log fails no more mutations are logged.
+func (r *MapKeyValue[K, T]) Sync() error {
+	if r.wal == nil {
+		return ErrNotDurable
+	}
+	return r.wal.sync()
+}
+
+// Compact writes a snapshot of the container and starts a new empty write-ahead log.
+// The container is locked while the snapshot is written.
+func (r *MapKeyValue[K, T]) Compact() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.wal == nil {
+		return ErrNotDurable
+	}
+
+	entries := make([]snapshotEntry[K, T], 0, len(r.data))
+	for key, value := range r.data {
+		if r.expired(key) {
+			continue
+		}
+		entries = append(entries, snapshotEntry[K, T]{key: key, value: value, expireAt: r.expires[key]})
+	}
+
+	return r.wal.compact(func(w io.Writer) error {
+		return writeSnapshot(w, entries, r.wal.options...)
+	})
+}
+
+// replay applies the records of the log to the container and truncates the log after the
+// last valid record. Only the final record can be truncated or corrupted, by a write interrupted
+// by a crash, a corrupted record followed by other records returns an error and the log is not modified.
+func (r *MapKeyValue[K, T]) replay(w *wal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the evictions are in the log, so the replay must not evict anything else
+	maxEntries, maxCost := r.maxEntries, r.maxCost
+	r.maxEntries, r.maxCost = math.MaxInt, math.MaxInt
+	defer func() {
+		r.maxEntries, r.maxCost = maxEntries, maxCost
+	}()
+
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	cr := &countingReader{r: bufio.NewReader(w.file)}
+	var offset int64
+	for {
+		payload, err := readWALRecord(cr, info.Size()-offset)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// truncated final record
+			break
+		}
+		if errors.Is(err, ErrSnapshotInvalid) || errors.Is(err, ErrSnapshotChecksum) {
+			if cr.n < info.Size() {
+				return fmt.Errorf("r9e: corrupted log record at offset %d: %w", offset, err)
+			}
+			// truncated or corrupted final record
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := r.apply(payload, w.codecs); err != nil {
+			return fmt.Errorf("r9e: replaying log at offset %d: %w", offset, err)
+		}
+		offset = cr.n
+	}
+
+	if err := w.file.Truncate(offset); err != nil {
+		return err
+	}
+	_, err = w.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader, counting the bytes read.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// apply applies a record of the log to the container.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) apply(payload []byte, so snapshotOptions) error {
+	if len(payload) == 0 {
+		return fmt.Errorf("%w: empty log record", ErrSnapshotInvalid)
+	}
+
+	br := &snapshotReader{r: bytes.NewReader(payload[1:]), crc: crc32.New(crcTable)}
+	switch payload[0] {
+	case walOpSet:
+		e, err := readSnapshotEntry[K, T](br, so)
+		if err != nil {
+			return err
+		}
+		if !e.expireAt.IsZero() && !e.expireAt.After(r.now()) {
+			r.delete(e.key, OpExpire)
+			return nil
+		}
+		r.set(e.key, e.value, e.expireAt)
+
+	case walOpDelete:
+		key, err := readSnapshotKey[K](br, so)
+		if err != nil {
+			return err
+		}
+		r.delete(key, OpDelete)
+
+	case walOpClear:
+		r.reset()
+
+	case walOpExpire:
+		expireAt, err := br.readTime()
+		if err != nil {
+			return err
+		}
+		key, err := readSnapshotKey[K](br, so)
+		if err != nil {
+			return err
+		}
+		if _, ok := r.data[key]; !ok {
+			return nil
+		}
+
+		switch {
+		case expireAt.IsZero():
+			delete(r.expires, key)
+			r.track(key)
+		case !expireAt.After(r.now()):
+			r.delete(key, OpExpire)
+		default:
+			r.expires[key] = expireAt
+			r.startJanitor()
+			r.track(key)
+		}
+
+	case walOpBatch:
+		records := bytes.NewReader(payload[1:])
+		for records.Len() > 0 {
+			record, err := readWALRecord(records, int64(records.Len()))
+			if err != nil {
+				return err
+			}
+			if err := r.apply(record, so); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown log operation %d", ErrSnapshotInvalid, payload[0])
+	}
+	return nil
+}
+
+// logSet appends a set record to the log.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) logSet(key K, value T, expireAt time.Time) {
+	if r.wal == nil {
+		return
+	}
+
+	payload, err := appendSnapshotEntry([]byte{walOpSet}, r.wal.codecs, snapshotEntry[K, T]{key: key, value: value, expireAt: expireAt})
+	r.wal.append(payload, err)
+}
+
+// logDelete appends a delete record to the log.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) logDelete(key K) {
+	if r.wal == nil {
+		return
+	}
+
+	payload, err := appendSnapshotKey([]byte{walOpDelete}, r.wal.codecs, key)
+	r.wal.append(payload, err)
+}
+
+// logClear appends a clear record to the log.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) logClear() {
+	if r.wal == nil {
+		return
+	}
+
+	r.wal.append([]byte{walOpClear}, nil)
+}
+
+// logExpire appends a record changing the expiration of the key to the log.
+// A zero expireAt means the key never expires.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) logExpire(key K, expireAt time.Time) {
+	if r.wal == nil {
+		return
+	}
+
+	payload, err := appendSnapshotKey(appendSnapshotTime([]byte{walOpExpire}, expireAt), r.wal.codecs, key)
+	r.wal.append(payload, err)
+}
+
+// readWALRecord reads a record of the log, starting remaining bytes before its end, and returns its payload.
+// Returns io.EOF at the end of the log, ErrSnapshotInvalid wrapping io.ErrUnexpectedEOF if the record is
+// truncated, ErrSnapshotChecksum if it is corrupted, and ErrSnapshotInvalid if its length is invalid.
+// The length is checked by its own checksum before the payload is read, so a corrupted length is never
+// taken for a truncated record.
+func readWALRecord(r io.Reader, remaining int64) ([]byte, error) {
+	var header [walHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+	}
+
+	if crc32.Checksum(header[:4], crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, fmt.Errorf("%w: log record length", ErrSnapshotChecksum)
+	}
+
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > maxWALRecordSize {
+		return nil, fmt.Errorf("%w: log record of %d bytes", ErrSnapshotInvalid, size)
+	}
+	if int64(size) > remaining-walHeaderSize {
+		return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, io.ErrUnexpectedEOF)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, io.ErrUnexpectedEOF)
+	}
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[8:]) {
+		return nil, ErrSnapshotChecksum
+	}
+	return payload, nil
+}
+
+// wal is the append-only write-ahead log of a durable container.
+// Each record is: payload length uint32 | CRC-32C of the length uint32 | CRC-32C of the payload uint32 | payload,
+// where the payload is the operation followed by its fields encoded as in the snapshots.
+// The records of a transaction are written as the payload of a single batch record,
+// so they are replayed all or none.
+type wal struct {
+	mu      sync.Mutex
+	dir     string
+	file    *os.File
+	fsync   FsyncPolicy
+	dirty   bool
+	err     error
+	batch   []byte
+	done    chan struct{}
+	stopped chan struct{}
+
+	options []SnapshotOptions
+	codecs  snapshotOptions
+}
+
+// start starts the goroutine flushing the log when the policy is FsyncEverySecond.
+func (w *wal) start() {
+	if w.fsync != FsyncEverySecond {
+		return
+	}
+
+	w.done = make(chan struct{})
+	w.stopped = make(chan struct{})
+	go func() {
+		defer close(w.stopped)
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_ = w.sync() // the error is kept and returned by Sync and Close
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+// append writes a record with the given payload. The encoding error of the payload, if any,
+// is kept as the error of the log.
+func (w *wal) append(payload []byte, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return
+	}
+	if err != nil {
+		w.err = err
+		return
+	}
+
+	if w.batch != nil {
+		w.batch = appendWALRecord(w.batch, payload)
+		return
+	}
+
+	if _, err := w.file.Write(appendWALRecord(nil, payload)); err != nil {
+		w.err = err
+		return
+	}
+
+	if w.fsync == FsyncAlways {
+		w.err = w.file.Sync()
+		return
+	}
+	w.dirty = true
+}
+
+// begin starts a batch, the next records are written together by commit.
+func (w *wal) begin() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.batch = []byte{walOpBatch}
+}
+
+// commit writes the records of the batch as a single record.
+func (w *wal) commit() {
+	w.mu.Lock()
+	batch := w.batch
+	w.batch = nil
+	w.mu.Unlock()
+
+	if len(batch) > 1 {
+		w.append(batch, nil)
+	}
+}
+
+// appendWALRecord appends the record with the given payload to buf.
+func appendWALRecord(buf []byte, payload []byte) []byte {
+	size := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	buf = append(buf, size...)
+	buf = binary.BigEndian.AppendUint32(buf, crc32.Checksum(size, crcTable))
+	buf = binary.BigEndian.AppendUint32(buf, crc32.Checksum(payload, crcTable))
+	return append(buf, payload...)
+}
+
+// sync flushes the log to the disk if there are pending records.
+func (w *wal) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil || !w.dirty {
+		return w.err
+	}
+
+	w.dirty = false
+	w.err = w.file.Sync()
+	return w.err
+}
+
+// compact writes the snapshot using the given function and replaces the log with an empty one.
+// If there is a crash between both steps, the old log is replayed over the new snapshot, which
+// gives the same result because the records don't depend on the previous state.
+func (w *wal) compact(write func(w io.Writer) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if err := writeFileAtomic(filepath.Join(w.dir, walSnapshotFile), write); err != nil {
+		return err
+	}
+
+	path := filepath.Join(w.dir, walLogFile)
+	if err := writeFileAtomic(path, func(io.Writer) error { return nil }); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		w.err = err
+		return err
+	}
+
+	w.file.Close()
+	w.file = f
+	w.dirty = false
+	return nil
+}
+
+// close stops the flushing goroutine, flushes and closes the log.
+// Returns the first error found writing the log.
+func (w *wal) close() error {
+	if w.done != nil {
+		close(w.done)
+		<-w.stopped
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err == nil && w.dirty {
+		w.err = w.file.Sync()
+	}
+	if err := w.file.Close(); w.err == nil {
+		w.err = err
+	}
+
+	err := w.err
+	if w.err == nil {
+		w.err = os.ErrClosed
+	}
+	return err
+}
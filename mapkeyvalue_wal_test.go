@@ -0,0 +1,360 @@
+package r9e
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOpenMapKeyValue(t *testing.T) {
+	t.Run("test OpenMapKeyValue replays Set, Delete, GetAnDelete and Clear", func(t *testing.T) {
+		dir := t.TempDir()
+
+		kv, err := OpenMapKeyValue[string, int](dir, WithFsyncPolicy(FsyncAlways))
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		kv.Set("zero", 0)
+		kv.Clear()
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+		kv.Set("three", 3)
+		kv.Set("one", 11)
+		kv.Delete("two")
+		kv.GetAnDelete("three")
+		kv.Set("four", 4)
+
+		if err := kv.Close(); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		reopened, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		defer reopened.Close()
+
+		keys := reopened.Keys()
+		sort.Strings(keys)
+		if len(keys) != 2 || keys[0] != "four" || keys[1] != "one" {
+			t.Errorf("Expected keys to be %v, got %v", []string{"four", "one"}, keys)
+		}
+		if reopened.Get("one") != 11 {
+			t.Errorf("Expected value to be %v, got %v", 11, reopened.Get("one"))
+		}
+	})
+
+	t.Run("test OpenMapKeyValue replays the expiration of the keys", func(t *testing.T) {
+		dir := t.TempDir()
+
+		kv, err := OpenMapKeyValue[string, int](dir, WithFsyncPolicy(FsyncNever))
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		kv.SetWithTTL("expired", 1, time.Millisecond)
+		kv.SetWithTTL("ttl", 2, time.Hour)
+		kv.SetWithTTL("persisted", 3, time.Hour)
+		kv.Persist("persisted")
+		kv.Set("expire", 4)
+		kv.ExpireAt("expire", time.Now().Add(time.Hour))
+
+		if err := kv.Close(); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		reopened, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		defer reopened.Close()
+
+		if reopened.ContainsKey("expired") {
+			t.Errorf("Expected key %v to be expired", "expired")
+		}
+		if ttl, ok := reopened.TTL("ttl"); !ok || ttl <= 0 || ttl > time.Hour {
+			t.Errorf("Expected ttl to be less than %v, got %v", time.Hour, ttl)
+		}
+		if ttl, ok := reopened.TTL("persisted"); !ok || ttl != NoExpiration {
+			t.Errorf("Expected ttl to be %v, got %v", NoExpiration, ttl)
+		}
+		if ttl, ok := reopened.TTL("expire"); !ok || ttl <= 0 || ttl > time.Hour {
+			t.Errorf("Expected ttl to be less than %v, got %v", time.Hour, ttl)
+		}
+	})
+
+	t.Run("test OpenMapKeyValue replays the evictions of a bounded container", func(t *testing.T) {
+		dir := t.TempDir()
+
+		kv, err := OpenMapKeyValue[int, int](dir, WithMaxEntries(10))
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+			kv.Get(0)
+		}
+		want := kv.Keys()
+		sort.Ints(want)
+
+		if err := kv.Close(); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		reopened, err := OpenMapKeyValue[int, int](dir, WithMaxEntries(10))
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		defer reopened.Close()
+
+		got := reopened.Keys()
+		sort.Ints(got)
+		if len(got) != len(want) {
+			t.Fatalf("Expected keys to be %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Expected keys to be %v, got %v", want, got)
+			}
+		}
+	})
+
+	t.Run("test OpenMapKeyValue with JSON codec", func(t *testing.T) {
+		dir := t.TempDir()
+
+		kv, err := OpenMapKeyValue[string, snapshotTestStruct](dir, WithLogCodec(JSONCodec{}, JSONCodec{}))
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		kv.Set("pi", snapshotTestStruct{"Archimedes' constant", 3.141592})
+		kv.Close()
+
+		reopened, err := OpenMapKeyValue[string, snapshotTestStruct](dir, WithLogCodec(JSONCodec{}, JSONCodec{}))
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		defer reopened.Close()
+
+		if reopened.Get("pi").Value != 3.141592 {
+			t.Errorf("Expected value to be %v, got %v", 3.141592, reopened.Get("pi").Value)
+		}
+	})
+}
+
+func TestOpenMapKeyValue_Recovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+	}{
+		{"truncated", func(data []byte) []byte { return data[:len(data)-3] }},
+		{"truncated header", func(data []byte) []byte { return append(data, 0, 0, 1) }},
+		{"corrupted", func(data []byte) []byte {
+			data[len(data)-1] ^= 0xff
+			return data
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run("test OpenMapKeyValue discards the "+tc.name+" final record", func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, walLogFile)
+
+			kv, err := OpenMapKeyValue[string, int](dir)
+			if err != nil {
+				t.Fatalf("Expected error to be %v, got %v", nil, err)
+			}
+			kv.Set("one", 1)
+			kv.Set("two", 2)
+			kv.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tc.corrupt(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			reopened, err := OpenMapKeyValue[string, int](dir)
+			if err != nil {
+				t.Fatalf("Expected error to be %v, got %v", nil, err)
+			}
+
+			if !reopened.ContainsKey("one") {
+				t.Errorf("Expected key %v to be recovered", "one")
+			}
+
+			// the log is usable after the recovery
+			reopened.Set("three", 3)
+			reopened.Close()
+
+			reopened, err = OpenMapKeyValue[string, int](dir)
+			if err != nil {
+				t.Fatalf("Expected error to be %v, got %v", nil, err)
+			}
+			defer reopened.Close()
+
+			if !reopened.ContainsKey("one") || !reopened.ContainsKey("three") {
+				t.Errorf("Expected keys to be %v, got %v", []string{"one", "three"}, reopened.Keys())
+			}
+		})
+	}
+}
+
+func TestOpenMapKeyValue_Corruption(t *testing.T) {
+	t.Run("test OpenMapKeyValue fails on a corrupted record followed by other records", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, walLogFile)
+
+		kv, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+		kv.Set("three", 3)
+		kv.Close()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the last byte of the payload of the first record
+		size := binary.BigEndian.Uint32(data[:4])
+		data[walHeaderSize+size-1] ^= 0xff
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := OpenMapKeyValue[string, int](dir); !errors.Is(err, ErrSnapshotChecksum) {
+			t.Errorf("Expected error to be %v, got %v", ErrSnapshotChecksum, err)
+		}
+
+		after, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(after, data) {
+			t.Errorf("Expected the log to be untouched, got %v bytes instead of %v", len(after), len(data))
+		}
+	})
+
+	t.Run("test OpenMapKeyValue fails on a corrupted length followed by other records", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, walLogFile)
+
+		kv, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+		kv.Close()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a length of the first record reaching beyond the end of the log
+		data[0] ^= 0x7f
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := OpenMapKeyValue[string, int](dir); !errors.Is(err, ErrSnapshotChecksum) {
+			t.Errorf("Expected error to be %v, got %v", ErrSnapshotChecksum, err)
+		}
+
+		after, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(after, data) {
+			t.Errorf("Expected the log to be untouched, got %v bytes instead of %v", len(after), len(data))
+		}
+	})
+}
+
+func TestCompact_MapKeyValue(t *testing.T) {
+	t.Run("test Compact writes a snapshot and starts a new log", func(t *testing.T) {
+		dir := t.TempDir()
+
+		kv, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		for i := 0; i < 100; i++ {
+			kv.Set(strconv.Itoa(i), i)
+		}
+		for i := 0; i < 50; i++ {
+			kv.Delete(strconv.Itoa(i))
+		}
+
+		if err := kv.Compact(); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		info, err := os.Stat(filepath.Join(dir, walLogFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() != 0 {
+			t.Errorf("Expected log size to be %v, got %v", 0, info.Size())
+		}
+
+		kv.Set("100", 100)
+		if err := kv.Close(); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		reopened, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		defer reopened.Close()
+
+		if reopened.Size() != 51 {
+			t.Errorf("Expected size to be %v, got %v", 51, reopened.Size())
+		}
+		if reopened.Get("99") != 99 || reopened.Get("100") != 100 {
+			t.Errorf("Expected values to be %v and %v, got %v and %v", 99, 100, reopened.Get("99"), reopened.Get("100"))
+		}
+	})
+
+	t.Run("test Compact and Sync without write-ahead log", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+
+		if err := kv.Compact(); !errors.Is(err, ErrNotDurable) {
+			t.Errorf("Expected error to be %v, got %v", ErrNotDurable, err)
+		}
+		if err := kv.Sync(); !errors.Is(err, ErrNotDurable) {
+			t.Errorf("Expected error to be %v, got %v", ErrNotDurable, err)
+		}
+	})
+
+	t.Run("test Sync returns the encoding errors", func(t *testing.T) {
+		kv, err := OpenMapKeyValue[string, TestStruct](t.TempDir())
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		defer kv.Close()
+
+		// gob can't encode structs without exported fields
+		kv.Set("a", TestStruct{a: "a", b: 1})
+
+		if err := kv.Sync(); err == nil {
+			t.Errorf("Expected Sync to fail")
+		}
+	})
+}
@@ -0,0 +1,551 @@
+package r9e
+
+import (
+	"container/list"
+	"iter"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// OrderedMapKeyValue is a generic key-value store container that is thread-safe and keeps
+// the insertion order of the keys.
+// This use a golang native map data structure and a doubly linked list as underlying data
+// structures and a mutex to protect the data. All the operations are O(1).
+type OrderedMapKeyValue[K comparable, T any] struct {
+	mu    sync.RWMutex
+	data  map[K]*list.Element
+	order *list.List
+
+	// front is the position of the key moved to the front and back the position of the next key
+	// added or moved to the back, so the positions of the keys increase in the order.
+	front int64
+	back  int64
+}
+
+// orderedEntry is a key-value pair of OrderedMapKeyValue and its position, used by the iterators
+// to resume after the chunks.
+type orderedEntry[K comparable, T any] struct {
+	kv[K, T]
+	pos int64
+}
+
+// NewOrderedMapKeyValue returns a new OrderedMapKeyValue container.
+func NewOrderedMapKeyValue[K comparable, T any]() *OrderedMapKeyValue[K, T] {
+	return &OrderedMapKeyValue[K, T]{
+		data:  make(map[K]*list.Element),
+		order: list.New(),
+	}
+}
+
+// Set sets the value associated with the key.
+// A new key is added at the end of the container, an existing key keeps its position.
+func (r *OrderedMapKeyValue[K, T]) Set(key K, value T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.set(key, value)
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (r *OrderedMapKeyValue[K, T]) GetAndCheck(key K) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if e, ok := r.data[key]; ok {
+		return e.Value.(*orderedEntry[K, T]).value, true
+	}
+	var empty T
+	return empty, false
+}
+
+// Get returns the value associated with the key.
+// If the key does not exist, return zero value of the type.
+func (r *OrderedMapKeyValue[K, T]) Get(key K) T {
+	value, _ := r.GetAndCheck(key)
+	return value
+}
+
+// GetAnDelete returns the value associated with the key and delete it if the key exist
+// if the key doesn't exist return the given key value false
+func (r *OrderedMapKeyValue[K, T]) GetAnDelete(key K) (T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.data[key]
+	if !ok {
+		var empty T
+		return empty, false
+	}
+
+	return r.remove(e).value, true
+}
+
+// Delete deletes the value associated with the key.
+func (r *OrderedMapKeyValue[K, T]) Delete(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := r.data[key]; ok {
+		r.remove(e)
+	}
+}
+
+// Clear deletes all key-value pairs stored in the container.
+func (r *OrderedMapKeyValue[K, T]) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data = make(map[K]*list.Element)
+	r.order.Init()
+}
+
+// Size returns the number of key-value pairs stored in the container.
+func (r *OrderedMapKeyValue[K, T]) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.data)
+}
+
+// IsEmpty returns true if the container is empty.
+func (r *OrderedMapKeyValue[K, T]) IsEmpty() bool {
+	return r.Size() == 0
+}
+
+// IsFull returns true if the container has elements.
+func (r *OrderedMapKeyValue[K, T]) IsFull() bool {
+	return r.Size() != 0
+}
+
+// ContainsKey returns true if the key is in the container.
+func (r *OrderedMapKeyValue[K, T]) ContainsKey(key K) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.data[key]
+	return ok
+}
+
+// ContainsValue returns true if the value is in the container.
+func (r *OrderedMapKeyValue[K, T]) ContainsValue(value T) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		if reflect.DeepEqual(e.Value.(*orderedEntry[K, T]).value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the key value associated with the key.
+func (r *OrderedMapKeyValue[K, T]) Key(key K) K {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.data[key]; ok {
+		return key
+	}
+	var empty K
+	return empty
+}
+
+// Keys returns all keys stored in the container, in insertion order.
+func (r *OrderedMapKeyValue[K, T]) Keys() []K {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]K, 0, len(r.data))
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*orderedEntry[K, T]).key)
+	}
+	return keys
+}
+
+// Values returns all values stored in the container, in insertion order.
+func (r *OrderedMapKeyValue[K, T]) Values() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := make([]T, 0, len(r.data))
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		values = append(values, e.Value.(*orderedEntry[K, T]).value)
+	}
+	return values
+}
+
+// ForEach calls the given function for each key-value pair in the container, in insertion order.
+// The function is called on a snapshot of the container taken without holding the lock,
+// so it can modify the container, but the changes are not seen by the iteration.
+func (r *OrderedMapKeyValue[K, T]) ForEach(fn func(key K, value T)) {
+	for _, pair := range r.pairs() {
+		fn(pair.key, pair.value)
+	}
+}
+
+// ForEachKey calls the given function for each key in the container, in insertion order.
+func (r *OrderedMapKeyValue[K, T]) ForEachKey(fn func(key K)) {
+	r.ForEach(func(key K, value T) {
+		fn(key)
+	})
+}
+
+// ForEachValue calls the given function for each value in the container, in insertion order.
+func (r *OrderedMapKeyValue[K, T]) ForEachValue(fn func(value T)) {
+	r.ForEach(func(key K, value T) {
+		fn(value)
+	})
+}
+
+// All returns an iterator over the key-value pairs of the container, in insertion order.
+// The iteration copies the key-value pairs in chunks, holding the lock only while each one is
+// copied, so the loop can modify the container and stopping it early doesn't copy the rest.
+// Each key is visited at most once, but the changes done during the iteration may or may not be
+// seen. The keys added or moved to the back during the iteration are not visited.
+func (r *OrderedMapKeyValue[K, T]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		var c orderedCursor
+		for {
+			chunk := r.chunk(&c)
+			for _, pair := range chunk {
+				if !yield(pair.key, pair.value) {
+					return
+				}
+			}
+
+			if len(chunk) < iterChunkSize {
+				return
+			}
+		}
+	}
+}
+
+// orderedCursor is the position of an iteration of OrderedMapKeyValue between its chunks.
+type orderedCursor struct {
+	started bool
+
+	// last is the element of the last key copied and pos its position at the time.
+	last *list.Element
+	pos  int64
+
+	// end is the position of the next key added when the iteration started.
+	end int64
+}
+
+// chunk copies the next chunk of key-value pairs of the iteration at the cursor, and moves it.
+// The chunk starts after the last key copied if it is still in the container at the same position,
+// otherwise after the first key with a greater position.
+func (r *OrderedMapKeyValue[K, T]) chunk(c *orderedCursor) []kv[K, T] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e := r.order.Front()
+	switch last := c.last; {
+	case !c.started:
+		c.started, c.end = true, r.back
+	case r.data[last.Value.(*orderedEntry[K, T]).key] == last && last.Value.(*orderedEntry[K, T]).pos == c.pos:
+		e = last.Next()
+	default:
+		for e != nil && e.Value.(*orderedEntry[K, T]).pos <= c.pos {
+			e = e.Next()
+		}
+	}
+
+	pairs := make([]kv[K, T], 0, iterChunkSize)
+	for ; e != nil && len(pairs) < iterChunkSize; e = e.Next() {
+		entry := e.Value.(*orderedEntry[K, T])
+		if entry.pos >= c.end {
+			break
+		}
+
+		pairs = append(pairs, entry.kv)
+		c.last, c.pos = e, entry.pos
+	}
+	return pairs
+}
+
+// pairs returns a snapshot of the key-value pairs in insertion order.
+// The functions given to the iteration methods are called on it without holding the lock.
+func (r *OrderedMapKeyValue[K, T]) pairs() []kv[K, T] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pairs := make([]kv[K, T], 0, len(r.data))
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		pairs = append(pairs, e.Value.(*orderedEntry[K, T]).kv)
+	}
+	return pairs
+}
+
+// KeysSeq returns an iterator over the keys of the container, in insertion order.
+// It has the same guarantees as All.
+func (r *OrderedMapKeyValue[K, T]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range r.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the container, in insertion order.
+// It has the same guarantees as All.
+func (r *OrderedMapKeyValue[K, T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, value := range r.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Clone returns a new OrderedMapKeyValue with a copy of the underlying data, in the same order.
+func (r *OrderedMapKeyValue[K, T]) Clone() *OrderedMapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return true
+	})
+}
+
+// CloneAndClear returns a new OrderedMapKeyValue with a copy of the underlying data and clears the container.
+func (r *OrderedMapKeyValue[K, T]) CloneAndClear() *OrderedMapKeyValue[K, T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := &OrderedMapKeyValue[K, T]{
+		data:  r.data,
+		order: r.order,
+		front: r.front,
+		back:  r.back,
+	}
+	r.data = make(map[K]*list.Element)
+	r.order = list.New()
+	return clone
+}
+
+// DeepEqual returns true if the given kv is deep equal to the OrderedMapKeyValue container,
+// including the order of the keys.
+func (r *OrderedMapKeyValue[K, T]) DeepEqual(kv *OrderedMapKeyValue[K, T]) bool {
+	if r == kv {
+		return true
+	}
+
+	keys, values := r.Keys(), r.Values()
+	otherKeys, otherValues := kv.Keys(), kv.Values()
+
+	return reflect.DeepEqual(keys, otherKeys) && reflect.DeepEqual(values, otherValues)
+}
+
+// Map returns a new OrderedMapKeyValue after applying the given function fn to each key-value pair.
+// If several keys are mapped to the same key, the position of the first one is kept with the last value.
+func (r *OrderedMapKeyValue[K, T]) Map(fn func(key K, value T) (newKey K, newValue T)) *OrderedMapKeyValue[K, T] {
+	m := NewOrderedMapKeyValue[K, T]()
+	r.ForEach(func(key K, value T) {
+		m.set(fn(key, value))
+	})
+	return m
+}
+
+// MapKey returns a new OrderedMapKeyValue after applying the given function fn to each key.
+func (r *OrderedMapKeyValue[K, T]) MapKey(fn func(key K) K) *OrderedMapKeyValue[K, T] {
+	return r.Map(func(key K, value T) (K, T) {
+		return fn(key), value
+	})
+}
+
+// MapValue returns a new OrderedMapKeyValue after applying the given function fn to each value.
+func (r *OrderedMapKeyValue[K, T]) MapValue(fn func(value T) T) *OrderedMapKeyValue[K, T] {
+	return r.Map(func(key K, value T) (K, T) {
+		return key, fn(value)
+	})
+}
+
+// Filter returns a new OrderedMapKeyValue after applying the given function fn to each key-value pair.
+func (r *OrderedMapKeyValue[K, T]) Filter(fn func(key K, value T) bool) *OrderedMapKeyValue[K, T] {
+	m, _ := r.Partition(fn)
+	return m
+}
+
+// FilterKey returns a new OrderedMapKeyValue after applying the given function fn to each key.
+func (r *OrderedMapKeyValue[K, T]) FilterKey(fn func(key K) bool) *OrderedMapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// FilterValue returns a new OrderedMapKeyValue after applying the given function fn to each value.
+func (r *OrderedMapKeyValue[K, T]) FilterValue(fn func(value T) bool) *OrderedMapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// Partition returns two new OrderedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each element. Both keep the order of the keys.
+func (r *OrderedMapKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, others *OrderedMapKeyValue[K, T]) {
+	match = NewOrderedMapKeyValue[K, T]()
+	others = NewOrderedMapKeyValue[K, T]()
+
+	r.ForEach(func(key K, value T) {
+		if fn(key, value) {
+			match.set(key, value)
+		} else {
+			others.set(key, value)
+		}
+	})
+	return match, others
+}
+
+// PartitionKey returns two new OrderedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each key. Both keep the order of the keys.
+func (r *OrderedMapKeyValue[K, T]) PartitionKey(fn func(key K) bool) (match, others *OrderedMapKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// PartitionValue returns two new OrderedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each value. Both keep the order of the keys.
+func (r *OrderedMapKeyValue[K, T]) PartitionValue(fn func(value T) bool) (match, others *OrderedMapKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// SortKeys returns a []*K (keys) after sorting the keys using the given sortFn function.
+func (r *OrderedMapKeyValue[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
+	keys := r.Keys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return sortFn(keys[i], keys[j])
+	})
+
+	m := make([]*K, len(keys))
+	for i := range keys {
+		m[i] = &keys[i]
+	}
+	return m
+}
+
+// SortValues returns a []*T (values) after sorting the values using given function sortFn.
+func (r *OrderedMapKeyValue[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
+	values := r.Values()
+	sort.SliceStable(values, func(i, j int) bool {
+		return sortFn(values[i], values[j])
+	})
+
+	m := make([]*T, len(values))
+	for i := range values {
+		m[i] = &values[i]
+	}
+	return m
+}
+
+// MoveToFront moves the key to the front of the container.
+// Returns false if the key doesn't exist.
+func (r *OrderedMapKeyValue[K, T]) MoveToFront(key K) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.data[key]
+	if ok {
+		r.front--
+		e.Value.(*orderedEntry[K, T]).pos = r.front
+		r.order.MoveToFront(e)
+	}
+	return ok
+}
+
+// MoveToBack moves the key to the back of the container.
+// Returns false if the key doesn't exist.
+func (r *OrderedMapKeyValue[K, T]) MoveToBack(key K) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.data[key]
+	if ok {
+		e.Value.(*orderedEntry[K, T]).pos = r.back
+		r.back++
+		r.order.MoveToBack(e)
+	}
+	return ok
+}
+
+// First returns the first key-value pair of the container and true,
+// or false if the container is empty.
+func (r *OrderedMapKeyValue[K, T]) First() (K, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return pairOf[K, T](r.order.Front())
+}
+
+// Last returns the last key-value pair of the container and true,
+// or false if the container is empty.
+func (r *OrderedMapKeyValue[K, T]) Last() (K, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return pairOf[K, T](r.order.Back())
+}
+
+// PopFirst removes and returns the first key-value pair of the container and true,
+// or false if the container is empty.
+func (r *OrderedMapKeyValue[K, T]) PopFirst() (K, T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, value, ok := pairOf[K, T](r.order.Front())
+	if ok {
+		r.remove(r.order.Front())
+	}
+	return key, value, ok
+}
+
+// PopLast removes and returns the last key-value pair of the container and true,
+// or false if the container is empty.
+func (r *OrderedMapKeyValue[K, T]) PopLast() (K, T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, value, ok := pairOf[K, T](r.order.Back())
+	if ok {
+		r.remove(r.order.Back())
+	}
+	return key, value, ok
+}
+
+// set stores the key-value pair, adding the key at the end if it doesn't exist.
+// The caller must hold the write lock, or own the container.
+func (r *OrderedMapKeyValue[K, T]) set(key K, value T) {
+	if e, ok := r.data[key]; ok {
+		e.Value.(*orderedEntry[K, T]).value = value
+		return
+	}
+
+	r.data[key] = r.order.PushBack(&orderedEntry[K, T]{kv: kv[K, T]{key: key, value: value}, pos: r.back})
+	r.back++
+}
+
+// remove removes the element of a key-value pair and returns the pair.
+// The caller must hold the write lock.
+func (r *OrderedMapKeyValue[K, T]) remove(e *list.Element) *orderedEntry[K, T] {
+	pair := r.order.Remove(e).(*orderedEntry[K, T])
+	delete(r.data, pair.key)
+	return pair
+}
+
+// pairOf returns the key-value pair of the element and true, or false if the element is nil.
+func pairOf[K comparable, T any](e *list.Element) (K, T, bool) {
+	if e == nil {
+		var key K
+		var value T
+		return key, value, false
+	}
+
+	pair := e.Value.(*orderedEntry[K, T])
+	return pair.key, pair.value, true
+}
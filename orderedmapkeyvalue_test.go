@@ -0,0 +1,220 @@
+package r9e
+
+import (
+	"slices"
+	"testing"
+)
+
+func newOrderedTestKeyValue(keys ...string) *OrderedMapKeyValue[string, int] {
+	kv := NewOrderedMapKeyValue[string, int]()
+	for i, key := range keys {
+		kv.Set(key, i+1)
+	}
+	return kv
+}
+
+func TestSet_OrderedMapKeyValue(t *testing.T) {
+	t.Run("test Set keeps the insertion order", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("c", "a", "d", "b")
+
+		if keys := kv.Keys(); !slices.Equal(keys, []string{"c", "a", "d", "b"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"c", "a", "d", "b"}, keys)
+		}
+		if values := kv.Values(); !slices.Equal(values, []int{1, 2, 3, 4}) {
+			t.Errorf("Expected values to be %v, got %v", []int{1, 2, 3, 4}, values)
+		}
+	})
+
+	t.Run("test Set of an existing key keeps its position", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("a", "b", "c")
+		kv.Set("a", 10)
+
+		if keys := kv.Keys(); !slices.Equal(keys, []string{"a", "b", "c"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"a", "b", "c"}, keys)
+		}
+		if kv.Get("a") != 10 {
+			t.Errorf("Expected value to be %v, got %v", 10, kv.Get("a"))
+		}
+	})
+
+	t.Run("test Delete and Set again adds the key at the end", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("a", "b", "c")
+		kv.Delete("a")
+		kv.Set("a", 1)
+
+		if keys := kv.Keys(); !slices.Equal(keys, []string{"b", "c", "a"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"b", "c", "a"}, keys)
+		}
+	})
+
+	t.Run("test All, KeysSeq and ValuesSeq iterate in insertion order", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("c", "a", "b")
+
+		var keys []string
+		var values []int
+		for key, value := range kv.All() {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+
+		if !slices.Equal(keys, []string{"c", "a", "b"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"c", "a", "b"}, keys)
+		}
+		if !slices.Equal(values, []int{1, 2, 3}) {
+			t.Errorf("Expected values to be %v, got %v", []int{1, 2, 3}, values)
+		}
+		if got := slices.Collect(kv.KeysSeq()); !slices.Equal(got, keys) {
+			t.Errorf("Expected KeysSeq to be %v, got %v", keys, got)
+		}
+		if got := slices.Collect(kv.ValuesSeq()); !slices.Equal(got, values) {
+			t.Errorf("Expected ValuesSeq to be %v, got %v", values, got)
+		}
+	})
+}
+
+func TestMove_OrderedMapKeyValue(t *testing.T) {
+	t.Run("test MoveToFront and MoveToBack", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("a", "b", "c", "d")
+
+		if !kv.MoveToFront("c") {
+			t.Errorf("Expected MoveToFront to be %v, got %v", true, false)
+		}
+		if !kv.MoveToBack("a") {
+			t.Errorf("Expected MoveToBack to be %v, got %v", true, false)
+		}
+
+		if keys := kv.Keys(); !slices.Equal(keys, []string{"c", "b", "d", "a"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"c", "b", "d", "a"}, keys)
+		}
+	})
+
+	t.Run("test MoveToFront and MoveToBack with a missing key", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("a", "b")
+
+		if kv.MoveToFront("z") {
+			t.Errorf("Expected MoveToFront to be %v, got %v", false, true)
+		}
+		if kv.MoveToBack("z") {
+			t.Errorf("Expected MoveToBack to be %v, got %v", false, true)
+		}
+		if kv.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+	})
+}
+
+func TestFirstAndLast_OrderedMapKeyValue(t *testing.T) {
+	t.Run("test First and Last", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("a", "b", "c")
+
+		if key, value, ok := kv.First(); !ok || key != "a" || value != 1 {
+			t.Errorf("Expected First to be %v %v %v, got %v %v %v", "a", 1, true, key, value, ok)
+		}
+		if key, value, ok := kv.Last(); !ok || key != "c" || value != 3 {
+			t.Errorf("Expected Last to be %v %v %v, got %v %v %v", "c", 3, true, key, value, ok)
+		}
+		if kv.Size() != 3 {
+			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		}
+	})
+
+	t.Run("test PopFirst and PopLast", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("a", "b", "c")
+
+		if key, value, ok := kv.PopFirst(); !ok || key != "a" || value != 1 {
+			t.Errorf("Expected PopFirst to be %v %v %v, got %v %v %v", "a", 1, true, key, value, ok)
+		}
+		if key, value, ok := kv.PopLast(); !ok || key != "c" || value != 3 {
+			t.Errorf("Expected PopLast to be %v %v %v, got %v %v %v", "c", 3, true, key, value, ok)
+		}
+		if keys := kv.Keys(); !slices.Equal(keys, []string{"b"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"b"}, keys)
+		}
+		if kv.ContainsKey("a") || kv.ContainsKey("c") {
+			t.Errorf("Expected keys %v and %v to be deleted", "a", "c")
+		}
+	})
+
+	t.Run("test First, Last, PopFirst and PopLast with an empty container", func(t *testing.T) {
+		kv := NewOrderedMapKeyValue[string, int]()
+
+		if _, _, ok := kv.First(); ok {
+			t.Errorf("Expected First to be %v, got %v", false, ok)
+		}
+		if _, _, ok := kv.Last(); ok {
+			t.Errorf("Expected Last to be %v, got %v", false, ok)
+		}
+		if _, _, ok := kv.PopFirst(); ok {
+			t.Errorf("Expected PopFirst to be %v, got %v", false, ok)
+		}
+		if _, _, ok := kv.PopLast(); ok {
+			t.Errorf("Expected PopLast to be %v, got %v", false, ok)
+		}
+	})
+}
+
+func TestFilterMapPartition_OrderedMapKeyValue(t *testing.T) {
+	t.Run("test Filter keeps the order", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("e", "d", "c", "b", "a")
+
+		got := kv.FilterValue(func(value int) bool {
+			return value%2 == 1
+		})
+
+		if keys := got.Keys(); !slices.Equal(keys, []string{"e", "c", "a"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"e", "c", "a"}, keys)
+		}
+	})
+
+	t.Run("test Map keeps the order", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("c", "b", "a")
+
+		got := kv.Map(func(key string, value int) (string, int) {
+			return key + key, value * 10
+		})
+
+		if keys := got.Keys(); !slices.Equal(keys, []string{"cc", "bb", "aa"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"cc", "bb", "aa"}, keys)
+		}
+		if values := got.Values(); !slices.Equal(values, []int{10, 20, 30}) {
+			t.Errorf("Expected values to be %v, got %v", []int{10, 20, 30}, values)
+		}
+	})
+
+	t.Run("test Partition keeps the order", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("f", "e", "d", "c", "b", "a")
+
+		match, others := kv.PartitionKey(func(key string) bool {
+			return key > "c"
+		})
+
+		if keys := match.Keys(); !slices.Equal(keys, []string{"f", "e", "d"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"f", "e", "d"}, keys)
+		}
+		if keys := others.Keys(); !slices.Equal(keys, []string{"c", "b", "a"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"c", "b", "a"}, keys)
+		}
+	})
+
+	t.Run("test Clone, CloneAndClear and DeepEqual", func(t *testing.T) {
+		kv := newOrderedTestKeyValue("b", "a")
+
+		clone := kv.Clone()
+		if !clone.DeepEqual(kv) {
+			t.Errorf("Expected clone %v to be equal to %v", clone.Keys(), kv.Keys())
+		}
+
+		clone.MoveToBack("b")
+		if clone.DeepEqual(kv) {
+			t.Errorf("Expected containers with a different order to be different")
+		}
+
+		cleared := kv.CloneAndClear()
+		if !kv.IsEmpty() {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+		if keys := cleared.Keys(); !slices.Equal(keys, []string{"b", "a"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"b", "a"}, keys)
+		}
+	})
+}
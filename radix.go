@@ -0,0 +1,253 @@
+package r9e
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// radixNode is a node of a radix tree, a trie where the nodes with only one child are merged
+// with it, so the edges are labeled with strings instead of bytes.
+// Each node counts the keys stored in its subtree, so the prefix counts are O(prefix length).
+// It is not thread-safe.
+type radixNode[T any] struct {
+	// prefix is the label of the edge from the parent.
+	prefix string
+
+	// children are sorted by the first byte of their prefix, which is unique.
+	children []*radixNode[T]
+
+	value T
+	leaf  bool
+	count int
+}
+
+// child returns the position of the child starting with the byte c and true if it exist,
+// or the position to insert it and false.
+func (n *radixNode[T]) child(c byte) (int, bool) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= c
+	})
+	return i, i < len(n.children) && n.children[i].prefix[0] == c
+}
+
+// get returns the node of the key, or nil if it doesn't exist.
+func (n *radixNode[T]) get(key string) *radixNode[T] {
+	for key != "" {
+		i, ok := n.child(key[0])
+		if !ok || !strings.HasPrefix(key, n.children[i].prefix) {
+			return nil
+		}
+		key = key[len(n.children[i].prefix):]
+		n = n.children[i]
+	}
+
+	if !n.leaf {
+		return nil
+	}
+	return n
+}
+
+// set stores the value of the key and returns true if the key was added.
+func (n *radixNode[T]) set(key string, value T) bool {
+	if key == "" {
+		added := !n.leaf
+		n.value, n.leaf = value, true
+		if added {
+			n.count++
+		}
+		return added
+	}
+
+	i, ok := n.child(key[0])
+	if !ok {
+		n.children = slices.Insert(n.children, i, &radixNode[T]{prefix: key, value: value, leaf: true, count: 1})
+		n.count++
+		return true
+	}
+
+	child := n.children[i]
+	common := commonPrefixLen(key, child.prefix)
+	if common < len(child.prefix) {
+		// the key diverges inside the edge, so it is split
+		split := &radixNode[T]{prefix: child.prefix[:common], children: []*radixNode[T]{child}, count: child.count}
+		child.prefix = child.prefix[common:]
+		n.children[i] = split
+		child = split
+	}
+
+	added := child.set(key[common:], value)
+	if added {
+		n.count++
+	}
+	return added
+}
+
+// delete removes the key and returns its value and true, or false if it doesn't exist.
+func (n *radixNode[T]) delete(key string) (T, bool) {
+	var empty T
+
+	if key == "" {
+		if !n.leaf {
+			return empty, false
+		}
+
+		value := n.value
+		n.value, n.leaf = empty, false
+		n.count--
+		return value, true
+	}
+
+	i, ok := n.child(key[0])
+	if !ok || !strings.HasPrefix(key, n.children[i].prefix) {
+		return empty, false
+	}
+
+	value, ok := n.children[i].delete(key[len(n.children[i].prefix):])
+	if ok {
+		n.count--
+		n.compact(i)
+	}
+	return value, ok
+}
+
+// deletePrefix removes the keys starting with prefix and returns how many were removed.
+func (n *radixNode[T]) deletePrefix(prefix string) int {
+	if prefix == "" {
+		removed := n.count
+		*n = radixNode[T]{prefix: n.prefix}
+		return removed
+	}
+
+	i, ok := n.child(prefix[0])
+	if !ok {
+		return 0
+	}
+
+	child := n.children[i]
+	removed := 0
+	switch {
+	case strings.HasPrefix(child.prefix, prefix):
+		// every key of the child starts with the prefix
+		removed = child.count
+		child.count = 0
+	case strings.HasPrefix(prefix, child.prefix):
+		removed = child.deletePrefix(prefix[len(child.prefix):])
+	}
+
+	if removed > 0 {
+		n.count -= removed
+		n.compact(i)
+	}
+	return removed
+}
+
+// compact removes the child at position i if it is empty, or merges it with its only child
+// if it doesn't have a value.
+func (n *radixNode[T]) compact(i int) {
+	child := n.children[i]
+
+	switch {
+	case child.count == 0:
+		n.children = slices.Delete(n.children, i, i+1)
+	case !child.leaf && len(child.children) == 1:
+		grandchild := child.children[0]
+		grandchild.prefix = child.prefix + grandchild.prefix
+		n.children[i] = grandchild
+	}
+}
+
+// seek returns the node whose subtree holds the keys starting with prefix and the key of that
+// node, or nil if there are no such keys.
+func (n *radixNode[T]) seek(prefix string) (*radixNode[T], string) {
+	key := ""
+	for prefix != "" {
+		i, ok := n.child(prefix[0])
+		if !ok {
+			return nil, ""
+		}
+
+		child := n.children[i]
+		switch {
+		case strings.HasPrefix(prefix, child.prefix):
+			prefix = prefix[len(child.prefix):]
+		case strings.HasPrefix(child.prefix, prefix):
+			prefix = ""
+		default:
+			return nil, ""
+		}
+
+		key += child.prefix
+		n = child
+	}
+	return n, key
+}
+
+// longestPrefix returns the node of the longest key that is a prefix of the given key and
+// the length of that key, or nil if there is none.
+func (n *radixNode[T]) longestPrefix(key string) (*radixNode[T], int) {
+	var match *radixNode[T]
+	length, matchLength := 0, 0
+
+	for {
+		if n.leaf {
+			match, matchLength = n, length
+		}
+		if length == len(key) {
+			break
+		}
+
+		i, ok := n.child(key[length])
+		if !ok || !strings.HasPrefix(key[length:], n.children[i].prefix) {
+			break
+		}
+		length += len(n.children[i].prefix)
+		n = n.children[i]
+	}
+	return match, matchLength
+}
+
+// walk calls yield for each key-value pair of the subtree in lexicographic order, key being the
+// key of the node. Returns false if yield stopped the walk.
+func (n *radixNode[T]) walk(key string, yield func(key string, value T) bool) bool {
+	if n.leaf && !yield(key, n.value) {
+		return false
+	}
+
+	for _, child := range n.children {
+		if !child.walk(key+child.prefix, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// walkAfter calls yield for each key-value pair of the subtree whose key is greater than after,
+// in lexicographic order, key being the key of the node. The subtrees whose keys are all less
+// than after are skipped. Returns false if yield stopped the walk.
+func (n *radixNode[T]) walkAfter(key, after string, yield func(key string, value T) bool) bool {
+	if !strings.HasPrefix(after, key) {
+		if key < after {
+			// the keys of the subtree diverge from after with a smaller byte
+			return true
+		}
+		return n.walk(key, yield)
+	}
+
+	// key is a prefix of after, so only the keys of the children can be greater than it
+	for _, child := range n.children {
+		if !child.walkAfter(key+child.prefix, after, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// commonPrefixLen returns the length of the common prefix of a and b.
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
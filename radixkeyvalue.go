@@ -0,0 +1,399 @@
+package r9e
+
+import (
+	"iter"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// RadixKeyValue is a generic key-value store container with string keys that is thread-safe
+// and supports prefix queries.
+// This use a radix tree as underlying data structure and a mutex to protect the data.
+// The prefix operations are O(prefix length + matches), and the iterations are in
+// lexicographic order of the keys.
+type RadixKeyValue[T any] struct {
+	mu   sync.RWMutex
+	root *radixNode[T]
+}
+
+// NewRadixKeyValue returns a new RadixKeyValue container.
+func NewRadixKeyValue[T any]() *RadixKeyValue[T] {
+	return &RadixKeyValue[T]{
+		root: &radixNode[T]{},
+	}
+}
+
+// Set sets the value associated with the key.
+func (r *RadixKeyValue[T]) Set(key string, value T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.root.set(key, value)
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (r *RadixKeyValue[T]) GetAndCheck(key string) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n := r.root.get(key); n != nil {
+		return n.value, true
+	}
+	var empty T
+	return empty, false
+}
+
+// Get returns the value associated with the key.
+// If the key does not exist, return zero value of the type.
+func (r *RadixKeyValue[T]) Get(key string) T {
+	value, _ := r.GetAndCheck(key)
+	return value
+}
+
+// GetAnDelete returns the value associated with the key and delete it if the key exist
+// if the key doesn't exist return the given key value false
+func (r *RadixKeyValue[T]) GetAnDelete(key string) (T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.root.delete(key)
+}
+
+// Delete deletes the value associated with the key.
+func (r *RadixKeyValue[T]) Delete(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.root.delete(key)
+}
+
+// Clear deletes all key-value pairs stored in the container.
+func (r *RadixKeyValue[T]) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.root = &radixNode[T]{}
+}
+
+// Size returns the number of key-value pairs stored in the container.
+func (r *RadixKeyValue[T]) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.root.count
+}
+
+// IsEmpty returns true if the container is empty.
+func (r *RadixKeyValue[T]) IsEmpty() bool {
+	return r.Size() == 0
+}
+
+// IsFull returns true if the container has elements.
+func (r *RadixKeyValue[T]) IsFull() bool {
+	return r.Size() != 0
+}
+
+// ContainsKey returns true if the key is in the container.
+func (r *RadixKeyValue[T]) ContainsKey(key string) bool {
+	_, ok := r.GetAndCheck(key)
+	return ok
+}
+
+// ContainsValue returns true if the value is in the container.
+func (r *RadixKeyValue[T]) ContainsValue(value T) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// the walk is stopped by the first match
+	return !r.root.walk("", func(key string, v T) bool {
+		return !reflect.DeepEqual(v, value)
+	})
+}
+
+// Key returns the key value associated with the key.
+func (r *RadixKeyValue[T]) Key(key string) string {
+	if r.ContainsKey(key) {
+		return key
+	}
+	return ""
+}
+
+// Keys returns all keys stored in the container, in lexicographic order.
+func (r *RadixKeyValue[T]) Keys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]string, 0, r.root.count)
+	r.root.walk("", func(key string, value T) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns all values stored in the container, in lexicographic order of the keys.
+func (r *RadixKeyValue[T]) Values() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := make([]T, 0, r.root.count)
+	r.root.walk("", func(key string, value T) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// ForEach calls the given function for each key-value pair in the container, in lexicographic order.
+// The function is called on a snapshot of the container taken without holding the lock,
+// so it can modify the container, but the changes are not seen by the iteration.
+func (r *RadixKeyValue[T]) ForEach(fn func(key string, value T)) {
+	for _, pair := range r.pairs("", nil, 0) {
+		fn(pair.key, pair.value)
+	}
+}
+
+// ForEachKey calls the given function for each key in the container, in lexicographic order.
+func (r *RadixKeyValue[T]) ForEachKey(fn func(key string)) {
+	r.ForEach(func(key string, value T) {
+		fn(key)
+	})
+}
+
+// ForEachValue calls the given function for each value in the container, in lexicographic order
+// of the keys.
+func (r *RadixKeyValue[T]) ForEachValue(fn func(value T)) {
+	r.ForEach(func(key string, value T) {
+		fn(value)
+	})
+}
+
+// All returns an iterator over the key-value pairs of the container, in lexicographic order.
+// The iteration copies the key-value pairs in chunks, holding the lock only while each one is
+// copied, so the loop can modify the container and stopping it early doesn't copy the rest.
+// Each key is visited at most once, but the changes done during the iteration may or may not be seen.
+func (r *RadixKeyValue[T]) All() iter.Seq2[string, T] {
+	return r.PrefixScan("")
+}
+
+// KeysSeq returns an iterator over the keys of the container, in lexicographic order.
+// It has the same guarantees as All.
+func (r *RadixKeyValue[T]) KeysSeq() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for key := range r.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the container, in lexicographic order of the keys.
+// It has the same guarantees as All.
+func (r *RadixKeyValue[T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, value := range r.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// PrefixScan returns an iterator over the key-value pairs whose key starts with prefix, in
+// lexicographic order. It has the same guarantees as All.
+func (r *RadixKeyValue[T]) PrefixScan(prefix string) iter.Seq2[string, T] {
+	return chunks(func(after *string, limit int) []kv[string, T] {
+		return r.pairs(prefix, after, limit)
+	})
+}
+
+// pairs returns a snapshot of the key-value pairs whose key starts with prefix, in lexicographic
+// order, from the first key greater than after, if it is given, until limit pairs are copied,
+// if it is positive. The functions given to the iteration methods are called on it without
+// holding the lock.
+func (r *RadixKeyValue[T]) pairs(prefix string, after *string, limit int) []kv[string, T] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pairs []kv[string, T]
+	add := func(key string, value T) bool {
+		pairs = append(pairs, kv[string, T]{key, value})
+		return limit <= 0 || len(pairs) < limit
+	}
+
+	if n, key := r.root.seek(prefix); n != nil {
+		if after != nil {
+			n.walkAfter(key, *after, add)
+		} else {
+			n.walk(key, add)
+		}
+	}
+	return pairs
+}
+
+// DeletePrefix deletes the key-value pairs whose key starts with prefix and returns how many
+// were deleted.
+func (r *RadixKeyValue[T]) DeletePrefix(prefix string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.root.deletePrefix(prefix)
+}
+
+// CountPrefix returns the number of keys starting with prefix.
+func (r *RadixKeyValue[T]) CountPrefix(prefix string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n, _ := r.root.seek(prefix); n != nil {
+		return n.count
+	}
+	return 0
+}
+
+// LongestPrefixMatch returns the longest key that is a prefix of the given key, its value and true,
+// or false if there is no such key.
+func (r *RadixKeyValue[T]) LongestPrefixMatch(key string) (string, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n, length := r.root.longestPrefix(key); n != nil {
+		return key[:length], n.value, true
+	}
+	var empty T
+	return "", empty, false
+}
+
+// Clone returns a new RadixKeyValue with a copy of the underlying data.
+func (r *RadixKeyValue[T]) Clone() *RadixKeyValue[T] {
+	return r.Filter(func(key string, value T) bool {
+		return true
+	})
+}
+
+// CloneAndClear returns a new RadixKeyValue with a copy of the underlying data and clears the container.
+func (r *RadixKeyValue[T]) CloneAndClear() *RadixKeyValue[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := &RadixKeyValue[T]{
+		root: r.root,
+	}
+	r.root = &radixNode[T]{}
+	return clone
+}
+
+// DeepEqual returns true if the given kv is deep equal to the RadixKeyValue container.
+func (r *RadixKeyValue[T]) DeepEqual(kv *RadixKeyValue[T]) bool {
+	if r == kv {
+		return true
+	}
+
+	return reflect.DeepEqual(r.Keys(), kv.Keys()) && reflect.DeepEqual(r.Values(), kv.Values())
+}
+
+// Map returns a new RadixKeyValue after applying the given function fn to each key-value pair.
+func (r *RadixKeyValue[T]) Map(fn func(key string, value T) (newKey string, newValue T)) *RadixKeyValue[T] {
+	m := NewRadixKeyValue[T]()
+	r.ForEach(func(key string, value T) {
+		m.root.set(fn(key, value))
+	})
+	return m
+}
+
+// MapKey returns a new RadixKeyValue after applying the given function fn to each key.
+func (r *RadixKeyValue[T]) MapKey(fn func(key string) string) *RadixKeyValue[T] {
+	return r.Map(func(key string, value T) (string, T) {
+		return fn(key), value
+	})
+}
+
+// MapValue returns a new RadixKeyValue after applying the given function fn to each value.
+func (r *RadixKeyValue[T]) MapValue(fn func(value T) T) *RadixKeyValue[T] {
+	return r.Map(func(key string, value T) (string, T) {
+		return key, fn(value)
+	})
+}
+
+// Filter returns a new RadixKeyValue after applying the given function fn to each key-value pair.
+func (r *RadixKeyValue[T]) Filter(fn func(key string, value T) bool) *RadixKeyValue[T] {
+	m, _ := r.Partition(fn)
+	return m
+}
+
+// FilterKey returns a new RadixKeyValue after applying the given function fn to each key.
+func (r *RadixKeyValue[T]) FilterKey(fn func(key string) bool) *RadixKeyValue[T] {
+	return r.Filter(func(key string, value T) bool {
+		return fn(key)
+	})
+}
+
+// FilterValue returns a new RadixKeyValue after applying the given function fn to each value.
+func (r *RadixKeyValue[T]) FilterValue(fn func(value T) bool) *RadixKeyValue[T] {
+	return r.Filter(func(key string, value T) bool {
+		return fn(value)
+	})
+}
+
+// Partition returns two new RadixKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each element.
+func (r *RadixKeyValue[T]) Partition(fn func(key string, value T) bool) (match, others *RadixKeyValue[T]) {
+	match = NewRadixKeyValue[T]()
+	others = NewRadixKeyValue[T]()
+
+	r.ForEach(func(key string, value T) {
+		if fn(key, value) {
+			match.root.set(key, value)
+		} else {
+			others.root.set(key, value)
+		}
+	})
+	return match, others
+}
+
+// PartitionKey returns two new RadixKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each key.
+func (r *RadixKeyValue[T]) PartitionKey(fn func(key string) bool) (match, others *RadixKeyValue[T]) {
+	return r.Partition(func(key string, value T) bool {
+		return fn(key)
+	})
+}
+
+// PartitionValue returns two new RadixKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each value.
+func (r *RadixKeyValue[T]) PartitionValue(fn func(value T) bool) (match, others *RadixKeyValue[T]) {
+	return r.Partition(func(key string, value T) bool {
+		return fn(value)
+	})
+}
+
+// SortKeys returns a []*string (keys) after sorting the keys using the given sortFn function.
+func (r *RadixKeyValue[T]) SortKeys(sortFn func(key1, key2 string) bool) []*string {
+	keys := r.Keys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return sortFn(keys[i], keys[j])
+	})
+
+	m := make([]*string, len(keys))
+	for i := range keys {
+		m[i] = &keys[i]
+	}
+	return m
+}
+
+// SortValues returns a []*T (values) after sorting the values using given function sortFn.
+func (r *RadixKeyValue[T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
+	values := r.Values()
+	sort.SliceStable(values, func(i, j int) bool {
+		return sortFn(values[i], values[j])
+	})
+
+	m := make([]*T, len(values))
+	for i := range values {
+		m[i] = &values[i]
+	}
+	return m
+}
@@ -0,0 +1,221 @@
+package r9e
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newRadixTestKeyValue() *RadixKeyValue[int] {
+	kv := NewRadixKeyValue[int]()
+	for i, key := range []string{
+		"acme/api/1",
+		"acme/api/2",
+		"acme/web/1",
+		"acme",
+		"globex/api/1",
+		"globex/db/1",
+		"globex/db/10",
+	} {
+		kv.Set(key, i)
+	}
+	return kv
+}
+
+func TestSet_RadixKeyValue(t *testing.T) {
+	t.Run("test Set, Get and Delete with shared prefixes", func(t *testing.T) {
+		kv := newRadixTestKeyValue()
+
+		if kv.Size() != 7 {
+			t.Errorf("Expected size to be %v, got %v", 7, kv.Size())
+		}
+		if value, ok := kv.GetAndCheck("globex/db/1"); !ok || value != 5 {
+			t.Errorf("Expected value to be %v, got %v", 5, value)
+		}
+		if kv.ContainsKey("acme/") || kv.ContainsKey("globex/db/") {
+			t.Errorf("Expected inner prefixes not to be keys")
+		}
+
+		kv.Delete("globex/db/1")
+		if kv.ContainsKey("globex/db/1") || !kv.ContainsKey("globex/db/10") {
+			t.Errorf("Expected only key %v to be deleted", "globex/db/1")
+		}
+
+		kv.Set("acme", 100)
+		if kv.Get("acme") != 100 || kv.Size() != 6 {
+			t.Errorf("Expected value and size to be %v and %v, got %v and %v", 100, 6, kv.Get("acme"), kv.Size())
+		}
+	})
+
+	t.Run("test Keys are in lexicographic order", func(t *testing.T) {
+		kv := newRadixTestKeyValue()
+
+		keys := kv.Keys()
+		if !sort.StringsAreSorted(keys) {
+			t.Errorf("Expected keys to be sorted, got %v", keys)
+		}
+	})
+
+	t.Run("test empty key", func(t *testing.T) {
+		kv := NewRadixKeyValue[int]()
+		kv.Set("", 1)
+		kv.Set("a", 2)
+
+		if value, ok := kv.GetAndCheck(""); !ok || value != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, value)
+		}
+		if keys := kv.Keys(); !slices.Equal(keys, []string{"", "a"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"", "a"}, keys)
+		}
+	})
+
+	t.Run("test random changes against a map", func(t *testing.T) {
+		kv := NewRadixKeyValue[int]()
+		m := make(map[string]int)
+
+		for i := 0; i < 5000; i++ {
+			key := strings.Repeat("ab", rand.Intn(3)) + string(rune('a'+rand.Intn(4))) + string(rune('a'+rand.Intn(4)))
+			if rand.Intn(3) == 0 {
+				kv.Delete(key)
+				delete(m, key)
+			} else {
+				kv.Set(key, i)
+				m[key] = i
+			}
+		}
+
+		if kv.Size() != len(m) {
+			t.Fatalf("Expected size to be %v, got %v", len(m), kv.Size())
+		}
+		for key, value := range m {
+			if got, ok := kv.GetAndCheck(key); !ok || got != value {
+				t.Fatalf("Expected value of %v to be %v, got %v", key, value, got)
+			}
+		}
+		for _, prefix := range []string{"", "a", "ab", "aba", "abab", "c", "abd", "zz"} {
+			expected := 0
+			for key := range m {
+				if strings.HasPrefix(key, prefix) {
+					expected++
+				}
+			}
+			if count := kv.CountPrefix(prefix); count != expected {
+				t.Fatalf("Expected CountPrefix(%q) to be %v, got %v", prefix, expected, count)
+			}
+		}
+	})
+}
+
+func TestPrefixScan_RadixKeyValue(t *testing.T) {
+	kv := newRadixTestKeyValue()
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"acme/", []string{"acme/api/1", "acme/api/2", "acme/web/1"}},
+		{"acme", []string{"acme", "acme/api/1", "acme/api/2", "acme/web/1"}},
+		{"globex/d", []string{"globex/db/1", "globex/db/10"}},
+		{"globex/db/1", []string{"globex/db/1", "globex/db/10"}},
+		{"initech", nil},
+		{"acme/x", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run("test PrefixScan "+tc.prefix, func(t *testing.T) {
+			var keys []string
+			for key := range kv.PrefixScan(tc.prefix) {
+				keys = append(keys, key)
+			}
+
+			if !slices.Equal(keys, tc.expected) {
+				t.Errorf("Expected keys to be %v, got %v", tc.expected, keys)
+			}
+			if count := kv.CountPrefix(tc.prefix); count != len(tc.expected) {
+				t.Errorf("Expected CountPrefix to be %v, got %v", len(tc.expected), count)
+			}
+		})
+	}
+
+	t.Run("test PrefixScan stops early", func(t *testing.T) {
+		count := 0
+		for range kv.PrefixScan("") {
+			count++
+			break
+		}
+
+		if count != 1 {
+			t.Errorf("Expected count to be %v, got %v", 1, count)
+		}
+	})
+}
+
+func TestDeletePrefix_RadixKeyValue(t *testing.T) {
+	t.Run("test DeletePrefix", func(t *testing.T) {
+		kv := newRadixTestKeyValue()
+
+		if removed := kv.DeletePrefix("acme/"); removed != 3 {
+			t.Errorf("Expected removed to be %v, got %v", 3, removed)
+		}
+		if keys := kv.Keys(); !slices.Equal(keys, []string{"acme", "globex/api/1", "globex/db/1", "globex/db/10"}) {
+			t.Errorf("Expected keys to be %v, got %v", []string{"acme", "globex/api/1", "globex/db/1", "globex/db/10"}, keys)
+		}
+
+		if removed := kv.DeletePrefix("globex/db/1"); removed != 2 {
+			t.Errorf("Expected removed to be %v, got %v", 2, removed)
+		}
+		if removed := kv.DeletePrefix("initech"); removed != 0 {
+			t.Errorf("Expected removed to be %v, got %v", 0, removed)
+		}
+		if kv.Size() != 2 || kv.CountPrefix("globex") != 1 {
+			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
+		}
+
+		// the tree is still usable after the deletes
+		kv.Set("globex/db/2", 2)
+		if kv.CountPrefix("globex/") != 2 {
+			t.Errorf("Expected CountPrefix to be %v, got %v", 2, kv.CountPrefix("globex/"))
+		}
+	})
+
+	t.Run("test DeletePrefix with empty prefix", func(t *testing.T) {
+		kv := newRadixTestKeyValue()
+
+		if removed := kv.DeletePrefix(""); removed != 7 {
+			t.Errorf("Expected removed to be %v, got %v", 7, removed)
+		}
+		if !kv.IsEmpty() {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+}
+
+func TestLongestPrefixMatch_RadixKeyValue(t *testing.T) {
+	kv := NewRadixKeyValue[string]()
+	kv.Set("/", "root")
+	kv.Set("/api", "api")
+	kv.Set("/api/v1", "v1")
+
+	tests := []struct {
+		key      string
+		expected string
+		found    bool
+	}{
+		{"/api/v1/users", "/api/v1", true},
+		{"/api/v2", "/api", true},
+		{"/api", "/api", true},
+		{"/static", "/", true},
+		{"api", "", false},
+	}
+
+	for _, tc := range tests {
+		t.Run("test LongestPrefixMatch "+tc.key, func(t *testing.T) {
+			key, _, ok := kv.LongestPrefixMatch(tc.key)
+			if key != tc.expected || ok != tc.found {
+				t.Errorf("Expected LongestPrefixMatch to be %v %v, got %v %v", tc.expected, tc.found, key, ok)
+			}
+		})
+	}
+}
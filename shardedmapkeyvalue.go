@@ -0,0 +1,438 @@
+package r9e
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"time"
+)
+
+type shardedMapKeyValueOptions struct {
+	shards  int
+	hasher  any
+	options []MapKeyValueOptions
+}
+
+// ShardedMapKeyValueOptions are the options for ShardedMapKeyValue container.
+type ShardedMapKeyValueOptions func(*shardedMapKeyValueOptions)
+
+// WithShards sets the number of shards of the ShardedMapKeyValue container.
+// The number is rounded up to the next power of two, the default is four times GOMAXPROCS.
+func WithShards(n int) ShardedMapKeyValueOptions {
+	return func(kv *shardedMapKeyValueOptions) {
+		kv.shards = n
+	}
+}
+
+// WithHasher sets the Hasher used to choose the shard of the keys of the ShardedMapKeyValue container.
+// The default is NewHasher.
+func WithHasher[K comparable](hasher Hasher[K]) ShardedMapKeyValueOptions {
+	return func(kv *shardedMapKeyValueOptions) {
+		kv.hasher = hasher
+	}
+}
+
+// WithShardOptions sets the options used to create every MapKeyValue shard of the ShardedMapKeyValue container.
+// Options like WithMaxEntries or WithCapacity apply to each shard and not to the whole container.
+func WithShardOptions(options ...MapKeyValueOptions) ShardedMapKeyValueOptions {
+	return func(kv *shardedMapKeyValueOptions) {
+		kv.options = append(kv.options, options...)
+	}
+}
+
+// ShardedMapKeyValue is a generic key-value store container that is thread-safe.
+// This split the keys across several MapKeyValue shards, each one protected by its own mutex,
+// so the writes of different keys don't contend for the same lock.
+// The operations involving several keys, like Clear or Filter, are not atomic across the shards.
+type ShardedMapKeyValue[K comparable, T any] struct {
+	shards []*MapKeyValue[K, T]
+	mask   uint64
+	hash   Hasher[K]
+}
+
+// NewShardedMapKeyValue returns a new ShardedMapKeyValue container.
+func NewShardedMapKeyValue[K comparable, T any](options ...ShardedMapKeyValueOptions) *ShardedMapKeyValue[K, T] {
+	kvo := shardedMapKeyValueOptions{
+		shards: runtime.GOMAXPROCS(0) * 4,
+	}
+	for _, opt := range options {
+		opt(&kvo)
+	}
+
+	n := 1
+	for n < kvo.shards {
+		n <<= 1
+	}
+
+	hash := NewHasher[K]()
+	if kvo.hasher != nil {
+		h, ok := kvo.hasher.(Hasher[K])
+		if !ok {
+			var key K
+			panic(fmt.Sprintf("r9e: the hasher doesn't match the container key type %T", key))
+		}
+		hash = h
+	}
+
+	kv := &ShardedMapKeyValue[K, T]{
+		shards: make([]*MapKeyValue[K, T], n),
+		mask:   uint64(n - 1),
+		hash:   hash,
+	}
+	for i := range kv.shards {
+		kv.shards[i] = NewMapKeyValue[K, T](kvo.options...)
+	}
+	return kv
+}
+
+// newEmpty returns a new empty ShardedMapKeyValue with the same shards and hasher.
+func (r *ShardedMapKeyValue[K, T]) newEmpty() *ShardedMapKeyValue[K, T] {
+	kv := &ShardedMapKeyValue[K, T]{
+		shards: make([]*MapKeyValue[K, T], len(r.shards)),
+		mask:   r.mask,
+		hash:   r.hash,
+	}
+	for i := range kv.shards {
+		kv.shards[i] = NewMapKeyValue[K, T]()
+	}
+	return kv
+}
+
+// shard returns the shard of the key.
+func (r *ShardedMapKeyValue[K, T]) shard(key K) *MapKeyValue[K, T] {
+	return r.shards[r.hash(key)&r.mask]
+}
+
+// Set sets the value associated with the key.
+func (r *ShardedMapKeyValue[K, T]) Set(key K, value T) {
+	r.shard(key).Set(key, value)
+}
+
+// SetWithTTL sets the value associated with the key and expires it after the given ttl.
+// A ttl less or equal than zero means the key never expires.
+func (r *ShardedMapKeyValue[K, T]) SetWithTTL(key K, value T, ttl time.Duration) {
+	r.shard(key).SetWithTTL(key, value, ttl)
+}
+
+// ExpireAt sets the time when the key expires.
+// Returns false if the key doesn't exist.
+func (r *ShardedMapKeyValue[K, T]) ExpireAt(key K, at time.Time) bool {
+	return r.shard(key).ExpireAt(key, at)
+}
+
+// TTL returns the remaining time to live of the key and true if the key exists.
+// If the key exists but it doesn't expire, return NoExpiration.
+func (r *ShardedMapKeyValue[K, T]) TTL(key K) (time.Duration, bool) {
+	return r.shard(key).TTL(key)
+}
+
+// Persist removes the expiration of the key, so it never expires.
+// Returns true if the key exists and the expiration was removed.
+func (r *ShardedMapKeyValue[K, T]) Persist(key K) bool {
+	return r.shard(key).Persist(key)
+}
+
+// DeleteExpired removes all the expired key-value pairs from the container.
+func (r *ShardedMapKeyValue[K, T]) DeleteExpired() {
+	for _, shard := range r.shards {
+		shard.DeleteExpired()
+	}
+}
+
+// Close closes every shard, and returns their errors joined.
+func (r *ShardedMapKeyValue[K, T]) Close() error {
+	var errs []error
+	for _, shard := range r.shards {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (r *ShardedMapKeyValue[K, T]) GetAndCheck(key K) (T, bool) {
+	return r.shard(key).GetAndCheck(key)
+}
+
+// Get returns the value associated with the key.
+// If the key does not exist, return zero value of the type.
+func (r *ShardedMapKeyValue[K, T]) Get(key K) T {
+	return r.shard(key).Get(key)
+}
+
+// Peek returns the value associated with the key if this exist also a
+// boolean value if this exist of not, without notifying the access to the eviction policy.
+func (r *ShardedMapKeyValue[K, T]) Peek(key K) (T, bool) {
+	return r.shard(key).Peek(key)
+}
+
+// GetAnDelete returns the value associated with the key and delete it if the key exist
+// if the key doesn't exist return the given key value false
+func (r *ShardedMapKeyValue[K, T]) GetAnDelete(key K) (T, bool) {
+	return r.shard(key).GetAnDelete(key)
+}
+
+// Delete deletes the value associated with the key.
+func (r *ShardedMapKeyValue[K, T]) Delete(key K) {
+	r.shard(key).Delete(key)
+}
+
+// Clear deletes all key-value pairs stored in the container.
+func (r *ShardedMapKeyValue[K, T]) Clear() {
+	for _, shard := range r.shards {
+		shard.Clear()
+	}
+}
+
+// Size returns the number of key-value pairs stored in the container.
+// This is the sum of the size of the shards.
+func (r *ShardedMapKeyValue[K, T]) Size() int {
+	size := 0
+	for _, shard := range r.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
+// IsEmpty returns true if the container is empty.
+func (r *ShardedMapKeyValue[K, T]) IsEmpty() bool {
+	for _, shard := range r.shards {
+		if shard.IsFull() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFull returns true if the container has elements.
+func (r *ShardedMapKeyValue[K, T]) IsFull() bool {
+	return !r.IsEmpty()
+}
+
+// ContainsKey returns true if the key is in the container.
+func (r *ShardedMapKeyValue[K, T]) ContainsKey(key K) bool {
+	return r.shard(key).ContainsKey(key)
+}
+
+// ContainsValue returns true if the value is in the container.
+func (r *ShardedMapKeyValue[K, T]) ContainsValue(value T) bool {
+	for _, shard := range r.shards {
+		if shard.ContainsValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the key value associated with the key.
+func (r *ShardedMapKeyValue[K, T]) Key(key K) K {
+	return r.shard(key).Key(key)
+}
+
+// Keys returns all keys stored in the container.
+func (r *ShardedMapKeyValue[K, T]) Keys() []K {
+	keys := make([]K, 0, r.Size())
+	for _, shard := range r.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+// Values returns all values stored in the container.
+func (r *ShardedMapKeyValue[K, T]) Values() []T {
+	values := make([]T, 0, r.Size())
+	for _, shard := range r.shards {
+		values = append(values, shard.Values()...)
+	}
+	return values
+}
+
+// ForEach calls the given function for each key-value pair in the container.
+// The function is called on a snapshot of all the shards taken before the first call,
+// so it can modify the container without visiting the keys it sets.
+func (r *ShardedMapKeyValue[K, T]) ForEach(fn func(key K, value T)) {
+	for _, pair := range r.pairs() {
+		fn(pair.key, pair.value)
+	}
+}
+
+// ForEachKey calls the given function for each key in the container.
+// It has the same guarantees as ForEach.
+func (r *ShardedMapKeyValue[K, T]) ForEachKey(fn func(key K)) {
+	for _, pair := range r.pairs() {
+		fn(pair.key)
+	}
+}
+
+// ForEachValue calls the given function for each value in the container.
+// It has the same guarantees as ForEach.
+func (r *ShardedMapKeyValue[K, T]) ForEachValue(fn func(value T)) {
+	for _, pair := range r.pairs() {
+		fn(pair.value)
+	}
+}
+
+// pairs returns a copy of the non-expired key-value pairs of all the shards.
+// Each shard is copied holding its lock, one after another.
+func (r *ShardedMapKeyValue[K, T]) pairs() []kv[K, T] {
+	var pairs []kv[K, T]
+	for _, shard := range r.shards {
+		pairs = append(pairs, shard.pairs()...)
+	}
+	return pairs
+}
+
+// Clone returns a new ShardedMapKeyValue with a copy of the underlying data.
+func (r *ShardedMapKeyValue[K, T]) Clone() *ShardedMapKeyValue[K, T] {
+	clone := r.newEmpty()
+	for i, shard := range r.shards {
+		clone.shards[i] = shard.Clone()
+	}
+	return clone
+}
+
+// CloneAndClear returns a new ShardedMapKeyValue with a copy of the underlying data and clears the container.
+func (r *ShardedMapKeyValue[K, T]) CloneAndClear() *ShardedMapKeyValue[K, T] {
+	clone := r.newEmpty()
+	for i, shard := range r.shards {
+		clone.shards[i] = shard.CloneAndClear()
+	}
+	return clone
+}
+
+// DeepEqual returns true if the given kv is deep equal to the ShardedMapKeyValue container
+func (r *ShardedMapKeyValue[K, T]) DeepEqual(kv *ShardedMapKeyValue[K, T]) bool {
+	if r == kv {
+		return true
+	}
+
+	if r.Size() != kv.Size() {
+		return false
+	}
+
+	equal := true
+	r.ForEach(func(key K, value T) {
+		if !equal {
+			return
+		}
+		other, ok := kv.Peek(key)
+		equal = ok && reflect.DeepEqual(value, other)
+	})
+	return equal
+}
+
+// Map returns a new ShardedMapKeyValue after applying the given function fn to each key-value pair.
+func (r *ShardedMapKeyValue[K, T]) Map(fn func(key K, value T) (newKey K, newValue T)) *ShardedMapKeyValue[K, T] {
+	m := r.newEmpty()
+	r.ForEach(func(key K, value T) {
+		m.Set(fn(key, value))
+	})
+	return m
+}
+
+// MapKey returns a new ShardedMapKeyValue after applying the given function fn to each key.
+func (r *ShardedMapKeyValue[K, T]) MapKey(fn func(key K) K) *ShardedMapKeyValue[K, T] {
+	m := r.newEmpty()
+	r.ForEach(func(key K, value T) {
+		m.Set(fn(key), value)
+	})
+	return m
+}
+
+// MapValue returns a new ShardedMapKeyValue after applying the given function fn to each value.
+func (r *ShardedMapKeyValue[K, T]) MapValue(fn func(value T) T) *ShardedMapKeyValue[K, T] {
+	m := r.newEmpty()
+	for i, shard := range r.shards {
+		m.shards[i] = shard.MapValue(fn)
+	}
+	return m
+}
+
+// Filter returns a new ShardedMapKeyValue after applying the given function fn to each key-value pair.
+func (r *ShardedMapKeyValue[K, T]) Filter(fn func(key K, value T) bool) *ShardedMapKeyValue[K, T] {
+	m := r.newEmpty()
+	for i, shard := range r.shards {
+		m.shards[i] = shard.Filter(fn)
+	}
+	return m
+}
+
+// FilterKey returns a new ShardedMapKeyValue after applying the given function fn to each key.
+func (r *ShardedMapKeyValue[K, T]) FilterKey(fn func(key K) bool) *ShardedMapKeyValue[K, T] {
+	m := r.newEmpty()
+	for i, shard := range r.shards {
+		m.shards[i] = shard.FilterKey(fn)
+	}
+	return m
+}
+
+// FilterValue returns a new ShardedMapKeyValue after applying the given function fn to each value.
+func (r *ShardedMapKeyValue[K, T]) FilterValue(fn func(value T) bool) *ShardedMapKeyValue[K, T] {
+	m := r.newEmpty()
+	for i, shard := range r.shards {
+		m.shards[i] = shard.FilterValue(fn)
+	}
+	return m
+}
+
+// Partition returns two new ShardedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each element.
+func (r *ShardedMapKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, others *ShardedMapKeyValue[K, T]) {
+	match, others = r.newEmpty(), r.newEmpty()
+	for i, shard := range r.shards {
+		match.shards[i], others.shards[i] = shard.Partition(fn)
+	}
+	return
+}
+
+// PartitionKey returns two new ShardedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each key.
+func (r *ShardedMapKeyValue[K, T]) PartitionKey(fn func(key K) bool) (match, others *ShardedMapKeyValue[K, T]) {
+	match, others = r.newEmpty(), r.newEmpty()
+	for i, shard := range r.shards {
+		match.shards[i], others.shards[i] = shard.PartitionKey(fn)
+	}
+	return
+}
+
+// PartitionValue returns two new ShardedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each value.
+func (r *ShardedMapKeyValue[K, T]) PartitionValue(fn func(value T) bool) (match, others *ShardedMapKeyValue[K, T]) {
+	match, others = r.newEmpty(), r.newEmpty()
+	for i, shard := range r.shards {
+		match.shards[i], others.shards[i] = shard.PartitionValue(fn)
+	}
+	return
+}
+
+// SortKeys returns a []*K (keys) after sorting the keys using the given sortFn function.
+func (r *ShardedMapKeyValue[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
+	keys := r.Keys()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return sortFn(keys[i], keys[j])
+	})
+
+	m := make([]*K, len(keys))
+	for i := range keys {
+		m[i] = &keys[i]
+	}
+	return m
+}
+
+// SortValues returns a []*T (values) after sorting the values using given function sortFn.
+func (r *ShardedMapKeyValue[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
+	values := r.Values()
+
+	sort.Slice(values, func(i, j int) bool {
+		return sortFn(values[i], values[j])
+	})
+
+	m := make([]*T, len(values))
+	for i := range values {
+		m[i] = &values[i]
+	}
+	return m
+}
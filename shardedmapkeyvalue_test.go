@@ -0,0 +1,330 @@
+package r9e
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestNewShardedMapKeyValue(t *testing.T) {
+	t.Run("test NewShardedMapKeyValue[int, int] rounds the shards to a power of two", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[int, int](WithShards(5))
+
+		if len(kv.shards) != 8 {
+			t.Errorf("Expected shards to be %v, got %v", 8, len(kv.shards))
+		}
+		if kv.Size() != 0 {
+			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		}
+	})
+
+	t.Run("test NewShardedMapKeyValue[string, int] spreads the keys across the shards", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[string, int](WithShards(4))
+
+		for i := 0; i < 1000; i++ {
+			kv.Set(strconv.Itoa(i), i)
+		}
+
+		if kv.Size() != 1000 {
+			t.Errorf("Expected size to be %v, got %v", 1000, kv.Size())
+		}
+		for i, shard := range kv.shards {
+			if shard.Size() == 0 {
+				t.Errorf("Expected shard %v to have keys", i)
+			}
+		}
+	})
+
+	t.Run("test NewShardedMapKeyValue with custom hasher", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[int, int](WithShards(4), WithHasher(Hasher[int](func(key int) uint64 {
+			return 0
+		})))
+
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+		}
+
+		if kv.shards[0].Size() != 100 {
+			t.Errorf("Expected size of the first shard to be %v, got %v", 100, kv.shards[0].Size())
+		}
+	})
+
+	t.Run("test NewShardedMapKeyValue with hasher of a different key type panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected NewShardedMapKeyValue to panic")
+			}
+		}()
+
+		NewShardedMapKeyValue[string, int](WithHasher(NewHasher[int]()))
+	})
+
+	t.Run("test NewShardedMapKeyValue with shard options", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[int, int](WithShards(2), WithShardOptions(WithMaxEntries(10)))
+
+		for i := 0; i < 1000; i++ {
+			kv.Set(i, i)
+		}
+
+		if kv.Size() != 20 {
+			t.Errorf("Expected size to be %v, got %v", 20, kv.Size())
+		}
+	})
+}
+
+func TestGetSetDelete_ShardedMapKeyValue(t *testing.T) {
+	t.Run("test Set, Get, GetAndCheck, GetAnDelete, Delete and Clear", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[string, int]()
+
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+		kv.Set("three", 3)
+
+		if v := kv.Get("one"); v != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, v)
+		}
+		if v, ok := kv.GetAndCheck("two"); !ok || v != 2 {
+			t.Errorf("Expected value to be %v, got %v", 2, v)
+		}
+		if _, ok := kv.GetAndCheck("four"); ok {
+			t.Errorf("Expected GetAndCheck to be %v, got %v", false, ok)
+		}
+		if v, ok := kv.GetAnDelete("three"); !ok || v != 3 {
+			t.Errorf("Expected value to be %v, got %v", 3, v)
+		}
+		if kv.ContainsKey("three") {
+			t.Errorf("Expected ContainsKey to be %v, got %v", false, true)
+		}
+		if kv.Key("two") != "two" {
+			t.Errorf("Expected key to be %v, got %v", "two", kv.Key("two"))
+		}
+		if !kv.ContainsValue(2) {
+			t.Errorf("Expected ContainsValue to be %v, got %v", true, false)
+		}
+
+		kv.Delete("two")
+
+		if kv.Size() != 1 {
+			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
+		}
+		if !kv.IsFull() || kv.IsEmpty() {
+			t.Errorf("Expected container to be full")
+		}
+
+		kv.Clear()
+
+		if !kv.IsEmpty() || kv.IsFull() {
+			t.Errorf("Expected container to be empty")
+		}
+	})
+}
+
+func TestKeysValues_ShardedMapKeyValue(t *testing.T) {
+	t.Run("test Keys, Values, ForEach, ForEachKey and ForEachValue", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[int, int]()
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i*10)
+		}
+
+		keys := kv.Keys()
+		sort.Ints(keys)
+		values := kv.Values()
+		sort.Ints(values)
+
+		for i := 0; i < 100; i++ {
+			if keys[i] != i || values[i] != i*10 {
+				t.Fatalf("Expected key-value to be %v-%v, got %v-%v", i, i*10, keys[i], values[i])
+			}
+		}
+
+		sumKeys, sumValues, count := 0, 0, 0
+		kv.ForEach(func(key int, value int) {
+			count++
+		})
+		kv.ForEachKey(func(key int) {
+			sumKeys += key
+		})
+		kv.ForEachValue(func(value int) {
+			sumValues += value
+		})
+
+		if count != 100 || sumKeys != 4950 || sumValues != 49500 {
+			t.Errorf("Expected count, keys and values to be %v, %v, %v, got %v, %v, %v", 100, 4950, 49500, count, sumKeys, sumValues)
+		}
+	})
+}
+
+func TestCloneDeepEqual_ShardedMapKeyValue(t *testing.T) {
+	t.Run("test Clone, DeepEqual and CloneAndClear", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[string, TestStruct]()
+		kv.Set("a", TestStruct{a: "a", b: 1})
+		kv.Set("b", TestStruct{a: "b", b: 2})
+
+		clone := kv.Clone()
+
+		if !kv.DeepEqual(clone) {
+			t.Errorf("Expected DeepEqual to be %v, got %v", true, false)
+		}
+
+		clone.Set("b", TestStruct{a: "b", b: 3})
+
+		if kv.DeepEqual(clone) {
+			t.Errorf("Expected DeepEqual to be %v, got %v", false, true)
+		}
+
+		cleared := kv.CloneAndClear()
+
+		if cleared.Size() != 2 || kv.Size() != 0 {
+			t.Errorf("Expected sizes to be %v and %v, got %v and %v", 2, 0, cleared.Size(), kv.Size())
+		}
+	})
+}
+
+func TestMapFilterPartition_ShardedMapKeyValue(t *testing.T) {
+	kv := NewShardedMapKeyValue[int, int]()
+	for i := 0; i < 100; i++ {
+		kv.Set(i, i)
+	}
+
+	t.Run("test Map moves the keys to their new shard", func(t *testing.T) {
+		m := kv.Map(func(key int, value int) (int, int) {
+			return key + 1000, value * 2
+		})
+
+		if m.Size() != 100 || m.Get(1010) != 20 {
+			t.Errorf("Expected value to be %v, got %v", 20, m.Get(1010))
+		}
+
+		mk := kv.MapKey(func(key int) int { return -key })
+		if mk.Get(-10) != 10 {
+			t.Errorf("Expected value to be %v, got %v", 10, mk.Get(-10))
+		}
+
+		mv := kv.MapValue(func(value int) int { return value + 1 })
+		if mv.Get(10) != 11 {
+			t.Errorf("Expected value to be %v, got %v", 11, mv.Get(10))
+		}
+	})
+
+	t.Run("test Filter, FilterKey and FilterValue", func(t *testing.T) {
+		even := func(n int) bool { return n%2 == 0 }
+
+		if f := kv.Filter(func(key int, value int) bool { return even(key) }); f.Size() != 50 {
+			t.Errorf("Expected size to be %v, got %v", 50, f.Size())
+		}
+		if f := kv.FilterKey(even); f.Size() != 50 {
+			t.Errorf("Expected size to be %v, got %v", 50, f.Size())
+		}
+		if f := kv.FilterValue(func(value int) bool { return value < 10 }); f.Size() != 10 {
+			t.Errorf("Expected size to be %v, got %v", 10, f.Size())
+		}
+	})
+
+	t.Run("test Partition, PartitionKey and PartitionValue", func(t *testing.T) {
+		less := func(n int) bool { return n < 30 }
+
+		match, others := kv.Partition(func(key int, value int) bool { return less(key) })
+		if match.Size() != 30 || others.Size() != 70 {
+			t.Errorf("Expected sizes to be %v and %v, got %v and %v", 30, 70, match.Size(), others.Size())
+		}
+
+		match, others = kv.PartitionKey(less)
+		if match.Size() != 30 || others.Size() != 70 {
+			t.Errorf("Expected sizes to be %v and %v, got %v and %v", 30, 70, match.Size(), others.Size())
+		}
+
+		match, others = kv.PartitionValue(less)
+		if match.Size() != 30 || others.Size() != 70 {
+			t.Errorf("Expected sizes to be %v and %v, got %v and %v", 30, 70, match.Size(), others.Size())
+		}
+	})
+
+	t.Run("test SortKeys and SortValues", func(t *testing.T) {
+		keys := kv.SortKeys(func(key1, key2 int) bool { return key1 > key2 })
+		values := kv.SortValues(func(value1, value2 int) bool { return value1 < value2 })
+
+		for i := 0; i < 100; i++ {
+			if *keys[i] != 99-i || *values[i] != i {
+				t.Fatalf("Expected key and value to be %v and %v, got %v and %v", 99-i, i, *keys[i], *values[i])
+			}
+		}
+	})
+}
+
+func TestConcurrent_ShardedMapKeyValue(t *testing.T) {
+	t.Run("test concurrent Set and Delete keep the size consistent", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[int, int](WithShards(8))
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					kv.Set(g*1000+i, i)
+				}
+				for i := 0; i < 500; i++ {
+					kv.Delete(g*1000 + i)
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if kv.Size() != 4000 {
+			t.Errorf("Expected size to be %v, got %v", 4000, kv.Size())
+		}
+		if len(kv.Keys()) != 4000 {
+			t.Errorf("Expected keys to be %v, got %v", 4000, len(kv.Keys()))
+		}
+	})
+}
+
+func TestClose_ShardedMapKeyValue(t *testing.T) {
+	t.Run("test Close returns the errors of every shard", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[string, int](WithShards(4))
+		for _, i := range []int{0, 2} {
+			shard, err := OpenMapKeyValue[string, int](t.TempDir())
+			if err != nil {
+				t.Fatalf("Expected error to be %v, got %v", nil, err)
+			}
+			// the log can't be closed again
+			shard.wal.file.Close()
+			kv.shards[i] = shard
+		}
+
+		err := kv.Close()
+		if !errors.Is(err, os.ErrClosed) {
+			t.Errorf("Expected error to be %v, got %v", os.ErrClosed, err)
+		}
+		if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 2 {
+			t.Errorf("Expected errors to be %v, got %v", 2, len(errs))
+		}
+	})
+
+	t.Run("test Close without errors", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[string, int](WithShards(4))
+
+		if err := kv.Close(); err != nil {
+			t.Errorf("Expected error to be %v, got %v", nil, err)
+		}
+	})
+}
+
+func BenchmarkShardedMapKeyValue_Set_Get_string_struct_concurrent(b *testing.B) {
+	kv := NewShardedMapKeyValue[string, TestStruct](WithShardOptions(WithCapacity(kvSize)))
+
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			keyval := fmt.Sprintf("%x", md5.Sum([]byte(strconv.Itoa(rnd.Intn(kvSize)))))
+			kv.Set(keyval, TestStruct{a: keyval, b: rnd.Intn(kvSize)})
+			kv.Get(keyval)
+		}
+	})
+}
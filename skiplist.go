@@ -0,0 +1,232 @@
+package r9e
+
+import (
+	"math/rand/v2"
+)
+
+const (
+	// skiplistMaxLevel is enough for 4^32 elements using skiplistP.
+	skiplistMaxLevel = 32
+
+	// skiplistP is the inverse of the probability of a node to be in the next level.
+	skiplistP = 4
+)
+
+// skiplist is an indexable skip list, the span of each link is the number of nodes it skips,
+// so the rank and the position of the keys are found in O(log n).
+// It is not thread-safe.
+type skiplist[K comparable, T any] struct {
+	compare func(a, b K) int
+	head    *skiplistNode[K, T]
+	tail    *skiplistNode[K, T]
+	level   int
+	size    int
+}
+
+type skiplistNode[K comparable, T any] struct {
+	key   K
+	value T
+	prev  *skiplistNode[K, T]
+	next  []skiplistLink[K, T]
+}
+
+type skiplistLink[K comparable, T any] struct {
+	node *skiplistNode[K, T]
+	span int
+}
+
+// newSkiplist returns a new empty skiplist ordered by compare.
+func newSkiplist[K comparable, T any](compare func(a, b K) int) *skiplist[K, T] {
+	return &skiplist[K, T]{
+		compare: compare,
+		head:    &skiplistNode[K, T]{next: make([]skiplistLink[K, T], skiplistMaxLevel)},
+		level:   1,
+	}
+}
+
+// randomLevel returns the level of a new node.
+func (s *skiplist[K, T]) randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && rand.IntN(skiplistP) == 0 {
+		level++
+	}
+	return level
+}
+
+// get returns the node of the key, or nil if it doesn't exist.
+func (s *skiplist[K, T]) get(key K) *skiplistNode[K, T] {
+	if n := s.ceiling(key); n != nil && s.compare(n.key, key) == 0 {
+		return n
+	}
+	return nil
+}
+
+// set stores the value of the key and returns true if the key was added.
+func (s *skiplist[K, T]) set(key K, value T) bool {
+	var update [skiplistMaxLevel]*skiplistNode[K, T]
+	var rank [skiplistMaxLevel]int
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		if i < s.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.next[i].node != nil && s.compare(x.next[i].node.key, key) < 0 {
+			rank[i] += x.next[i].span
+			x = x.next[i].node
+		}
+		update[i] = x
+	}
+
+	if n := x.next[0].node; n != nil && s.compare(n.key, key) == 0 {
+		n.value = value
+		return false
+	}
+
+	level := s.randomLevel()
+	if level > s.level {
+		for i := s.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = s.head
+			s.head.next[i] = skiplistLink[K, T]{span: s.size}
+		}
+		s.level = level
+	}
+
+	n := &skiplistNode[K, T]{key: key, value: value, next: make([]skiplistLink[K, T], level)}
+	for i := 0; i < level; i++ {
+		n.next[i].node = update[i].next[i].node
+		update[i].next[i].node = n
+
+		// the new node splits the span of the previous node
+		n.next[i].span = update[i].next[i].span - (rank[0] - rank[i])
+		update[i].next[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < s.level; i++ {
+		update[i].next[i].span++
+	}
+
+	if update[0] != s.head {
+		n.prev = update[0]
+	}
+	if n.next[0].node != nil {
+		n.next[0].node.prev = n
+	} else {
+		s.tail = n
+	}
+
+	s.size++
+	return true
+}
+
+// delete removes the key and returns its node, or nil if it doesn't exist.
+func (s *skiplist[K, T]) delete(key K) *skiplistNode[K, T] {
+	var update [skiplistMaxLevel]*skiplistNode[K, T]
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && s.compare(x.next[i].node.key, key) < 0 {
+			x = x.next[i].node
+		}
+		update[i] = x
+	}
+
+	n := x.next[0].node
+	if n == nil || s.compare(n.key, key) != 0 {
+		return nil
+	}
+
+	s.remove(n, update[:s.level])
+	return n
+}
+
+// remove unlinks the node, update holds the previous node of each level.
+func (s *skiplist[K, T]) remove(n *skiplistNode[K, T], update []*skiplistNode[K, T]) {
+	for i := range update {
+		if update[i].next[i].node == n {
+			update[i].next[i].span += n.next[i].span - 1
+			update[i].next[i].node = n.next[i].node
+		} else {
+			update[i].next[i].span--
+		}
+	}
+
+	if n.next[0].node != nil {
+		n.next[0].node.prev = n.prev
+	} else {
+		s.tail = n.prev
+	}
+
+	for s.level > 1 && s.head.next[s.level-1].node == nil {
+		s.level--
+	}
+	s.size--
+}
+
+// first returns the node of the smallest key, or nil if the skiplist is empty.
+func (s *skiplist[K, T]) first() *skiplistNode[K, T] {
+	return s.head.next[0].node
+}
+
+// last returns the node of the greatest key, or nil if the skiplist is empty.
+func (s *skiplist[K, T]) last() *skiplistNode[K, T] {
+	return s.tail
+}
+
+// ceiling returns the node of the smallest key greater or equal than the given key, or nil.
+func (s *skiplist[K, T]) ceiling(key K) *skiplistNode[K, T] {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && s.compare(x.next[i].node.key, key) < 0 {
+			x = x.next[i].node
+		}
+	}
+	return x.next[0].node
+}
+
+// floor returns the node of the greatest key less or equal than the given key, or nil.
+func (s *skiplist[K, T]) floor(key K) *skiplistNode[K, T] {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && s.compare(x.next[i].node.key, key) <= 0 {
+			x = x.next[i].node
+		}
+	}
+	if x == s.head {
+		return nil
+	}
+	return x
+}
+
+// rank returns the number of keys less than the given key.
+func (s *skiplist[K, T]) rank(key K) int {
+	rank := 0
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && s.compare(x.next[i].node.key, key) < 0 {
+			rank += x.next[i].span
+			x = x.next[i].node
+		}
+	}
+	return rank
+}
+
+// index returns the node at the zero based position i, or nil if it is out of range.
+func (s *skiplist[K, T]) index(i int) *skiplistNode[K, T] {
+	if i < 0 || i >= s.size {
+		return nil
+	}
+
+	traversed := 0
+	x := s.head
+	for l := s.level - 1; l >= 0; l-- {
+		for x.next[l].node != nil && traversed+x.next[l].span <= i+1 {
+			traversed += x.next[l].span
+			x = x.next[l].node
+		}
+		if traversed == i+1 {
+			return x
+		}
+	}
+	return nil
+}
@@ -7,11 +7,40 @@ import (
 	"sync/atomic"
 )
 
+type smapKeyValueOptions struct {
+	stats bool
+}
+
+// SMapKeyValueOptions are the options for SMapKeyValue container.
+type SMapKeyValueOptions func(*smapKeyValueOptions)
+
 // SMapKeyValue is a generic key-value store container that is thread-safe.
 // This use a golang native sync.Map data structure as underlying data structure.
+// The values are stored as pointers, so they can be compared and swapped atomically.
 type SMapKeyValue[K comparable, T any] struct {
+	// current holds the underlying map, replaced by Drain and Swap. Use data to read it.
+	current atomic.Pointer[sync.Map]
+
+	// count is the number of keys. It is incremented before a key is stored and decremented
+	// after it is removed, so it can be greater than the real size while a write is in progress
+	// but never smaller.
 	count atomic.Uint64
-	data  sync.Map
+
+	// mu is shared by the writers and held exclusively to commit the transactions,
+	// seq counts the writes to detect the conflicts of the transactions, and writing counts the
+	// writes in progress, which are not counted by seq yet.
+	mu      sync.RWMutex
+	seq     atomic.Uint64
+	writing atomic.Int64
+
+	// committing is true while a transaction is committed, so the reads wait for it to finish.
+	committing atomic.Bool
+
+	// hub notifies the changes to the watchers, created by the first Watch.
+	hub atomic.Pointer[watchHub[K, T]]
+
+	// stats counts the operations when the container was created using WithSMapStats.
+	stats *stats
 }
 
 // skv is a helper struct to sort the values of the SMapKeyValue container.
@@ -21,26 +50,39 @@ type skv[K comparable, T any] struct {
 }
 
 // NewSMapKeyValue returns a new SMapKeyValue container.
-func NewSMapKeyValue[K comparable, T any]() *SMapKeyValue[K, T] {
-	return &SMapKeyValue[K, T]{
-		data: sync.Map{},
+func NewSMapKeyValue[K comparable, T any](options ...SMapKeyValueOptions) *SMapKeyValue[K, T] {
+	kvo := smapKeyValueOptions{}
+	for _, opt := range options {
+		opt(&kvo)
 	}
+
+	kv := &SMapKeyValue[K, T]{
+		stats: newStats(kvo.stats),
+	}
+	kv.current.Store(&sync.Map{})
+
+	return kv
 }
 
 // Set sets the value associated with the key.
 func (r *SMapKeyValue[K, T]) Set(key K, value T) {
-	r.count.Add(1)
-	r.data.Store(key, value)
+	defer r.notify()
+
+	r.lockWrite()
+	defer r.unlockWrite()
+
+	r.set(key, value)
 }
 
 // GetAndCheck returns the value associated with the key if this exist also a
 // boolean value if this exist of not.
 func (r *SMapKeyValue[K, T]) GetAndCheck(key K) (T, bool) {
-	value, ok := r.data.Load(key)
+	value, ok := r.load(key)
+	r.stats.get(ok)
 
 	switch value := value.(type) {
-	case T:
-		return value, ok
+	case *T:
+		return *value, ok
 	default:
 		var t T
 		return t, ok
@@ -50,11 +92,12 @@ func (r *SMapKeyValue[K, T]) GetAndCheck(key K) (T, bool) {
 // Get returns the value associated with the key.
 // If the key does not exist, return zero value of the type.
 func (r *SMapKeyValue[K, T]) Get(key K) T {
-	value, _ := r.data.Load(key)
+	value, ok := r.load(key)
+	r.stats.get(ok)
 
 	switch value := value.(type) {
-	case T:
-		return value
+	case *T:
+		return *value
 	default:
 		var t T
 		return t
@@ -64,32 +107,32 @@ func (r *SMapKeyValue[K, T]) Get(key K) T {
 // GetAnDelete returns the value associated with the key and delete it if the key exist
 // if the key doesn't exist return the given key value false
 func (r *SMapKeyValue[K, T]) GetAnDelete(key K) (T, bool) {
-	value, ok := r.data.LoadAndDelete(key)
-	if ok {
-		r.count.Swap(r.count.Load() - 1)
+	defer r.notify()
 
-	}
+	r.lockWrite()
+	defer r.unlockWrite()
 
-	switch value := value.(type) {
-	case T:
-		return value, ok
-	default:
-		var t T
-		return t, ok
-	}
+	return r.delete(key)
 }
 
 // Delete deletes the value associated with the key.
 func (r *SMapKeyValue[K, T]) Delete(key K) {
-	if _, ok := r.data.LoadAndDelete(key); ok {
-		r.count.Swap(r.count.Load() - 1)
-	}
+	defer r.notify()
+
+	r.lockWrite()
+	defer r.unlockWrite()
+
+	r.delete(key)
 }
 
 // Clear deletes all key-value pairs stored in the container.
 func (r *SMapKeyValue[K, T]) Clear() {
-	r.data = sync.Map{}
-	r.count.Swap(0)
+	defer r.notify()
+
+	r.lockWrite()
+	defer r.unlockWrite()
+
+	r.reset()
 }
 
 // Size returns the number of key-value pairs stored in the container.
@@ -109,7 +152,7 @@ func (r *SMapKeyValue[K, T]) IsFull() bool {
 
 // ContainsKey returns true if the key is in the container.
 func (r *SMapKeyValue[K, T]) ContainsKey(key K) bool {
-	_, ok := r.data.Load(key)
+	_, ok := r.load(key)
 	return ok
 }
 
@@ -117,8 +160,8 @@ func (r *SMapKeyValue[K, T]) ContainsKey(key K) bool {
 func (r *SMapKeyValue[K, T]) ContainsValue(value T) bool {
 	var ret bool
 
-	r.data.Range(func(key, v any) bool {
-		if reflect.DeepEqual(v, value) {
+	r.data().Range(func(key, v any) bool {
+		if reflect.DeepEqual(*v.(*T), value) {
 			ret = true
 			return false
 		}
@@ -129,7 +172,7 @@ func (r *SMapKeyValue[K, T]) ContainsValue(value T) bool {
 
 // Get returns the key value associated with the key.
 func (r *SMapKeyValue[K, T]) Key(key K) K {
-	if _, ok := r.data.Load(key); ok {
+	if _, ok := r.load(key); ok {
 		return key
 	}
 	var empty K
@@ -139,7 +182,7 @@ func (r *SMapKeyValue[K, T]) Key(key K) K {
 // Keys returns all keys stored in the container.
 func (r *SMapKeyValue[K, T]) Keys() []K {
 	keys := make([]K, 0, r.Size())
-	r.data.Range(func(key, value any) bool {
+	r.data().Range(func(key, value any) bool {
 		keys = append(keys, key.(K))
 		return true
 	})
@@ -149,43 +192,49 @@ func (r *SMapKeyValue[K, T]) Keys() []K {
 // Values returns all values stored in the container.
 func (r *SMapKeyValue[K, T]) Values() []T {
 	values := make([]T, 0, r.Size())
-	r.data.Range(func(key, value any) bool {
-		values = append(values, value.(T))
+	r.data().Range(func(key, value any) bool {
+		values = append(values, *value.(*T))
 		return true
 	})
 	return values
 }
 
 // ForEach calls the given function for each key-value pair in the container.
+// The function is called on a snapshot of the container taken before the first call,
+// so it can modify the container without visiting the keys it sets.
 func (r *SMapKeyValue[K, T]) ForEach(fn func(key K, value T)) {
-	r.data.Range(func(key, value any) bool {
-		fn(key.(K), value.(T))
-		return true
-	})
+	for _, pair := range r.pairs() {
+		fn(pair.key, pair.value)
+	}
 }
 
 // ForEachKey calls the given function for each key in the container.
+// It has the same guarantees as ForEach.
 func (r *SMapKeyValue[K, T]) ForEachKey(fn func(key K)) {
-	r.data.Range(func(key, value any) bool {
-		fn(key.(K))
-		return true
-	})
+	for _, pair := range r.pairs() {
+		fn(pair.key)
+	}
 }
 
 // ForEachValue calls the given function for each value in the container.
+// It has the same guarantees as ForEach.
 func (r *SMapKeyValue[K, T]) ForEachValue(fn func(value T)) {
-	r.data.Range(func(key, value any) bool {
-		fn(value.(T))
-		return true
-	})
+	for _, pair := range r.pairs() {
+		fn(pair.value)
+	}
 }
 
 // Clone returns a new SMapKeyValue with a copy of the underlying data.
 func (r *SMapKeyValue[K, T]) Clone() *SMapKeyValue[K, T] {
 	clone := NewSMapKeyValue[K, T]()
 
-	r.data.Range(func(key, value any) bool {
-		clone.Set(key.(K), value.(T))
+	// count is never smaller than the real size, so the container is empty
+	if r.count.Load() == 0 {
+		return clone
+	}
+
+	r.data().Range(func(key, value any) bool {
+		clone.Set(key.(K), *value.(*T))
 		return true
 	})
 
@@ -193,16 +242,58 @@ func (r *SMapKeyValue[K, T]) Clone() *SMapKeyValue[K, T] {
 }
 
 // CloneAndClear returns a new SMapKeyValue with a copy of the underlying data and clears the container.
+// The writers wait until the container is cleared, so none is lost.
 func (r *SMapKeyValue[K, T]) CloneAndClear() *SMapKeyValue[K, T] {
-	clone := NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
-		clone.Set(key.(K), value.(T))
-		return true
-	})
-	r.Clear()
+	defer r.notify()
+
+	r.lock()
+	defer r.unlock()
+
+	clone := r.Clone()
+	r.reset()
 	return clone
 }
 
+// Drain atomically replaces the underlying data of the container with an empty map and returns a new
+// SMapKeyValue holding the previous one, without copying it. Every concurrent write is either in the
+// returned container or in the container, so none is lost.
+// The writers wait until the map is replaced, the readers don't. The returned container doesn't
+// use the options of the container, so it doesn't count the operations even if the container was
+// created using WithSMapStats.
+func (r *SMapKeyValue[K, T]) Drain() *SMapKeyValue[K, T] {
+	defer r.notify()
+
+	r.lock()
+	defer r.unlock()
+
+	return r.replace(&sync.Map{}, 0)
+}
+
+// Swap atomically replaces the key-value pairs of the container with a copy of the given newData and
+// returns a new SMapKeyValue holding the previous ones, like Drain.
+func (r *SMapKeyValue[K, T]) Swap(newData map[K]T) *SMapKeyValue[K, T] {
+	defer r.notify()
+
+	data := &sync.Map{}
+	for key, value := range newData {
+		data.Store(key, &value)
+	}
+
+	r.lock()
+	defer r.unlock()
+
+	old := r.replace(data, len(newData))
+	for key, value := range newData {
+		r.stats.set()
+		r.publishSet(key, nil, false, value)
+	}
+	if len(newData) > 0 {
+		r.seq.Add(1)
+	}
+
+	return old
+}
+
 // DeepEqual returns true if the given kv is deep equal to the SMapKeyValue container
 func (r *SMapKeyValue[K, T]) DeepEqual(kv *SMapKeyValue[K, T]) bool {
 	if r.Size() != kv.Size() {
@@ -213,15 +304,10 @@ func (r *SMapKeyValue[K, T]) DeepEqual(kv *SMapKeyValue[K, T]) bool {
 	}
 
 	var ret bool
-	r.data.Range(func(key, value any) bool {
+	r.data().Range(func(key, value any) bool {
 		kk, ok := kv.GetAndCheck(key.(K))
-		if !ok {
-			ret = false
-			return false
-		} else {
-			ret = reflect.DeepEqual(kk, value.(T))
-			return true
-		}
+		ret = ok && reflect.DeepEqual(kk, *value.(*T))
+		return ret
 	})
 
 	return ret
@@ -230,8 +316,8 @@ func (r *SMapKeyValue[K, T]) DeepEqual(kv *SMapKeyValue[K, T]) bool {
 // Map returns a new SMapKeyValue after applying the given function fn to each key-value pair.
 func (r *SMapKeyValue[K, T]) Map(fn func(key K, value T) (newKey K, newValue T)) *SMapKeyValue[K, T] {
 	m := NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
-		newKey, newValue := fn(key.(K), value.(T))
+	r.data().Range(func(key, value any) bool {
+		newKey, newValue := fn(key.(K), *value.(*T))
 		m.Set(newKey, newValue)
 		return true
 	})
@@ -242,9 +328,9 @@ func (r *SMapKeyValue[K, T]) Map(fn func(key K, value T) (newKey K, newValue T))
 // MapKey returns a new SMapKeyValue after applying the given function fn to each key.
 func (r *SMapKeyValue[K, T]) MapKey(fn func(key K) K) *SMapKeyValue[K, T] {
 	m := NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
+	r.data().Range(func(key, value any) bool {
 		newKey := fn(key.(K))
-		m.Set(newKey, value.(T))
+		m.Set(newKey, *value.(*T))
 		return true
 	})
 	return m
@@ -253,8 +339,8 @@ func (r *SMapKeyValue[K, T]) MapKey(fn func(key K) K) *SMapKeyValue[K, T] {
 // MapValue returns a new SMapKeyValue after applying the given function fn to each value.
 func (r *SMapKeyValue[K, T]) MapValue(fn func(value T) T) *SMapKeyValue[K, T] {
 	m := NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
-		newValue := fn(value.(T))
+	r.data().Range(func(key, value any) bool {
+		newValue := fn(*value.(*T))
 		m.Set(key.(K), newValue)
 		return true
 	})
@@ -264,9 +350,9 @@ func (r *SMapKeyValue[K, T]) MapValue(fn func(value T) T) *SMapKeyValue[K, T] {
 // Filter returns a new SMapKeyValue after applying the given function fn to each key-value pair.
 func (r *SMapKeyValue[K, T]) Filter(fn func(key K, value T) bool) *SMapKeyValue[K, T] {
 	m := NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
-		if fn(key.(K), value.(T)) {
-			m.Set(key.(K), value.(T))
+	r.data().Range(func(key, value any) bool {
+		if fn(key.(K), *value.(*T)) {
+			m.Set(key.(K), *value.(*T))
 		}
 		return true
 	})
@@ -276,9 +362,9 @@ func (r *SMapKeyValue[K, T]) Filter(fn func(key K, value T) bool) *SMapKeyValue[
 // FilterKey returns a new SMapKeyValue after applying the given function fn to each key.
 func (r *SMapKeyValue[K, T]) FilterKey(fn func(key K) bool) *SMapKeyValue[K, T] {
 	m := NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
+	r.data().Range(func(key, value any) bool {
 		if fn(key.(K)) {
-			m.Set(key.(K), value.(T))
+			m.Set(key.(K), *value.(*T))
 		}
 		return true
 	})
@@ -288,9 +374,9 @@ func (r *SMapKeyValue[K, T]) FilterKey(fn func(key K) bool) *SMapKeyValue[K, T]
 // FilterValue returns a new SMapKeyValue after applying the given function fn to each value.
 func (r *SMapKeyValue[K, T]) FilterValue(fn func(value T) bool) *SMapKeyValue[K, T] {
 	m := NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
-		if fn(value.(T)) {
-			m.Set(key.(K), value.(T))
+	r.data().Range(func(key, value any) bool {
+		if fn(*value.(*T)) {
+			m.Set(key.(K), *value.(*T))
 		}
 		return true
 	})
@@ -302,11 +388,11 @@ func (r *SMapKeyValue[K, T]) FilterValue(fn func(value T) bool) *SMapKeyValue[K,
 func (r *SMapKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, others *SMapKeyValue[K, T]) {
 	match = NewSMapKeyValue[K, T]()
 	others = NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
-		if fn(key.(K), value.(T)) {
-			match.Set(key.(K), value.(T))
+	r.data().Range(func(key, value any) bool {
+		if fn(key.(K), *value.(*T)) {
+			match.Set(key.(K), *value.(*T))
 		} else {
-			others.Set(key.(K), value.(T))
+			others.Set(key.(K), *value.(*T))
 		}
 		return true
 	})
@@ -319,11 +405,11 @@ func (r *SMapKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, oth
 func (r *SMapKeyValue[K, T]) PartitionKey(fn func(key K) bool) (match, others *SMapKeyValue[K, T]) {
 	match = NewSMapKeyValue[K, T]()
 	others = NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
+	r.data().Range(func(key, value any) bool {
 		if fn(key.(K)) {
-			match.Set(key.(K), value.(T))
+			match.Set(key.(K), *value.(*T))
 		} else {
-			others.Set(key.(K), value.(T))
+			others.Set(key.(K), *value.(*T))
 		}
 		return true
 	})
@@ -336,11 +422,11 @@ func (r *SMapKeyValue[K, T]) PartitionKey(fn func(key K) bool) (match, others *S
 func (r *SMapKeyValue[K, T]) PartitionValue(fn func(value T) bool) (match, others *SMapKeyValue[K, T]) {
 	match = NewSMapKeyValue[K, T]()
 	others = NewSMapKeyValue[K, T]()
-	r.data.Range(func(key, value any) bool {
-		if fn(value.(T)) {
-			match.Set(key.(K), value.(T))
+	r.data().Range(func(key, value any) bool {
+		if fn(*value.(*T)) {
+			match.Set(key.(K), *value.(*T))
 		} else {
-			others.Set(key.(K), value.(T))
+			others.Set(key.(K), *value.(*T))
 		}
 		return true
 	})
@@ -366,8 +452,8 @@ func (r *SMapKeyValue[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
 // SortValues returns a []*T (values) after sorting the values using given function sortFn.
 func (r *SMapKeyValue[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
 	kvs := make([]*skv[K, T], 0, r.Size())
-	r.data.Range(func(key, value any) bool {
-		kvs = append(kvs, &skv[K, T]{key.(K), value.(T)})
+	r.data().Range(func(key, value any) bool {
+		kvs = append(kvs, &skv[K, T]{key.(K), *value.(*T)})
 		return true
 	})
 
@@ -382,3 +468,177 @@ func (r *SMapKeyValue[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T
 
 	return m
 }
+
+// pairs returns a copy of the key-value pairs of the container.
+// It has the same guarantees as sync.Map.Range.
+func (r *SMapKeyValue[K, T]) pairs() []skv[K, T] {
+	pairs := make([]skv[K, T], 0, r.Size())
+	r.data().Range(func(key, value any) bool {
+		pairs = append(pairs, skv[K, T]{key.(K), *value.(*T)})
+		return true
+	})
+	return pairs
+}
+
+// load returns the entry of the key. The read waits for the transaction being committed, if any,
+// so the reads never see part of its changes.
+func (r *SMapKeyValue[K, T]) load(key K) (any, bool) {
+	if r.committing.Load() {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+	}
+	return r.data().Load(key)
+}
+
+// set stores the key-value pair and notifies the watchers.
+// The caller must hold the lock, shared or exclusive.
+func (r *SMapKeyValue[K, T]) set(key K, value T) {
+	r.count.Add(1)
+
+	old, loaded := r.data().Swap(key, &value)
+	if loaded {
+		// the key was overwritten
+		r.count.Add(^uint64(0))
+	}
+	r.seq.Add(1)
+	r.stats.set()
+	r.publishSet(key, old, loaded, value)
+}
+
+// publishSet queues the OpSet event for the watchers. old is the previous entry of the key.
+// The event is queued after the key is stored, so the concurrent writers of the same key can
+// queue their events in a different order than their changes, as documented by Watch.
+func (r *SMapKeyValue[K, T]) publishSet(key K, old any, loaded bool, value T) {
+	h := r.hub.Load()
+	if !h.watched() {
+		return
+	}
+
+	var oldValue T
+	if loaded {
+		oldValue = *old.(*T)
+	}
+	h.publish(Event[K, T]{Op: OpSet, Key: key, OldValue: oldValue, HasOldValue: loaded, NewValue: value})
+}
+
+// delete removes the key-value pair and notifies the watchers.
+// The caller must hold the lock, shared or exclusive.
+func (r *SMapKeyValue[K, T]) delete(key K) (T, bool) {
+	value, ok := r.data().LoadAndDelete(key)
+	if ok {
+		r.count.Add(^uint64(0))
+		r.seq.Add(1)
+		r.stats.remove(OpDelete)
+	}
+
+	switch value := value.(type) {
+	case *T:
+		r.publish(Event[K, T]{Op: OpDelete, Key: key, OldValue: *value, HasOldValue: true})
+		return *value, ok
+	default:
+		var t T
+		return t, ok
+	}
+}
+
+// reset removes all the key-value pairs and notifies the watchers.
+// The keys are deleted one by one, so the concurrent writers are not lost: a key stored
+// while the container is cleared is either removed or kept, and counted accordingly.
+// The caller must hold the lock, shared or exclusive.
+func (r *SMapKeyValue[K, T]) reset() {
+	// count is never smaller than the real size, so the container is empty
+	if r.count.Load() == 0 {
+		return
+	}
+
+	// clearing an empty container is not a write, so it doesn't conflict with the transactions
+	removed := 0
+	r.data().Range(func(key, _ any) bool {
+		value, ok := r.data().LoadAndDelete(key)
+		if !ok {
+			// deleted concurrently
+			return true
+		}
+
+		removed++
+		r.count.Add(^uint64(0))
+		r.publish(Event[K, T]{Op: OpClear, Key: key.(K), OldValue: *value.(*T), HasOldValue: true})
+		return true
+	})
+
+	r.stats.delete(removed)
+	if removed > 0 {
+		r.seq.Add(1)
+	}
+}
+
+// lockWrite acquires the lock shared by the writers and counts the write in progress.
+func (r *SMapKeyValue[K, T]) lockWrite() {
+	r.mu.RLock()
+	r.writing.Add(1)
+}
+
+// unlockWrite releases the lock acquired by lockWrite.
+func (r *SMapKeyValue[K, T]) unlockWrite() {
+	r.writing.Add(-1)
+	r.mu.RUnlock()
+}
+
+// lock acquires the exclusive lock to write while there is no other write in progress.
+func (r *SMapKeyValue[K, T]) lock() {
+	r.mu.Lock()
+	r.writing.Add(1)
+}
+
+// unlock releases the lock acquired by lock.
+func (r *SMapKeyValue[K, T]) unlock() {
+	r.writing.Add(-1)
+	r.mu.Unlock()
+}
+
+// data returns the underlying map, creating it if the container was not created using NewSMapKeyValue.
+func (r *SMapKeyValue[K, T]) data() *sync.Map {
+	if m := r.current.Load(); m != nil {
+		return m
+	}
+	r.current.CompareAndSwap(nil, &sync.Map{})
+	return r.current.Load()
+}
+
+// replace replaces the underlying map with data, holding size key-value pairs, and returns a new
+// SMapKeyValue holding the previous one. The removed key-value pairs are notified to the watchers.
+// The caller must hold the exclusive lock, so there is no write in progress.
+func (r *SMapKeyValue[K, T]) replace(data *sync.Map, size int) *SMapKeyValue[K, T] {
+	old := NewSMapKeyValue[K, T]()
+	old.current.Store(r.data())
+	old.count.Store(r.count.Load())
+
+	r.current.Store(data)
+	r.count.Store(uint64(size))
+
+	removed := old.Size()
+	if r.hub.Load().watched() {
+		old.data().Range(func(key, value any) bool {
+			r.publish(Event[K, T]{Op: OpClear, Key: key.(K), OldValue: *value.(*T), HasOldValue: true})
+			return true
+		})
+	}
+
+	r.stats.delete(removed)
+	if removed > 0 {
+		r.seq.Add(1)
+	}
+
+	return old
+}
+
+// publish queues the event for the watchers.
+func (r *SMapKeyValue[K, T]) publish(ev Event[K, T]) {
+	r.hub.Load().publish(ev)
+}
+
+// notify delivers the queued events to the watchers.
+// This must be called without holding the lock.
+func (r *SMapKeyValue[K, T]) notify() {
+	r.hub.Load().flush()
+}
@@ -5,9 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
-	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -178,1625 +176,220 @@ func TestNewSMapKeyValue(t *testing.T) {
 	})
 }
 
-func TestSet_SMapKeyValue(t *testing.T) {
-	t.Run("test Set for NewSMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-	})
-}
-
-func TestGetAndCheck_SMapKeyValue(t *testing.T) {
-	t.Run("test GetCheck for NewSMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value, ok := kv.GetAndCheck("Archimedes")
-		if !ok {
-			t.Errorf("Expected GetCheck to return true, got %v", ok)
-		}
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-	})
-
-	t.Run("test GetCheck for NewSMapKeyValue[string, struct] key doesn't exist", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		_, ok := kv.GetAndCheck("Euler")
-		if ok {
-			t.Errorf("Expected GetCheck to return true, got %v", ok)
-		}
-	})
-}
-
-func TestGet_SMapKeyValue(t *testing.T) {
-	t.Run("test Get for NewSMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-	})
-
-	t.Run("test Get for NewSMapKeyValue[string, struct] key doesn't exist", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Euler")
-		if value.value != 0 {
-			t.Errorf("Expected value to be %v, got %v", nil, value)
-		}
-	})
-}
-
-func TestGetAnDelete_SMapKeyValue(t *testing.T) {
-	t.Run("test GetAnDelete for NewSMapKeyValue[string, struct] key exist", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		value, ok := kv.GetAnDelete("Archimedes")
-		if !ok {
-			t.Errorf("Expected GetAnDelete to return true, got %v", ok)
-		}
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-
-		if kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
-		}
-	})
-
-	t.Run("test GetAnDelete for NewSMapKeyValue[string, struct] key doesn't exist", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value, ok := kv.GetAnDelete("Euler")
-		if ok {
-			t.Errorf("Expected GetAnDelete to return true, got %v", ok)
-		}
-
-		if value.value != 0 {
-			t.Errorf("Expected value to be %v, got %v", nil, value)
-		}
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-	})
-}
-
-func TestDelete_SMapKeyValue(t *testing.T) {
-	t.Run("test Delete for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-
-		kv.Delete("Archimedes")
-
-		if kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
-		}
-
-		value = kv.Get("Golden Ratio")
-
-		if value.Name != "This is The Golden Ratio" {
-			t.Errorf("Expected value to be %s, got %s", "This is The Golden Ratio", value.Name)
-		}
-		if value.value != 1.6180 {
-			t.Errorf("Expected value to be %v, got %v", 1.6180, value.value)
-		}
-	})
-
-	t.Run("test Delete for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		kv.Delete("Archimedes")
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-}
-
-func TestClear_SMapKeyValue(t *testing.T) {
-	t.Run("test Clear for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		value := kv.Get("Archimedes")
-
-		if value.Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected value to be %s, got %s", "This is Archimedes' Constant (Pi)", value.Name)
-		}
-		if value.value != 3.1415 {
-			t.Errorf("Expected value to be %v, got %v", 3.1415, value.value)
-		}
-
-		kv.Clear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-
-	t.Run("test Clear for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		kv.Clear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-}
-
-func TestSize_SMapKeyValue(t *testing.T) {
-	t.Run("test Size for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.Delete("Archimedes")
-
-		if kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, kv.Size())
-		}
-
-		kv.Delete("Euler")
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		kv.Clear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-
-	t.Run("test Size for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		kv.Delete("Euler")
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-	})
-}
-
-func TestIsEmpty_SMapKeyValue(t *testing.T) {
-	t.Run("test IsEmpty for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		if kv.IsEmpty() != false {
-			t.Errorf("Expected IsEmpty to be %v, got %v", false, kv.IsEmpty())
-		}
-
-		kv.Clear()
-
-		if kv.IsEmpty() != true {
-			t.Errorf("Expected IsEmpty to be %v, got %v", true, kv.IsEmpty())
-		}
-	})
-}
-
-func TestIsFull_SMapKeyValue(t *testing.T) {
-	t.Run("test IsFull for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		if kv.IsFull() != true {
-			t.Errorf("Expected IsFull to be %v, got %v", false, kv.IsFull())
-		}
-
-		kv.Clear()
-
-		if kv.IsFull() != false {
-			t.Errorf("Expected IsFull to be %v, got %v", true, kv.IsFull())
-		}
-	})
-}
-
-func TestContainsKey_SMapKeyValue(t *testing.T) {
-	t.Run("test ContainsKey for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		if kv.ContainsKey("Archimedes") != true {
-			t.Errorf("Expected key to be %v, got %v", true, kv.ContainsKey("Archimedes"))
-		}
-
-		if kv.ContainsKey("Do not exist") != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsKey("Do not exist"))
-		}
-	})
-
-	t.Run("test ContainsKey for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		if kv.ContainsKey("Do not exist") != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsKey("Do not exist"))
-		}
-	})
-}
-
-func TestContainsValue_SMapKeyValue(t *testing.T) {
-	t.Run("test ContainsValue for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv.Size())
-		}
-
-		if kv.ContainsValue(STestStruct{"This is Archimedes' Constant (Pi)", 3.1415}) != true {
-			t.Errorf("Expected key to be %v, got %v", true, kv.ContainsValue(STestStruct{"This is Archimedes' Constant (Pi)", 3.1415}))
-		}
-
-		if kv.ContainsValue(STestStruct{"This is other constant", 0.00000}) != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsValue(STestStruct{"This is other constant", 0.00000}))
-		}
-	})
-
-	t.Run("test ContainsValue for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		if kv.ContainsValue(STestStruct{"This is other constant", 0.00000}) != false {
-			t.Errorf("Expected key to be %v, got %v", false, kv.ContainsValue(STestStruct{"This is other constant", 0.00000}))
-		}
-	})
-}
-
-func TestKey_SMapKeyValue(t *testing.T) {
-	t.Run("test Key for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		if kv.Key("Archimedes") != "Archimedes" {
-			t.Errorf("Expected key to be %v, got %v", "Archimedes", kv.Key("Archimedes"))
-		}
-
-		if kv.Key("Do Not Exist") != "" {
-			t.Errorf("Expected key to be %v, got %v", "Archimedes", kv.Key("Do Not Exist"))
-		}
-	})
-}
-
-func TestKeys_SMapKeyValue(t *testing.T) {
-	t.Run("test Keys for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		keys := kv.Keys()
-
-		if len(keys) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(keys))
-		}
-
-		for _, key := range keys {
-			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be %v, got %v", true, key)
-			}
-		}
-	})
-
-	t.Run("test Keys for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		keys := kv.Keys()
-
-		if len(keys) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(keys))
-		}
-	})
-}
-
-func TestValues_SMapKeyValue(t *testing.T) {
-	t.Run("test Values for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		values := kv.Values()
-
-		if len(values) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(values))
-		}
-
-		for _, val := range values {
-			if val.Name != "This is Archimedes' Constant (Pi)" && val.Name != "This is Euler's Number (e)" && val.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value to be %v, got %v", true, val)
-			}
-
-			if val.value != 3.1415 && val.value != 2.7182 && val.value != 1.6180 {
-				t.Errorf("Expected value to be %v, got %v", true, val)
-			}
-		}
-	})
-
-	t.Run("test Values for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		values := kv.Values()
-
-		if len(values) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(values))
-		}
-	})
-}
-
-func TestEach_SMapKeyValue(t *testing.T) {
-	t.Run("test Each for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.ForEach(func(key string, value STestStruct) {
-			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be %v, got %v", true, key)
-			}
-
-			if value.Name != "This is Archimedes' Constant (Pi)" && value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-
-			if value.value != 3.1415 && value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-		})
-	})
-
-	t.Run("test Each for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.ForEach(func(key string, value STestStruct) {
-			t.Errorf("Expected Each to not be called, got %v", true)
-		})
-	})
-}
-
-func TestEachKey_SMapKeyValue(t *testing.T) {
-	t.Run("test EachKey for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.ForEachKey(func(key string) {
-			if key != "Archimedes" && key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be %v, got %v", true, key)
-			}
-		})
-	})
-
-	t.Run("test EachKey for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.ForEachKey(func(key string) {
-			t.Errorf("Expected EachKey to not be called, got %v", true)
-		})
-	})
-}
-
-func TestEachValue_SMapKeyValue(t *testing.T) {
-	t.Run("test EachValue for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kv.ForEachValue(func(value STestStruct) {
-			if value.Name != "This is Archimedes' Constant (Pi)" && value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-
-			if value.value != 3.1415 && value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value to be %v, got %v", true, value)
-			}
-		})
-	})
-
-	t.Run("test EachValue for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.ForEachValue(func(value STestStruct) {
-			t.Errorf("Expected EachValue to not be called, got %v", true)
-		})
-	})
-}
-
-func TestClone_SMapKeyValue(t *testing.T) {
-	t.Run("test Clone for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kvClone := kv.Clone()
-
-		if kvClone.Size() != kv.Size() {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		kvKeys := kv.Keys()
-		kvCloneKeys := kvClone.Keys()
-
-		sort.Strings(kvKeys)
-		sort.Strings(kvCloneKeys)
-
-		if reflect.DeepEqual(kvKeys, kvCloneKeys) == false {
-			t.Errorf("Expected keys to be equal, got %v", true)
-		}
-
-		kvSortedValues := kv.SortValues(func(value1, value2 STestStruct) bool {
-			return value1.value < value2.value
-		})
-		kvCloneSortedValues := kvClone.SortValues(func(value1, value2 STestStruct) bool {
-			return value1.value < value2.value
-		})
-
-		if reflect.DeepEqual(kvSortedValues, kvCloneSortedValues) == false {
-			t.Errorf("Expected values to be equal, got %v", true)
-		}
-
-		for _, kvValue := range kvSortedValues {
-			if kvClone.ContainsValue(*kvValue) == false {
-				t.Errorf("Expected Clone to contain value, got %v", true)
-			}
-		}
-	})
-
-	t.Run("test Clone for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kvClone := kv.Clone()
-
-		if kvClone.Size() != kv.Size() {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		if reflect.DeepEqual(kv, kvClone) == false {
-			t.Errorf("Expected Clone to be equal to original, got %v", true)
-		}
-	})
-}
-
-func TestCloneAndClear_SMapKeyValue(t *testing.T) {
-	t.Run("test CloneAndClear for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kvClone := kv.CloneAndClear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		if kvClone.Size() == kv.Size() {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		if reflect.DeepEqual(kv, kvClone) == true {
-			t.Errorf("Expected Clone to be not equal to original, got %v", true)
-		}
-
-		kvKeys := kv.Keys()
-		kvCloneKeys := kvClone.Keys()
-
-		sort.Strings(kvKeys)
-		sort.Strings(kvCloneKeys)
-
-		if reflect.DeepEqual(kvKeys, kvCloneKeys) == true {
-			t.Errorf("Expected keys to be not equal, got %v", true)
-		}
-	})
-
-	t.Run("test CloneAndClear for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kvClone := kv.CloneAndClear()
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-
-		if kvClone.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 3, kvClone.Size())
-		}
-
-		if reflect.DeepEqual(kv, kvClone) == false {
-			t.Errorf("Expected Clone to be equal to original, got %v", true)
-		}
-	})
-}
-
-func TestDeepEqual_SMapKeyValue(t *testing.T) {
-	t.Run("test DeepEqual for NewSMapKeyValue[string, struct] with keys disordered and same size", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewSMapKeyValue[string, STestStruct]()
-		kv2 := NewSMapKeyValue[string, STestStruct]()
-
-		kv1.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv1.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv1.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		kv2.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv2.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-		kv2.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv1.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
-		}
-		if kv2.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", kv1.DeepEqual(kv2))
-		}
-
-		if kv2.DeepEqual(kv1) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", kv2.DeepEqual(kv1))
-		}
-	})
-
-	t.Run("test DeepEqual for NewSMapKeyValue[string, struct] with keys and it is different same size", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewSMapKeyValue[string, STestStruct]()
-		kv2 := NewSMapKeyValue[string, STestStruct]()
-
-		kv1.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv1.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv1.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		kv2.Set("key 1", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv2.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv2.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv1.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
-		}
-		if kv2.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == true {
-			t.Errorf("Expected DeepEqual to be different, got %v", kv1.DeepEqual(kv2))
-		}
-
-		if kv2.DeepEqual(kv1) == true {
-			t.Errorf("Expected DeepEqual to be different, got %v", kv2.DeepEqual(kv1))
-		}
-	})
-
-	t.Run("test DeepEqual for NewSMapKeyValue[string, struct] with keys and it is different and different size", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewSMapKeyValue[string, STestStruct]()
-		kv2 := NewSMapKeyValue[string, STestStruct]()
-
-		kv1.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv1.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv1.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		kv2.Set("key 1", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-
-		if kv1.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv1.Size())
-		}
-		if kv2.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == true {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-
-		if kv2.DeepEqual(kv1) == true {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-	})
-
-	t.Run("test DeepEqual for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv1 := NewSMapKeyValue[string, STestStruct]()
-		kv2 := NewSMapKeyValue[string, STestStruct]()
-
-		if kv1.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv1.Size())
-		}
-		if kv2.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv2.Size())
-		}
-
-		if kv1.DeepEqual(kv2) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-		if kv2.DeepEqual(kv1) == false {
-			t.Errorf("Expected DeepEqual to be equal, got %v", true)
-		}
-	})
-}
-
-func TestMap_SMapKeyValue(t *testing.T) {
-	t.Run("test Map for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.Map(func(key string, value STestStruct) (newKey string, newValue STestStruct) {
-			newKey = key
-			newValue.Name = strings.ToUpper(value.Name)
-			newValue.value = value.value * 2
-			return
-		})
-
-		newKv.ForEach(func(key string, value STestStruct) {
-			if kv.Key(key) != key {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", strings.ToUpper(key), key)
-			}
-			if strings.ToUpper(kv.Get(key).Name) != value.Name {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", strings.ToUpper(kv.Get(key).Name), value.Name)
-			}
-			if kv.Get(key).value*2 != value.value {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", value.value*2, value.value)
-			}
-		})
-	})
-
-	t.Run("test Map for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		newKv := kv.Map(func(key string, value STestStruct) (newKey string, newValue STestStruct) {
-			newKey = strings.ToUpper(key)
-			newValue = value
-			return
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
+func TestConcurrentSize_SMapKeyValue(t *testing.T) {
+	const (
+		goroutines = 16
+		operations = 2000
+		keys       = 64
+	)
 
-func TestMapKey_SMapKeyValue(t *testing.T) {
-	t.Run("test MapKey for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.MapKey(func(key string) string {
-			return strings.ToUpper(key)
-		})
-
-		newKv.ForEach(func(key string, value STestStruct) {
-			if strings.ToUpper(kv.Key(strings.Title(strings.ToLower(key)))) != key {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(strings.Title(strings.ToLower(key))), key)
-			}
-			if kv.Get(strings.Title(strings.ToLower(key))).Name != value.Name {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", kv.Get(strings.Title(strings.ToLower(key))).Name, value.Name)
-			}
-			if kv.Get(strings.Title(strings.ToLower(key))).value != value.value {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(strings.Title(strings.ToLower(key))).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test MapKey for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		newKv := kv.MapKey(func(key string) string {
-			return strings.ToUpper(key)
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
-
-func TestMapValue_SMapKeyValue(t *testing.T) {
-	t.Run("test MapValue for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.MapValue(func(value STestStruct) STestStruct {
-			value.Name = strings.ToUpper(value.Name)
-			value.value = value.value * 2
-			return value
-		})
-
-		newKv.ForEach(func(key string, value STestStruct) {
-			if kv.Key(key) != key {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if strings.ToUpper(kv.Get(key).Name) != value.Name {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", kv.Get(key).Name, value.Name)
-			}
-			if kv.Get(key).value*2 != value.value {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test MapValue for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		newKv := kv.MapValue(func(value STestStruct) STestStruct {
-			value.Name = strings.ToUpper(value.Name)
-			value.value = value.value * 2
-			return value
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
-
-func TestFilter_SMapKeyValue(t *testing.T) {
-	t.Run("test Filter for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.Filter(func(key string, value STestStruct) bool {
-			return strings.Contains(value.Name, "Constant")
-		})
-
-		newKv.ForEach(func(key string, value STestStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test Filter for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		newKv := kv.Filter(func(key string, value STestStruct) bool {
-			return strings.Contains(value.Name, "Constant")
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
-	})
-}
-
-func TestFilterKey_SMapKeyValue(t *testing.T) {
-	t.Run("test FilterKey for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		newKv := kv.FilterKey(func(key string) bool {
-			return strings.Contains(key, "chime")
-		})
+	// ranged returns the number of keys found iterating the container.
+	ranged := func(kv *SMapKeyValue[int, int]) int {
+		n := 0
+		kv.ForEachKey(func(key int) { n++ })
+		return n
+	}
 
-		newKv.ForEach(func(key string, value STestStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
+	t.Run("test Size with concurrent overwrites", func(t *testing.T) {
+		kv := NewSMapKeyValue[int, int]()
 
-	t.Run("test FilterKey for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < operations; i++ {
+					kv.Set(i%keys, i)
+				}
+			}()
 		}
-		kv := NewSMapKeyValue[string, STestStruct]()
+		wg.Wait()
 
-		newKv := kv.FilterKey(func(key string) bool {
-			return strings.Contains(key, "chime")
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
+		if kv.Size() != keys {
+			t.Errorf("Expected size to be %v, got %v", keys, kv.Size())
 		}
 	})
-}
-
-func TestFilterValue_SMapKeyValue(t *testing.T) {
-	t.Run("test FilterValue for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
 
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
+	t.Run("test Size with concurrent writes and deletes", func(t *testing.T) {
+		kv := NewSMapKeyValue[int, int]()
 
-		newKv := kv.FilterValue(func(value STestStruct) bool {
-			return value.value > 3
-		})
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < operations; i++ {
+					key := (g*operations + i) % keys
+					switch i % 6 {
+					case 0:
+						kv.Set(key, i)
+					case 1:
+						kv.Delete(key)
+					case 2:
+						kv.GetAnDelete(key)
+					case 3:
+						kv.GetOrSet(key, i)
+					case 4:
+						kv.Compute(key, func(old int, exists bool) (int, ComputeAction) {
+							if exists {
+								return 0, ComputeDelete
+							}
+							return i, ComputeSet
+						})
+					case 5:
+						kv.CompareAndDelete(key, i, func(a, b int) bool { return true })
+					}
+
+					if size := kv.Size(); size < 0 || size > keys+goroutines {
+						t.Errorf("Expected size to be between %v and %v, got %v", 0, keys+goroutines, size)
+						return
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if kv.Size() != ranged(kv) {
+			t.Errorf("Expected size to be %v, got %v", ranged(kv), kv.Size())
+		}
+	})
+
+	t.Run("test Size with concurrent writes and Clear", func(t *testing.T) {
+		kv := NewSMapKeyValue[int, int]()
 
-		if newKv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, newKv.Size())
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < operations; i++ {
+					if g == 0 && i%100 == 0 {
+						kv.Clear()
+						continue
+					}
+					kv.Set((g*operations+i)%keys, i)
+				}
+			}(g)
 		}
+		wg.Wait()
 
-		newKv.ForEach(func(key string, value STestStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test FilterValue for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
+		if kv.Size() != ranged(kv) {
+			t.Errorf("Expected size to be %v, got %v", ranged(kv), kv.Size())
 		}
-		kv := NewSMapKeyValue[string, STestStruct]()
 
-		newKv := kv.FilterValue(func(value STestStruct) bool {
-			return value.value > 3
-		})
-
-		if kv.Size() != 0 {
+		kv.Clear()
+		if kv.Size() != 0 || !kv.IsEmpty() {
 			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
 		}
-		if newKv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, newKv.Size())
-		}
 	})
 }
 
-func TestPartition_SMapKeyValue(t *testing.T) {
-	t.Run("test Partition for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
+func TestDrain_SMapKeyValue(t *testing.T) {
+	t.Run("test Drain doesn't lose the concurrent writes", func(t *testing.T) {
+		const writers, writes = 8, 1000
+		kv := NewSMapKeyValue[int, int]()
 
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < writes; i++ {
+					kv.Set(w*writes+i, i)
+				}
+			}(w)
 		}
 
-		grp1Kv, grp2Kv := kv.Partition(func(key string, value STestStruct) bool {
-			return value.value > 3
-		})
+		stop := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(stop)
+		}()
 
-		if grp1Kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
+		seen := make(map[int]int)
+		collect := func(drained *SMapKeyValue[int, int]) {
+			drained.ForEachKey(func(key int) {
+				seen[key]++
+			})
 		}
-		if grp2Kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
-		}
-
-		grp1Kv.ForEach(func(key string, value STestStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
+		for done := false; !done; {
+			select {
+			case <-stop:
+				done = true
+			default:
 			}
-		})
-
-		grp2Kv.ForEach(func(key string, value STestStruct) {
-			if key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
-			}
-			if value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test Partition for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
+			collect(kv.Drain())
 		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		grp1Kv, grp2Kv := kv.Partition(func(key string, value STestStruct) bool {
-			return value.value > 3
-		})
 
-		if kv.Size() != 0 {
+		if !kv.IsEmpty() {
 			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
 		}
-		if grp1Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
-		}
-
-		if grp2Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
-		}
-	})
-}
-
-func TestPartitionKey_SMapKeyValue(t *testing.T) {
-	t.Run("test PartitionKey for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		grp1Kv, grp2Kv := kv.PartitionKey(func(key string) bool {
-			return key == "Archimedes"
-		})
-
-		if grp1Kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
+		if len(seen) != writers*writes {
+			t.Errorf("Expected drained keys to be %v, got %v", writers*writes, len(seen))
 		}
-		if grp2Kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
-		}
-
-		grp1Kv.ForEach(func(key string, value STestStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-
-		grp2Kv.ForEach(func(key string, value STestStruct) {
-			if key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
+		for key, n := range seen {
+			if n != 1 {
+				t.Errorf("Expected key %v to be drained %v time, got %v", key, 1, n)
 			}
-			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
-			}
-			if value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-	})
-
-	t.Run("test PartitionKey for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		grp1Kv, grp2Kv := kv.PartitionKey(func(key string) bool {
-			return key == "Archimedes"
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if grp1Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
-		}
-
-		if grp2Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
-		}
-	})
-}
-
-func TestPartitionValue_SMapKeyValue(t *testing.T) {
-	t.Run("test PartitionValue for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		grp1Kv, grp2Kv := kv.PartitionValue(func(value STestStruct) bool {
-			return value.value > 3
-		})
-
-		if grp1Kv.Size() != 1 {
-			t.Errorf("Expected size to be %v, got %v", 1, grp1Kv.Size())
 		}
-		if grp2Kv.Size() != 2 {
-			t.Errorf("Expected size to be %v, got %v", 2, grp1Kv.Size())
-		}
-
-		grp1Kv.ForEach(func(key string, value STestStruct) {
-			if key != "Archimedes" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Archimedes' Constant (Pi)" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", value.Name)
-			}
-			if value.value != 3.1415 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
-
-		grp2Kv.ForEach(func(key string, value STestStruct) {
-			if key != "Euler" && key != "Golden Ratio" {
-				t.Errorf("Expected key to be uppercase, want: %v, got %v", kv.Key(key), key)
-			}
-			if value.Name != "This is Euler's Number (e)" && value.Name != "This is The Golden Ratio" {
-				t.Errorf("Expected value.Name to be uppercase, want: %v, got %v", "This is Euler's Number (e)", value.Name)
-			}
-			if value.value != 2.7182 && value.value != 1.6180 {
-				t.Errorf("Expected value.value to be doubled, want: %v, got %v", kv.Get(key).value, value.value)
-			}
-		})
 	})
 
-	t.Run("test PartitionValue for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
+	t.Run("test Drain returns the previous key-value pairs", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+		kv.Set("one", 1)
+		kv.Set("two", 2)
 
-		grp1Kv, grp2Kv := kv.PartitionValue(func(value STestStruct) bool {
-			return value.value > 3
-		})
+		drained := kv.Drain()
+		kv.Set("three", 3)
 
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
-		}
-		if grp1Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp1Kv.Size())
+		if drained.Size() != 2 || drained.Get("one") != 1 || drained.Get("two") != 2 {
+			t.Errorf("Expected drained keys to be %v, got %v", []string{"one", "two"}, drained.Keys())
 		}
-
-		if grp2Kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, grp2Kv.Size())
+		if kv.Size() != 1 || !kv.ContainsKey("three") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"three"}, kv.Keys())
 		}
 	})
 }
 
-func TestSortKeys_SMapKeyValue(t *testing.T) {
-	t.Run("test SortKeys for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		kSorted := kv.SortKeys(func(key1 string, key2 string) bool {
-			return key1 < key2
-		})
-
-		if len(kSorted) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(kSorted))
-		}
-
-		if *kSorted[0] != "Archimedes" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "Archimedes", *kSorted[0])
-		}
-	})
-
-	t.Run("test SortKeys for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
+func TestSwap_SMapKeyValue(t *testing.T) {
+	t.Run("test Swap replaces the key-value pairs", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+		kv.Set("one", 1)
 
-		kSorted := kv.SortKeys(func(key1 string, key2 string) bool {
-			return key1 < key2
-		})
+		newData := map[string]int{"two": 2, "three": 3}
+		old := kv.Swap(newData)
+		newData["four"] = 4
 
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		if old.Size() != 1 || old.Get("one") != 1 {
+			t.Errorf("Expected old keys to be %v, got %v", []string{"one"}, old.Keys())
 		}
-		if len(kSorted) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(kSorted))
+		if kv.Size() != 2 || kv.Get("two") != 2 || kv.Get("three") != 3 || kv.ContainsKey("one") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two", "three"}, kv.Keys())
 		}
 	})
 }
 
-func TestSortValues_SMapKeyValue(t *testing.T) {
-	t.Run("test SortValues for NewSMapKeyValue[string, struct] with keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
-		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		kv.Set("Archimedes", STestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
-		kv.Set("Golden Ratio", STestStruct{"This is The Golden Ratio", 1.6180})
-		kv.Set("Euler", STestStruct{"This is Euler's Number (e)", 2.7182})
-
-		if kv.Size() != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, kv.Size())
-		}
-
-		vSorted := kv.SortValues(func(value1 STestStruct, value2 STestStruct) bool {
-			return value1.value < value2.value
-		})
-
-		if len(vSorted) != 3 {
-			t.Errorf("Expected size to be %v, got %v", 3, len(vSorted))
-		}
+func TestDeepEqual_SMapKeyValue(t *testing.T) {
+	t.Run("test DeepEqual for NewSMapKeyValue[string, int] with overwritten keys and one different value", func(t *testing.T) {
+		kv1 := NewSMapKeyValue[string, int]()
+		kv2 := NewSMapKeyValue[string, int]()
 
-		if vSorted[0].Name != "This is The Golden Ratio" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is The Golden Ratio", vSorted[0].Name)
+		for i := 0; i < 100; i++ {
+			key := strconv.Itoa(i)
+			kv1.Set(key, i)
+			kv1.Set(key, i)
+			kv2.Set(key, i)
 		}
-		if vSorted[1].Name != "This is Euler's Number (e)" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is Euler's Number (e)", vSorted[1].Name)
-		}
-		if vSorted[2].Name != "This is Archimedes' Constant (Pi)" {
-			t.Errorf("Expected key to be uppercase, want: %v, got %v", "This is Archimedes' Constant (Pi)", vSorted[2].Name)
-		}
-	})
+		kv2.Set("50", -1)
 
-	t.Run("test SortValues for NewSMapKeyValue[string, struct] without keys", func(t *testing.T) {
-		type STestStruct struct {
-			Name  string
-			value float64
+		if kv1.Size() != kv2.Size() {
+			t.Errorf("Expected size to be %v, got %v", kv1.Size(), kv2.Size())
 		}
-		kv := NewSMapKeyValue[string, STestStruct]()
-
-		vSorted := kv.SortValues(func(value1 STestStruct, value2 STestStruct) bool {
-			return value1.value < value2.value
-		})
-
-		if kv.Size() != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, kv.Size())
+		if kv1.DeepEqual(kv2) == true {
+			t.Errorf("Expected DeepEqual to be different, got %v", true)
 		}
-		if len(vSorted) != 0 {
-			t.Errorf("Expected size to be %v, got %v", 0, len(vSorted))
+		if kv2.DeepEqual(kv1) == true {
+			t.Errorf("Expected DeepEqual to be different, got %v", true)
 		}
 	})
 }
@@ -0,0 +1,508 @@
+package r9e
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotMagic identifies the snapshot files.
+var snapshotMagic = [4]byte{'R', '9', 'E', 'S'}
+
+// snapshotVersion is the version of the snapshot format written by SaveSnapshot.
+const snapshotVersion uint16 = 1
+
+// maxSnapshotFieldSize is the maximum size of an encoded key or value, used to detect corrupted snapshots.
+const maxSnapshotFieldSize = 1 << 30
+
+// crcTable is the table used to compute the checksums of the snapshots.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrSnapshotInvalid is returned when the data is not a snapshot or it is truncated.
+	ErrSnapshotInvalid = errors.New("r9e: invalid snapshot")
+
+	// ErrSnapshotVersion is returned when the snapshot was written using an unsupported format version.
+	ErrSnapshotVersion = errors.New("r9e: unsupported snapshot version")
+
+	// ErrSnapshotChecksum is returned when the checksum of the snapshot doesn't match its content.
+	ErrSnapshotChecksum = errors.New("r9e: snapshot checksum mismatch")
+)
+
+// Codec encodes and decodes the keys and values stored in the snapshots.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// GobCodec is a Codec using encoding/gob. This is the default Codec.
+// Every key and value is encoded as a separate gob stream, so it carries the description of
+// its type: a snapshot of struct values is larger and slower to read and write than with
+// JSONCodec or a Codec for the concrete types.
+type GobCodec struct{}
+
+// Marshal returns the gob encoding of v.
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the gob encoded data and stores the result in the value pointed to by v.
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// JSONCodec is a Codec using encoding/json.
+type JSONCodec struct{}
+
+// Marshal returns the JSON encoding of v.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes the JSON encoded data and stores the result in the value pointed to by v.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type snapshotOptions struct {
+	keyCodec   Codec
+	valueCodec Codec
+}
+
+// SnapshotOptions are the options for SaveSnapshot and LoadSnapshot.
+type SnapshotOptions func(*snapshotOptions)
+
+// WithKeyCodec sets the Codec used to encode and decode the keys. The default is GobCodec.
+func WithKeyCodec(codec Codec) SnapshotOptions {
+	return func(so *snapshotOptions) {
+		so.keyCodec = codec
+	}
+}
+
+// WithValueCodec sets the Codec used to encode and decode the values. The default is GobCodec.
+func WithValueCodec(codec Codec) SnapshotOptions {
+	return func(so *snapshotOptions) {
+		so.valueCodec = codec
+	}
+}
+
+// newSnapshotOptions returns the snapshot options with the defaults applied.
+func newSnapshotOptions(options ...SnapshotOptions) snapshotOptions {
+	so := snapshotOptions{
+		keyCodec:   GobCodec{},
+		valueCodec: GobCodec{},
+	}
+	for _, opt := range options {
+		opt(&so)
+	}
+	return so
+}
+
+// Snapshotter is implemented by the containers that can be saved to and restored from a snapshot.
+type Snapshotter interface {
+	SaveSnapshot(w io.Writer, options ...SnapshotOptions) error
+	LoadSnapshot(r io.Reader, options ...SnapshotOptions) error
+}
+
+// SaveSnapshotFile saves the snapshot of the container to the file at path.
+// The snapshot is written to a temporary file in the same directory which is renamed
+// to path once it is complete and synced, so path always contains a complete snapshot.
+func SaveSnapshotFile(path string, s Snapshotter, options ...SnapshotOptions) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return s.SaveSnapshot(w, options...)
+	})
+}
+
+// LoadSnapshotFile restores the container from the snapshot saved in the file at path.
+func LoadSnapshotFile(path string, s Snapshotter, options ...SnapshotOptions) error {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return s.LoadSnapshot(f, options...)
+}
+
+// writeFileAtomic writes the file at path using the given function. The content is written to a
+// temporary file in the same directory which is renamed to path once it is complete and synced.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes the directory entries, so a rename survives a crash.
+// Some platforms don't support it, so the errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return
+	}
+	defer d.Close()
+
+	_ = d.Sync()
+}
+
+// snapshotEntry is a key-value pair stored in a snapshot.
+// A zero expireAt means the key-value pair never expires.
+type snapshotEntry[K comparable, T any] struct {
+	key      K
+	value    T
+	expireAt time.Time
+}
+
+// writeSnapshot writes the entries to w using the snapshot format:
+//
+//	header:  magic "R9ES" | version uint16 | entries uint64
+//	entry:   expireAt int64 (unix nanoseconds, 0 never) | key length uvarint | key | value length uvarint | value
+//	trailer: CRC-32C of the header and the entries uint32
+//
+// All the integers are big endian.
+func writeSnapshot[K comparable, T any](w io.Writer, entries []snapshotEntry[K, T], options ...SnapshotOptions) error {
+	so := newSnapshotOptions(options...)
+
+	crc := crc32.New(crcTable)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	var header [14]byte
+	copy(header[:4], snapshotMagic[:])
+	binary.BigEndian.PutUint16(header[4:6], snapshotVersion)
+	binary.BigEndian.PutUint64(header[6:14], uint64(len(entries)))
+	if _, err := bw.Write(header[:]); err != nil {
+		return err
+	}
+
+	var buf []byte
+	for _, e := range entries {
+		var err error
+		if buf, err = appendSnapshotEntry(buf[:0], so, e); err != nil {
+			return err
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	var trailer [4]byte
+	binary.BigEndian.PutUint32(trailer[:], crc.Sum32())
+	_, err := w.Write(trailer[:])
+	return err
+}
+
+// appendSnapshotEntry appends the encoding of a single entry of the snapshot to buf.
+func appendSnapshotEntry[K comparable, T any](buf []byte, so snapshotOptions, e snapshotEntry[K, T]) ([]byte, error) {
+	buf = appendSnapshotTime(buf, e.expireAt)
+
+	buf, err := appendSnapshotKey(buf, so, e.key)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := so.valueCodec.Marshal(e.value)
+	if err != nil {
+		return nil, fmt.Errorf("r9e: encoding value of key %v: %w", e.key, err)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...), nil
+}
+
+// appendSnapshotKey appends the encoding of the key prefixed by its length to buf.
+func appendSnapshotKey[K comparable](buf []byte, so snapshotOptions, key K) ([]byte, error) {
+	data, err := so.keyCodec.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("r9e: encoding key: %w", err)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...), nil
+}
+
+// appendSnapshotTime appends the time as unix nanoseconds to buf. The zero time is encoded as 0.
+func appendSnapshotTime(buf []byte, t time.Time) []byte {
+	var nanos int64
+	if !t.IsZero() {
+		nanos = t.UnixNano()
+	}
+	return binary.BigEndian.AppendUint64(buf, uint64(nanos))
+}
+
+// readSnapshot reads the entries of a snapshot written by writeSnapshot, verifying its checksum.
+func readSnapshot[K comparable, T any](r io.Reader, options ...SnapshotOptions) ([]snapshotEntry[K, T], error) {
+	so := newSnapshotOptions(options...)
+
+	crc := crc32.New(crcTable)
+	br := &snapshotReader{r: bufio.NewReader(r), crc: crc}
+
+	var header [14]byte
+	if err := br.readFull(header[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:4], snapshotMagic[:]) {
+		return nil, fmt.Errorf("%w: bad magic number", ErrSnapshotInvalid)
+	}
+	if v := binary.BigEndian.Uint16(header[4:6]); v != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, v)
+	}
+
+	// the fields are decoded once the checksum is verified, so a corrupted snapshot is
+	// reported as ErrSnapshotChecksum and not as a decoding error
+	count := binary.BigEndian.Uint64(header[6:14])
+	raws := make([]rawSnapshotEntry, 0, min64(count, 1<<16))
+	for i := uint64(0); i < count; i++ {
+		raw, err := br.readEntry()
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+
+	sum := crc.Sum32()
+	var trailer [4]byte
+	if _, err := io.ReadFull(br.r, trailer[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSnapshotInvalid, err)
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != sum {
+		return nil, ErrSnapshotChecksum
+	}
+
+	entries := make([]snapshotEntry[K, T], 0, len(raws))
+	for _, raw := range raws {
+		e, err := decodeSnapshotEntry[K, T](raw, so)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// rawSnapshotEntry is an entry of the snapshot with the key and the value not decoded.
+type rawSnapshotEntry struct {
+	expireAt   time.Time
+	key, value []byte
+}
+
+// readSnapshotEntry reads and decodes a single entry of the snapshot.
+func readSnapshotEntry[K comparable, T any](br *snapshotReader, so snapshotOptions) (snapshotEntry[K, T], error) {
+	raw, err := br.readEntry()
+	if err != nil {
+		return snapshotEntry[K, T]{}, err
+	}
+	return decodeSnapshotEntry[K, T](raw, so)
+}
+
+// decodeSnapshotEntry decodes the key and the value of a raw entry.
+func decodeSnapshotEntry[K comparable, T any](raw rawSnapshotEntry, so snapshotOptions) (snapshotEntry[K, T], error) {
+	e := snapshotEntry[K, T]{expireAt: raw.expireAt}
+
+	if err := so.keyCodec.Unmarshal(raw.key, &e.key); err != nil {
+		return e, fmt.Errorf("r9e: decoding key: %w", err)
+	}
+	if err := so.valueCodec.Unmarshal(raw.value, &e.value); err != nil {
+		return e, fmt.Errorf("r9e: decoding value of key %v: %w", e.key, err)
+	}
+	return e, nil
+}
+
+// readSnapshotKey reads a key prefixed by its length.
+func readSnapshotKey[K comparable](br *snapshotReader, so snapshotOptions) (K, error) {
+	var key K
+
+	data, err := br.readField()
+	if err != nil {
+		return key, err
+	}
+	if err := so.keyCodec.Unmarshal(data, &key); err != nil {
+		return key, fmt.Errorf("r9e: decoding key: %w", err)
+	}
+	return key, nil
+}
+
+// snapshotReader reads the fields of a snapshot updating its checksum.
+type snapshotReader struct {
+	r interface {
+		io.Reader
+		io.ByteReader
+	}
+	crc hash.Hash32
+}
+
+// readFull reads exactly len(buf) bytes.
+func (s *snapshotReader) readFull(buf []byte) error {
+	if _, err := io.ReadFull(s.r, buf); err != nil {
+		return fmt.Errorf("%w: %v", ErrSnapshotInvalid, err)
+	}
+	s.crc.Write(buf)
+	return nil
+}
+
+// readTime reads a time written by appendSnapshotTime.
+func (s *snapshotReader) readTime() (time.Time, error) {
+	var buf [8]byte
+	if err := s.readFull(buf[:]); err != nil {
+		return time.Time{}, err
+	}
+	if nanos := int64(binary.BigEndian.Uint64(buf[:])); nanos != 0 {
+		return time.Unix(0, nanos), nil
+	}
+	return time.Time{}, nil
+}
+
+// readEntry reads a single entry of the snapshot without decoding its key and value.
+func (s *snapshotReader) readEntry() (rawSnapshotEntry, error) {
+	var raw rawSnapshotEntry
+
+	expireAt, err := s.readTime()
+	if err != nil {
+		return raw, err
+	}
+	key, err := s.readField()
+	if err != nil {
+		return raw, err
+	}
+	value, err := s.readField()
+	if err != nil {
+		return raw, err
+	}
+
+	raw.expireAt, raw.key, raw.value = expireAt, key, value
+	return raw, nil
+}
+
+// readField reads a field prefixed by its length.
+func (s *snapshotReader) readField() ([]byte, error) {
+	n, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSnapshotInvalid, err)
+	}
+	if n > maxSnapshotFieldSize {
+		return nil, fmt.Errorf("%w: field of %d bytes", ErrSnapshotInvalid, n)
+	}
+	s.crc.Write(binary.AppendUvarint(nil, n))
+
+	buf := make([]byte, n)
+	if err := s.readFull(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// min64 returns the smaller of x or y.
+func min64(x, y uint64) uint64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// SaveSnapshot writes a snapshot of the container to w.
+// Only the key-value pairs not expired are written, keeping their expiration time.
+func (r *MapKeyValue[K, T]) SaveSnapshot(w io.Writer, options ...SnapshotOptions) error {
+	r.mu.RLock()
+	entries := make([]snapshotEntry[K, T], 0, len(r.data))
+	for key, value := range r.data {
+		if r.expired(key) {
+			continue
+		}
+		entries = append(entries, snapshotEntry[K, T]{key: key, value: value, expireAt: r.expires[key]})
+	}
+	r.mu.RUnlock()
+
+	return writeSnapshot(w, entries, options...)
+}
+
+// LoadSnapshot replaces the content of the container with the snapshot read from rd.
+// The container is not modified if the snapshot is invalid.
+func (r *MapKeyValue[K, T]) LoadSnapshot(rd io.Reader, options ...SnapshotOptions) error {
+	entries, err := readSnapshot[K, T](rd, options...)
+	if err != nil {
+		return err
+	}
+
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reset()
+	now := r.now()
+	for _, e := range entries {
+		if !e.expireAt.IsZero() && !e.expireAt.After(now) {
+			continue
+		}
+
+		r.set(e.key, e.value, e.expireAt)
+	}
+	return nil
+}
+
+// SaveSnapshot writes a snapshot of the container to w.
+func (r *SMapKeyValue[K, T]) SaveSnapshot(w io.Writer, options ...SnapshotOptions) error {
+	entries := make([]snapshotEntry[K, T], 0, r.Size())
+	r.ForEach(func(key K, value T) {
+		entries = append(entries, snapshotEntry[K, T]{key: key, value: value})
+	})
+
+	return writeSnapshot(w, entries, options...)
+}
+
+// LoadSnapshot replaces the content of the container with the snapshot read from rd.
+// The container is not modified if the snapshot is invalid.
+func (r *SMapKeyValue[K, T]) LoadSnapshot(rd io.Reader, options ...SnapshotOptions) error {
+	entries, err := readSnapshot[K, T](rd, options...)
+	if err != nil {
+		return err
+	}
+
+	m := make(map[K]T, len(entries))
+	for _, e := range entries {
+		m[e.key] = e.value
+	}
+
+	// the content is replaced at once, so the readers don't see it partially loaded
+	r.Swap(m)
+	return nil
+}
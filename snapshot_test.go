@@ -0,0 +1,300 @@
+package r9e
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+type snapshotTestStruct struct {
+	Name  string
+	Value float64
+}
+
+func TestSnapshot_MapKeyValue(t *testing.T) {
+	t.Run("test SaveSnapshot and LoadSnapshot for MapKeyValue[string, struct]", func(t *testing.T) {
+		kv := NewMapKeyValue[string, snapshotTestStruct]()
+		kv.Set("Archimedes", snapshotTestStruct{"This is Archimedes' Constant (Pi)", 3.1415})
+		kv.Set("Euler", snapshotTestStruct{"This is Euler's Number (e)", 2.7182})
+		kv.Set("Golden Ratio", snapshotTestStruct{"This is The Golden Ratio", 1.6180})
+
+		var buf bytes.Buffer
+		if err := kv.SaveSnapshot(&buf); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		restored := NewMapKeyValue[string, snapshotTestStruct]()
+		restored.Set("Other", snapshotTestStruct{"This is not in the snapshot", 1})
+
+		if err := restored.LoadSnapshot(&buf); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if !kv.DeepEqual(restored) {
+			t.Errorf("Expected restored container to be equal to the original")
+		}
+	})
+
+	t.Run("test SaveSnapshot and LoadSnapshot keep the expiration", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[int, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL(1, 1, time.Minute)
+		kv.SetWithTTL(2, 2, time.Second)
+		kv.Set(3, 3)
+
+		var buf bytes.Buffer
+		if err := kv.SaveSnapshot(&buf); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		clock.Advance(2 * time.Second)
+
+		restored := NewMapKeyValue[int, int]()
+		restored.clock = clock.Now
+		defer restored.Close()
+
+		if err := restored.LoadSnapshot(&buf); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if restored.Size() != 2 {
+			t.Errorf("Expected size to be %v, got %v", 2, restored.Size())
+		}
+		if ttl, ok := restored.TTL(1); !ok || ttl != time.Minute-2*time.Second {
+			t.Errorf("Expected ttl to be %v, got %v", time.Minute-2*time.Second, ttl)
+		}
+		if ttl, ok := restored.TTL(3); !ok || ttl != NoExpiration {
+			t.Errorf("Expected ttl to be %v, got %v", NoExpiration, ttl)
+		}
+	})
+
+	t.Run("test SaveSnapshot and LoadSnapshot with JSON codec", func(t *testing.T) {
+		kv := NewMapKeyValue[string, snapshotTestStruct]()
+		kv.Set("pi", snapshotTestStruct{"Archimedes' constant", 3.141592})
+
+		var buf bytes.Buffer
+		if err := kv.SaveSnapshot(&buf, WithKeyCodec(JSONCodec{}), WithValueCodec(JSONCodec{})); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte(`"Archimedes' constant"`)) {
+			t.Errorf("Expected snapshot to contain the JSON encoded value")
+		}
+
+		restored := NewMapKeyValue[string, snapshotTestStruct]()
+		if err := restored.LoadSnapshot(&buf, WithKeyCodec(JSONCodec{}), WithValueCodec(JSONCodec{})); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if restored.Get("pi").Value != 3.141592 {
+			t.Errorf("Expected value to be %v, got %v", 3.141592, restored.Get("pi").Value)
+		}
+	})
+}
+
+func TestSnapshot_SMapKeyValue(t *testing.T) {
+	t.Run("test SaveSnapshot and LoadSnapshot for SMapKeyValue[int, string]", func(t *testing.T) {
+		kv := NewSMapKeyValue[int, string]()
+		for i := 0; i < 100; i++ {
+			kv.Set(i, time.Duration(i).String())
+		}
+
+		var buf bytes.Buffer
+		if err := kv.SaveSnapshot(&buf); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		restored := NewSMapKeyValue[int, string]()
+		if err := restored.LoadSnapshot(&buf); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		keys := restored.Keys()
+		sort.Ints(keys)
+		if len(keys) != 100 || keys[99] != 99 {
+			t.Errorf("Expected keys to be %v, got %v", 100, len(keys))
+		}
+		if restored.Get(42) != time.Duration(42).String() {
+			t.Errorf("Expected value to be %v, got %v", time.Duration(42).String(), restored.Get(42))
+		}
+	})
+
+	t.Run("test LoadSnapshot replaces the content at once", func(t *testing.T) {
+		kv := NewSMapKeyValue[int, int]()
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+		}
+
+		var buf bytes.Buffer
+		if err := kv.SaveSnapshot(&buf); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		snapshot := buf.Bytes()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				if err := kv.LoadSnapshot(bytes.NewReader(snapshot)); err != nil {
+					t.Errorf("Expected error to be %v, got %v", nil, err)
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if kv.Size() != 100 {
+				t.Fatalf("Expected size to be %v, got %v", 100, kv.Size())
+			}
+		}
+	})
+}
+
+func TestLoadSnapshot_Invalid(t *testing.T) {
+	kv := NewMapKeyValue[string, int]()
+	kv.Set("one", 1)
+	kv.Set("two", 2)
+
+	var buf bytes.Buffer
+	if err := kv.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("Expected error to be %v, got %v", nil, err)
+	}
+	snapshot := buf.Bytes()
+
+	// the values are encoded with the same length, so the last one starts at the same offset
+	value, err := GobCodec{}.Marshal(1)
+	if err != nil {
+		t.Fatalf("Expected error to be %v, got %v", nil, err)
+	}
+	lastValue := len(snapshot) - 4 - len(value)
+
+	tests := []struct {
+		name string
+		data func() []byte
+		err  error
+	}{
+		{"empty", func() []byte { return nil }, ErrSnapshotInvalid},
+		{"bad magic", func() []byte {
+			data := append([]byte(nil), snapshot...)
+			data[0] = 'X'
+			return data
+		}, ErrSnapshotInvalid},
+		{"bad version", func() []byte {
+			data := append([]byte(nil), snapshot...)
+			data[5] = 99
+			return data
+		}, ErrSnapshotVersion},
+		{"truncated", func() []byte { return snapshot[:len(snapshot)-10] }, ErrSnapshotInvalid},
+		{"corrupted", func() []byte {
+			data := append([]byte(nil), snapshot...)
+			data[len(data)-1] ^= 0xff
+			return data
+		}, ErrSnapshotChecksum},
+		{"corrupted value", func() []byte {
+			data := append([]byte(nil), snapshot...)
+			data[lastValue] = 0xff
+			return data
+		}, ErrSnapshotChecksum},
+	}
+
+	for _, tc := range tests {
+		t.Run("test LoadSnapshot with "+tc.name+" snapshot doesn't modify the container", func(t *testing.T) {
+			restored := NewMapKeyValue[string, int]()
+			restored.Set("three", 3)
+
+			err := restored.LoadSnapshot(bytes.NewReader(tc.data()))
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Expected error to be %v, got %v", tc.err, err)
+			}
+			if restored.Size() != 1 || restored.Get("three") != 3 {
+				t.Errorf("Expected container to be unchanged, got %v", restored.Keys())
+			}
+		})
+	}
+}
+
+// failingSnapshotter writes some bytes and then fails.
+type failingSnapshotter struct{}
+
+func (failingSnapshotter) SaveSnapshot(w io.Writer, options ...SnapshotOptions) error {
+	if _, err := w.Write([]byte("partial")); err != nil {
+		return err
+	}
+	return errors.New("failure")
+}
+
+func (failingSnapshotter) LoadSnapshot(r io.Reader, options ...SnapshotOptions) error {
+	return nil
+}
+
+func TestSnapshotFile(t *testing.T) {
+	t.Run("test SaveSnapshotFile and LoadSnapshotFile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kv.snapshot")
+
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
+
+		if err := SaveSnapshotFile(path, kv); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		restored := NewMapKeyValue[string, int]()
+		if err := LoadSnapshotFile(path, restored); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if restored.Get("one") != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, restored.Get("one"))
+		}
+	})
+
+	t.Run("test SaveSnapshotFile failure keeps the previous snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "kv.snapshot")
+
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
+		if err := SaveSnapshotFile(path, kv); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if err := SaveSnapshotFile(path, failingSnapshotter{}); err == nil {
+			t.Fatalf("Expected SaveSnapshotFile to fail")
+		}
+
+		restored := NewMapKeyValue[string, int]()
+		if err := LoadSnapshotFile(path, restored); err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if restored.Get("one") != 1 {
+			t.Errorf("Expected value to be %v, got %v", 1, restored.Get("one"))
+		}
+
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 {
+			t.Errorf("Expected temporary files to be removed, got %v files", len(files))
+		}
+	})
+
+	t.Run("test LoadSnapshotFile with missing file", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+
+		err := LoadSnapshotFile(filepath.Join(t.TempDir(), "missing"), kv)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected error to be %v, got %v", os.ErrNotExist, err)
+		}
+	})
+}
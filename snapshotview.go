@@ -0,0 +1,396 @@
+package r9e
+
+import (
+	"iter"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	_ Reader[string, any]   = (*SnapshotView[string, any])(nil)
+	_ Iterable[string, any] = (*SnapshotView[string, any])(nil)
+)
+
+// WithSnapshots makes the MapKeyValue container maintain the persistent copy of its key-value pairs
+// used by Snapshot since its creation, so even the first Snapshot runs in constant time.
+// Otherwise, the copy is created by the first Snapshot, blocking the writers while it is built.
+// Maintaining the copy makes every write O(log n) slower.
+func WithSnapshots() MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.snapshots = true
+	}
+}
+
+// viewEntry is a value stored in a SnapshotView and its expiration time, zero if it doesn't expire.
+type viewEntry[T any] struct {
+	value    T
+	expireAt time.Time
+}
+
+// SnapshotView is an immutable, point-in-time and read-only view of the key-value pairs of a container,
+// returned by Snapshot. The later changes of the container are not seen by the view, so its reads are
+// repeatable, and it is safe for concurrent use without locks.
+// The keys that expire are hidden when their expiration time is before the time of the snapshot.
+// The values are not copied, so the values holding pointers must not be modified.
+type SnapshotView[K comparable, T any] struct {
+	tree hamt[K, viewEntry[T]]
+
+	// at is the time of the snapshot, expiring is true if some key has an expiration time.
+	at       time.Time
+	expiring bool
+
+	// size is counted by the first Size when some key has an expiration time.
+	sizeOnce sync.Once
+	size     int
+}
+
+// newSnapshotView returns a new SnapshotView of the frozen tree taken at the given time.
+func newSnapshotView[K comparable, T any](tree hamt[K, viewEntry[T]], at time.Time, expiring bool) *SnapshotView[K, T] {
+	return &SnapshotView[K, T]{tree: tree, at: at, expiring: expiring}
+}
+
+// Snapshot returns an immutable, point-in-time and read-only view of the container. The view shares
+// the unchanged parts of a persistent copy of the key-value pairs maintained by the writes, so it is
+// created in constant time, and the reads and scans of the view don't block the container.
+// The first Snapshot creates the copy, blocking the writers while it is built, unless the container
+// was created using WithSnapshots. Since then every write updates the copy in O(log n).
+func (r *MapKeyValue[K, T]) Snapshot() *SnapshotView[K, T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tree == nil {
+		r.tree = r.newTree()
+		for key := range r.data {
+			r.track(key)
+		}
+	}
+
+	return newSnapshotView(r.tree.freeze(), r.now(), len(r.expires) > 0)
+}
+
+// Snapshot returns an immutable, point-in-time and read-only view of the container.
+// The SMapKeyValue container doesn't maintain a persistent copy of its key-value pairs, because its
+// writers would need to be serialized, so the view is built in O(n) blocking the writers, but not the
+// readers. It gives the point-in-time iteration that All and ForEach don't.
+func (r *SMapKeyValue[K, T]) Snapshot() *SnapshotView[K, T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tree := newHAMT[K, viewEntry[T]](NewHasher[K](), true)
+	r.data().Range(func(key, value any) bool {
+		tree.set(key.(K), viewEntry[T]{value: *value.(*T)})
+		return true
+	})
+
+	return newSnapshotView(tree.freeze(), time.Time{}, false)
+}
+
+// newTree returns a new empty persistent copy of the key-value pairs.
+func (r *MapKeyValue[K, T]) newTree() *hamt[K, viewEntry[T]] {
+	var hash Hasher[K]
+	if r.tree != nil {
+		hash = r.tree.hash
+	} else {
+		hash = NewHasher[K]()
+	}
+
+	tree := newHAMT[K, viewEntry[T]](hash, true)
+	return &tree
+}
+
+// track copies the key-value pair of the key and its expiration time to the persistent copy, if any.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) track(key K) {
+	if r.tree == nil {
+		return
+	}
+	r.tree.set(key, viewEntry[T]{value: r.data[key], expireAt: r.expires[key]})
+}
+
+// untrack removes the key from the persistent copy, if any.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) untrack(key K) {
+	if r.tree == nil {
+		return
+	}
+	r.tree.delete(key)
+}
+
+// resetTree empties the persistent copy, if any.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) resetTree() {
+	if r.tree == nil {
+		return
+	}
+	r.tree = r.newTree()
+}
+
+// lookup returns the value of the key and true if it exist and it was not expired at the time of the snapshot.
+func (r *SnapshotView[K, T]) lookup(key K) (T, bool) {
+	e, ok := r.tree.get(key)
+	if !ok || !r.live(e) {
+		var empty T
+		return empty, false
+	}
+	return e.value, true
+}
+
+// live returns true if the entry was not expired at the time of the snapshot.
+func (r *SnapshotView[K, T]) live(e viewEntry[T]) bool {
+	return e.expireAt.IsZero() || e.expireAt.After(r.at)
+}
+
+// Get returns the value associated with the key.
+// If the key does not exist, return zero value of the type.
+func (r *SnapshotView[K, T]) Get(key K) T {
+	value, _ := r.lookup(key)
+	return value
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (r *SnapshotView[K, T]) GetAndCheck(key K) (T, bool) {
+	return r.lookup(key)
+}
+
+// ContainsKey returns true if the key is in the view.
+func (r *SnapshotView[K, T]) ContainsKey(key K) bool {
+	_, ok := r.lookup(key)
+	return ok
+}
+
+// ContainsValue returns true if the value is in the view.
+func (r *SnapshotView[K, T]) ContainsValue(value T) bool {
+	for _, v := range r.All() {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the key value associated with the key.
+func (r *SnapshotView[K, T]) Key(key K) K {
+	if _, ok := r.lookup(key); ok {
+		return key
+	}
+	var empty K
+	return empty
+}
+
+// Size returns the number of key-value pairs in the view.
+// It runs in constant time, unless some key of the container had an expiration time when the snapshot
+// was taken, then the first call counts the key-value pairs not expired.
+func (r *SnapshotView[K, T]) Size() int {
+	if !r.expiring {
+		return r.tree.size
+	}
+
+	r.sizeOnce.Do(func() {
+		for range r.All() {
+			r.size++
+		}
+	})
+	return r.size
+}
+
+// IsEmpty returns true if the view is empty.
+func (r *SnapshotView[K, T]) IsEmpty() bool {
+	return r.Size() == 0
+}
+
+// IsFull returns true if the view has elements.
+func (r *SnapshotView[K, T]) IsFull() bool {
+	return r.Size() != 0
+}
+
+// Time returns the time when the snapshot was taken, used to hide the expired keys.
+// It is the zero time for the containers without expiration.
+func (r *SnapshotView[K, T]) Time() time.Time {
+	return r.at
+}
+
+// Keys returns all keys in the view.
+func (r *SnapshotView[K, T]) Keys() []K {
+	keys := make([]K, 0, r.tree.size)
+	for key := range r.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns all values in the view.
+func (r *SnapshotView[K, T]) Values() []T {
+	values := make([]T, 0, r.tree.size)
+	for _, value := range r.All() {
+		values = append(values, value)
+	}
+	return values
+}
+
+// ForEach calls the given function for each key-value pair in the view.
+func (r *SnapshotView[K, T]) ForEach(fn func(key K, value T)) {
+	for key, value := range r.All() {
+		fn(key, value)
+	}
+}
+
+// ForEachKey calls the given function for each key in the view.
+func (r *SnapshotView[K, T]) ForEachKey(fn func(key K)) {
+	for key := range r.All() {
+		fn(key)
+	}
+}
+
+// ForEachValue calls the given function for each value in the view.
+func (r *SnapshotView[K, T]) ForEachValue(fn func(value T)) {
+	for _, value := range r.All() {
+		fn(value)
+	}
+}
+
+// All returns an iterator over the key-value pairs of the view, in no particular order.
+// Every iteration sees the same key-value pairs.
+func (r *SnapshotView[K, T]) All() iter.Seq2[K, T] {
+	return func(yield func(K, T) bool) {
+		r.tree.all(func(key K, e viewEntry[T]) bool {
+			if !r.live(e) {
+				return true
+			}
+			return yield(key, e.value)
+		})
+	}
+}
+
+// KeysSeq returns an iterator over the keys of the view, in no particular order.
+func (r *SnapshotView[K, T]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range r.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the view, in no particular order.
+func (r *SnapshotView[K, T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, value := range r.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Map returns a new MapKeyValue after applying the given function fn to each key-value pair.
+func (r *SnapshotView[K, T]) Map(fn func(key K, value T) (newKey K, newValue T)) *MapKeyValue[K, T] {
+	m := NewMapKeyValue[K, T](WithCapacity(r.tree.size))
+	for key, value := range r.All() {
+		m.Set(fn(key, value))
+	}
+	return m
+}
+
+// MapKey returns a new MapKeyValue after applying the given function fn to each key.
+func (r *SnapshotView[K, T]) MapKey(fn func(key K) K) *MapKeyValue[K, T] {
+	return r.Map(func(key K, value T) (K, T) {
+		return fn(key), value
+	})
+}
+
+// MapValue returns a new MapKeyValue after applying the given function fn to each value.
+func (r *SnapshotView[K, T]) MapValue(fn func(value T) T) *MapKeyValue[K, T] {
+	return r.Map(func(key K, value T) (K, T) {
+		return key, fn(value)
+	})
+}
+
+// Filter returns a new MapKeyValue after applying the given function fn to each key-value pair.
+func (r *SnapshotView[K, T]) Filter(fn func(key K, value T) bool) *MapKeyValue[K, T] {
+	m := NewMapKeyValue[K, T]()
+	for key, value := range r.All() {
+		if fn(key, value) {
+			m.Set(key, value)
+		}
+	}
+	return m
+}
+
+// FilterKey returns a new MapKeyValue after applying the given function fn to each key.
+func (r *SnapshotView[K, T]) FilterKey(fn func(key K) bool) *MapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// FilterValue returns a new MapKeyValue after applying the given function fn to each value.
+func (r *SnapshotView[K, T]) FilterValue(fn func(value T) bool) *MapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// Partition returns two new MapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each element.
+func (r *SnapshotView[K, T]) Partition(fn func(key K, value T) bool) (match, others *MapKeyValue[K, T]) {
+	match = NewMapKeyValue[K, T]()
+	others = NewMapKeyValue[K, T]()
+	for key, value := range r.All() {
+		if fn(key, value) {
+			match.Set(key, value)
+		} else {
+			others.Set(key, value)
+		}
+	}
+	return
+}
+
+// PartitionKey returns two new MapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each key.
+func (r *SnapshotView[K, T]) PartitionKey(fn func(key K) bool) (match, others *MapKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// PartitionValue returns two new MapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each value.
+func (r *SnapshotView[K, T]) PartitionValue(fn func(value T) bool) (match, others *MapKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// SortKeys returns a []*K (keys) after sorting the keys using the given sortFn function.
+func (r *SnapshotView[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
+	keys := r.Keys()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return sortFn(keys[i], keys[j])
+	})
+
+	m := make([]*K, len(keys))
+	for i := range keys {
+		m[i] = &keys[i]
+	}
+	return m
+}
+
+// SortValues returns a []*T (values) after sorting the values using given function sortFn.
+func (r *SnapshotView[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
+	values := r.Values()
+
+	sort.Slice(values, func(i, j int) bool {
+		return sortFn(values[i], values[j])
+	})
+
+	m := make([]*T, len(values))
+	for i := range values {
+		m[i] = &values[i]
+	}
+	return m
+}
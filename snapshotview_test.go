@@ -0,0 +1,150 @@
+package r9e
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSnapshotView_MapKeyValue(t *testing.T) {
+	for name, options := range map[string][]MapKeyValueOptions{
+		"lazy":          nil,
+		"WithSnapshots": {WithSnapshots()},
+	} {
+		t.Run("test Snapshot is not changed by the writes "+name, func(t *testing.T) {
+			kv := NewMapKeyValue[string, int](options...)
+			kv.Set("one", 1)
+			kv.Set("two", 2)
+
+			view := kv.Snapshot()
+			kv.Set("one", 10)
+			kv.Delete("two")
+			kv.Set("three", 3)
+
+			if view.Size() != 2 || view.Get("one") != 1 || view.Get("two") != 2 || view.ContainsKey("three") {
+				t.Errorf("Expected view to be %v, got %v", map[string]int{"one": 1, "two": 2}, view.Keys())
+			}
+			if kv.Get("one") != 10 || kv.ContainsKey("two") || kv.Get("three") != 3 {
+				t.Errorf("Expected keys to be %v, got %v", []string{"one", "three"}, kv.Keys())
+			}
+
+			second := kv.Snapshot()
+			kv.Clear()
+			if second.Size() != 2 || second.Get("one") != 10 || second.Get("three") != 3 {
+				t.Errorf("Expected view to be %v, got %v", map[string]int{"one": 10, "three": 3}, second.Keys())
+			}
+			if view.Size() != 2 || view.Get("one") != 1 {
+				t.Errorf("Expected view to be %v, got %v", map[string]int{"one": 1, "two": 2}, view.Keys())
+			}
+		})
+	}
+
+	t.Run("test Snapshot hides the keys expired at the time of the snapshot", func(t *testing.T) {
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int]()
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		kv.SetWithTTL("short", 1, time.Second)
+		kv.SetWithTTL("long", 2, time.Minute)
+		kv.SetWithTTL("persisted", 3, time.Second)
+		kv.Persist("persisted")
+		clock.Advance(time.Second)
+
+		view := kv.Snapshot()
+		clock.Advance(time.Hour)
+
+		keys := view.Keys()
+		sort.Strings(keys)
+		if view.Size() != 2 || len(keys) != 2 || keys[0] != "long" || keys[1] != "persisted" {
+			t.Errorf("Expected keys to be %v, got %v", []string{"long", "persisted"}, keys)
+		}
+		if view.ContainsKey("short") {
+			t.Errorf("Expected key %v to be expired", "short")
+		}
+	})
+
+	t.Run("test the scans of the view don't block the writers", func(t *testing.T) {
+		kv := NewMapKeyValue[int, int](WithSnapshots())
+		for i := 0; i < 1000; i++ {
+			kv.Set(i, i)
+		}
+
+		view := kv.Snapshot()
+		filtered := view.Filter(func(key, value int) bool {
+			// the writers don't wait for the scan, and their changes are not seen by the view
+			withoutDeadlock(t, func() {
+				kv.Set(key+1000, value)
+				kv.Set(key, -value)
+			})
+			return value%2 == 0
+		})
+
+		if filtered.Size() != 500 {
+			t.Errorf("Expected size to be %v, got %v", 500, filtered.Size())
+		}
+		values := view.SortValues(func(value1, value2 int) bool {
+			return value1 < value2
+		})
+		for i, value := range values {
+			if *value != i {
+				t.Fatalf("Expected value to be %v, got %v", i, *value)
+			}
+		}
+	})
+
+	t.Run("test the views of Drain and Swap", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int](WithSnapshots())
+		kv.Set("one", 1)
+
+		view := kv.Snapshot()
+		kv.Swap(map[string]int{"two": 2})
+
+		if !view.ContainsKey("one") || view.ContainsKey("two") {
+			t.Errorf("Expected keys to be %v, got %v", []string{"one"}, view.Keys())
+		}
+		if swapped := kv.Snapshot(); swapped.Size() != 1 || swapped.Get("two") != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two"}, swapped.Keys())
+		}
+
+		kv.Drain()
+		if !kv.Snapshot().IsEmpty() {
+			t.Errorf("Expected view to be empty")
+		}
+	})
+}
+
+func TestSnapshotView_SMapKeyValue(t *testing.T) {
+	t.Run("test Snapshot is not changed by the writes", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+
+		view := kv.Snapshot()
+		kv.Set("one", 10)
+		kv.Delete("two")
+
+		if view.Size() != 2 || view.Get("one") != 1 || view.Get("two") != 2 {
+			t.Errorf("Expected view to be %v, got %v", map[string]int{"one": 1, "two": 2}, view.Keys())
+		}
+	})
+
+	t.Run("test All of the view is repeatable while the container is modified", func(t *testing.T) {
+		kv := NewSMapKeyValue[int, int]()
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+		}
+
+		view := kv.Snapshot()
+		visited := 0
+		for key := range view.All() {
+			kv.Delete(key)
+			kv.Set(key+100, key)
+			visited++
+		}
+
+		if visited != 100 {
+			t.Errorf("Expected visited keys to be %v, got %v", 100, visited)
+		}
+	})
+}
@@ -0,0 +1,491 @@
+package r9e
+
+import (
+	"cmp"
+	"iter"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// SortedMapKeyValue is a generic key-value store container that is thread-safe and keeps
+// the keys sorted.
+// This use an indexable skip list as underlying data structure and a mutex to protect the data.
+// Set, Get, Delete, Floor, Ceiling, Rank and Select are O(log n), and the iterations are in
+// ascending order of the keys.
+type SortedMapKeyValue[K comparable, T any] struct {
+	mu      sync.RWMutex
+	data    *skiplist[K, T]
+	compare func(a, b K) int
+}
+
+// NewSortedMapKeyValue returns a new SortedMapKeyValue container ordering the keys using
+// their natural order.
+func NewSortedMapKeyValue[K cmp.Ordered, T any]() *SortedMapKeyValue[K, T] {
+	return NewSortedMapKeyValueFunc[K, T](cmp.Compare[K])
+}
+
+// NewSortedMapKeyValueFunc returns a new SortedMapKeyValue container ordering the keys using
+// the given compare function, which returns a negative number when a < b, a positive number
+// when a > b and zero when a == b.
+func NewSortedMapKeyValueFunc[K comparable, T any](compare func(a, b K) int) *SortedMapKeyValue[K, T] {
+	return &SortedMapKeyValue[K, T]{
+		data:    newSkiplist[K, T](compare),
+		compare: compare,
+	}
+}
+
+// Set sets the value associated with the key.
+func (r *SortedMapKeyValue[K, T]) Set(key K, value T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data.set(key, value)
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (r *SortedMapKeyValue[K, T]) GetAndCheck(key K) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n := r.data.get(key); n != nil {
+		return n.value, true
+	}
+	var empty T
+	return empty, false
+}
+
+// Get returns the value associated with the key.
+// If the key does not exist, return zero value of the type.
+func (r *SortedMapKeyValue[K, T]) Get(key K) T {
+	value, _ := r.GetAndCheck(key)
+	return value
+}
+
+// GetAnDelete returns the value associated with the key and delete it if the key exist
+// if the key doesn't exist return the given key value false
+func (r *SortedMapKeyValue[K, T]) GetAnDelete(key K) (T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := r.data.delete(key); n != nil {
+		return n.value, true
+	}
+	var empty T
+	return empty, false
+}
+
+// Delete deletes the value associated with the key.
+func (r *SortedMapKeyValue[K, T]) Delete(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data.delete(key)
+}
+
+// Clear deletes all key-value pairs stored in the container.
+func (r *SortedMapKeyValue[K, T]) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data = newSkiplist[K, T](r.compare)
+}
+
+// Size returns the number of key-value pairs stored in the container.
+func (r *SortedMapKeyValue[K, T]) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.data.size
+}
+
+// IsEmpty returns true if the container is empty.
+func (r *SortedMapKeyValue[K, T]) IsEmpty() bool {
+	return r.Size() == 0
+}
+
+// IsFull returns true if the container has elements.
+func (r *SortedMapKeyValue[K, T]) IsFull() bool {
+	return r.Size() != 0
+}
+
+// ContainsKey returns true if the key is in the container.
+func (r *SortedMapKeyValue[K, T]) ContainsKey(key K) bool {
+	_, ok := r.GetAndCheck(key)
+	return ok
+}
+
+// ContainsValue returns true if the value is in the container.
+func (r *SortedMapKeyValue[K, T]) ContainsValue(value T) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for n := r.data.first(); n != nil; n = n.next[0].node {
+		if reflect.DeepEqual(n.value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the key value associated with the key.
+func (r *SortedMapKeyValue[K, T]) Key(key K) K {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n := r.data.get(key); n != nil {
+		return n.key
+	}
+	var empty K
+	return empty
+}
+
+// Keys returns all keys stored in the container, in ascending order.
+func (r *SortedMapKeyValue[K, T]) Keys() []K {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]K, 0, r.data.size)
+	for n := r.data.first(); n != nil; n = n.next[0].node {
+		keys = append(keys, n.key)
+	}
+	return keys
+}
+
+// Values returns all values stored in the container, in ascending order of the keys.
+func (r *SortedMapKeyValue[K, T]) Values() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := make([]T, 0, r.data.size)
+	for n := r.data.first(); n != nil; n = n.next[0].node {
+		values = append(values, n.value)
+	}
+	return values
+}
+
+// ForEach calls the given function for each key-value pair in the container, in ascending order.
+// The function is called on a snapshot of the container taken without holding the lock,
+// so it can modify the container, but the changes are not seen by the iteration.
+func (r *SortedMapKeyValue[K, T]) ForEach(fn func(key K, value T)) {
+	for _, pair := range r.pairs((*skiplist[K, T]).first, false, nil, 0) {
+		fn(pair.key, pair.value)
+	}
+}
+
+// ForEachKey calls the given function for each key in the container, in ascending order.
+func (r *SortedMapKeyValue[K, T]) ForEachKey(fn func(key K)) {
+	r.ForEach(func(key K, value T) {
+		fn(key)
+	})
+}
+
+// ForEachValue calls the given function for each value in the container, in ascending order
+// of the keys.
+func (r *SortedMapKeyValue[K, T]) ForEachValue(fn func(value T)) {
+	r.ForEach(func(key K, value T) {
+		fn(value)
+	})
+}
+
+// All returns an iterator over the key-value pairs of the container, in ascending order.
+// The iteration copies the key-value pairs in chunks, holding the lock only while each one is
+// copied, so the loop can modify the container and stopping it early doesn't copy the rest.
+// Each key is visited at most once, but the changes done during the iteration may or may not be seen.
+func (r *SortedMapKeyValue[K, T]) All() iter.Seq2[K, T] {
+	return r.chunks((*skiplist[K, T]).first, false, nil)
+}
+
+// Backward returns an iterator over the key-value pairs of the container, in descending order.
+// It has the same guarantees as All.
+func (r *SortedMapKeyValue[K, T]) Backward() iter.Seq2[K, T] {
+	return r.chunks((*skiplist[K, T]).last, true, nil)
+}
+
+// Range returns an iterator over the key-value pairs with a key greater or equal than from
+// and less than to, in ascending order. It has the same guarantees as All.
+func (r *SortedMapKeyValue[K, T]) Range(from, to K) iter.Seq2[K, T] {
+	start := func(s *skiplist[K, T]) *skiplistNode[K, T] {
+		return s.ceiling(from)
+	}
+	before := func(key K) bool {
+		return r.compare(key, to) < 0
+	}
+
+	return r.chunks(start, false, before)
+}
+
+// chunks returns an iterator over the key-value pairs given by pairs, copied in chunks.
+// Each chunk starts after the last key of the previous one, which may have been deleted.
+func (r *SortedMapKeyValue[K, T]) chunks(start func(s *skiplist[K, T]) *skiplistNode[K, T], backward bool, within func(key K) bool) iter.Seq2[K, T] {
+	return chunks(func(after *K, limit int) []kv[K, T] {
+		if after == nil {
+			return r.pairs(start, backward, within, limit)
+		}
+
+		key := *after
+		resume := func(s *skiplist[K, T]) *skiplistNode[K, T] {
+			if backward {
+				n := s.floor(key)
+				if n != nil && r.compare(n.key, key) == 0 {
+					n = n.prev
+				}
+				return n
+			}
+
+			n := s.ceiling(key)
+			if n != nil && r.compare(n.key, key) == 0 {
+				n = n.next[0].node
+			}
+			return n
+		}
+		return r.pairs(resume, backward, within, limit)
+	})
+}
+
+// pairs returns a snapshot of the key-value pairs from the node of the skiplist returned by start,
+// which is called holding the lock, in ascending order or descending if backward is true, until
+// a key is not accepted by within, if it is given, or limit pairs are copied, if it is positive.
+// The functions given to the iteration methods are called on it without holding the lock.
+func (r *SortedMapKeyValue[K, T]) pairs(start func(s *skiplist[K, T]) *skiplistNode[K, T], backward bool, within func(key K) bool, limit int) []kv[K, T] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pairs []kv[K, T]
+	for n := start(r.data); n != nil && (within == nil || within(n.key)) && (limit <= 0 || len(pairs) < limit); {
+		pairs = append(pairs, kv[K, T]{n.key, n.value})
+
+		if backward {
+			n = n.prev
+		} else {
+			n = n.next[0].node
+		}
+	}
+	return pairs
+}
+
+// KeysSeq returns an iterator over the keys of the container, in ascending order.
+// It has the same guarantees as All.
+func (r *SortedMapKeyValue[K, T]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range r.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the container, in ascending order of the keys.
+// It has the same guarantees as All.
+func (r *SortedMapKeyValue[K, T]) ValuesSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, value := range r.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Min returns the key-value pair with the smallest key and true, or false if the container is empty.
+func (r *SortedMapKeyValue[K, T]) Min() (K, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return skiplistPair(r.data.first())
+}
+
+// Max returns the key-value pair with the greatest key and true, or false if the container is empty.
+func (r *SortedMapKeyValue[K, T]) Max() (K, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return skiplistPair(r.data.last())
+}
+
+// Floor returns the key-value pair with the greatest key less or equal than the given key and true,
+// or false if there is no such key.
+func (r *SortedMapKeyValue[K, T]) Floor(key K) (K, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return skiplistPair(r.data.floor(key))
+}
+
+// Ceiling returns the key-value pair with the smallest key greater or equal than the given key and true,
+// or false if there is no such key.
+func (r *SortedMapKeyValue[K, T]) Ceiling(key K) (K, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return skiplistPair(r.data.ceiling(key))
+}
+
+// Rank returns the number of keys less than the given key, which is the zero based position
+// of the key when it exist.
+func (r *SortedMapKeyValue[K, T]) Rank(key K) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.data.rank(key)
+}
+
+// Select returns the key-value pair at the zero based position i in ascending order and true,
+// or false if i is out of range.
+func (r *SortedMapKeyValue[K, T]) Select(i int) (K, T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return skiplistPair(r.data.index(i))
+}
+
+// Clone returns a new SortedMapKeyValue with a copy of the underlying data.
+func (r *SortedMapKeyValue[K, T]) Clone() *SortedMapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return true
+	})
+}
+
+// CloneAndClear returns a new SortedMapKeyValue with a copy of the underlying data and clears the container.
+func (r *SortedMapKeyValue[K, T]) CloneAndClear() *SortedMapKeyValue[K, T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := &SortedMapKeyValue[K, T]{
+		data:    r.data,
+		compare: r.compare,
+	}
+	r.data = newSkiplist[K, T](r.compare)
+	return clone
+}
+
+// DeepEqual returns true if the given kv is deep equal to the SortedMapKeyValue container.
+func (r *SortedMapKeyValue[K, T]) DeepEqual(kv *SortedMapKeyValue[K, T]) bool {
+	if r == kv {
+		return true
+	}
+
+	return reflect.DeepEqual(r.Keys(), kv.Keys()) && reflect.DeepEqual(r.Values(), kv.Values())
+}
+
+// Map returns a new SortedMapKeyValue after applying the given function fn to each key-value pair.
+// The new container uses the same order.
+func (r *SortedMapKeyValue[K, T]) Map(fn func(key K, value T) (newKey K, newValue T)) *SortedMapKeyValue[K, T] {
+	m := r.newEmpty()
+	r.ForEach(func(key K, value T) {
+		m.data.set(fn(key, value))
+	})
+	return m
+}
+
+// MapKey returns a new SortedMapKeyValue after applying the given function fn to each key.
+func (r *SortedMapKeyValue[K, T]) MapKey(fn func(key K) K) *SortedMapKeyValue[K, T] {
+	return r.Map(func(key K, value T) (K, T) {
+		return fn(key), value
+	})
+}
+
+// MapValue returns a new SortedMapKeyValue after applying the given function fn to each value.
+func (r *SortedMapKeyValue[K, T]) MapValue(fn func(value T) T) *SortedMapKeyValue[K, T] {
+	return r.Map(func(key K, value T) (K, T) {
+		return key, fn(value)
+	})
+}
+
+// Filter returns a new SortedMapKeyValue after applying the given function fn to each key-value pair.
+func (r *SortedMapKeyValue[K, T]) Filter(fn func(key K, value T) bool) *SortedMapKeyValue[K, T] {
+	m, _ := r.Partition(fn)
+	return m
+}
+
+// FilterKey returns a new SortedMapKeyValue after applying the given function fn to each key.
+func (r *SortedMapKeyValue[K, T]) FilterKey(fn func(key K) bool) *SortedMapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// FilterValue returns a new SortedMapKeyValue after applying the given function fn to each value.
+func (r *SortedMapKeyValue[K, T]) FilterValue(fn func(value T) bool) *SortedMapKeyValue[K, T] {
+	return r.Filter(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// Partition returns two new SortedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each element.
+func (r *SortedMapKeyValue[K, T]) Partition(fn func(key K, value T) bool) (match, others *SortedMapKeyValue[K, T]) {
+	match = r.newEmpty()
+	others = r.newEmpty()
+
+	r.ForEach(func(key K, value T) {
+		if fn(key, value) {
+			match.data.set(key, value)
+		} else {
+			others.data.set(key, value)
+		}
+	})
+	return match, others
+}
+
+// PartitionKey returns two new SortedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each key.
+func (r *SortedMapKeyValue[K, T]) PartitionKey(fn func(key K) bool) (match, others *SortedMapKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(key)
+	})
+}
+
+// PartitionValue returns two new SortedMapKeyValue. One with all the elements that satisfy the predicate and
+// another with the rest. The predicate is applied to each value.
+func (r *SortedMapKeyValue[K, T]) PartitionValue(fn func(value T) bool) (match, others *SortedMapKeyValue[K, T]) {
+	return r.Partition(func(key K, value T) bool {
+		return fn(value)
+	})
+}
+
+// SortKeys returns a []*K (keys) after sorting the keys using the given sortFn function.
+// The keys are already sorted by the container, use Keys to get them in ascending order.
+func (r *SortedMapKeyValue[K, T]) SortKeys(sortFn func(key1, key2 K) bool) []*K {
+	keys := r.Keys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return sortFn(keys[i], keys[j])
+	})
+
+	m := make([]*K, len(keys))
+	for i := range keys {
+		m[i] = &keys[i]
+	}
+	return m
+}
+
+// SortValues returns a []*T (values) after sorting the values using given function sortFn.
+func (r *SortedMapKeyValue[K, T]) SortValues(sortFn func(value1, value2 T) bool) []*T {
+	values := r.Values()
+	sort.SliceStable(values, func(i, j int) bool {
+		return sortFn(values[i], values[j])
+	})
+
+	m := make([]*T, len(values))
+	for i := range values {
+		m[i] = &values[i]
+	}
+	return m
+}
+
+// newEmpty returns a new empty SortedMapKeyValue with the same order.
+func (r *SortedMapKeyValue[K, T]) newEmpty() *SortedMapKeyValue[K, T] {
+	return NewSortedMapKeyValueFunc[K, T](r.compare)
+}
+
+// skiplistPair returns the key-value pair of the node and true, or false if the node is nil.
+func skiplistPair[K comparable, T any](n *skiplistNode[K, T]) (K, T, bool) {
+	if n == nil {
+		var key K
+		var value T
+		return key, value, false
+	}
+	return n.key, n.value, true
+}
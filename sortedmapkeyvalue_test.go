@@ -0,0 +1,298 @@
+package r9e
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSet_SortedMapKeyValue(t *testing.T) {
+	t.Run("test Set keeps the keys sorted", func(t *testing.T) {
+		kv := NewSortedMapKeyValue[int, int]()
+
+		expected := make([]int, 0, 1000)
+		for _, i := range rand.Perm(1000) {
+			kv.Set(i, i*10)
+			expected = append(expected, i)
+		}
+		slices.Sort(expected)
+
+		if keys := kv.Keys(); !slices.Equal(keys, expected) {
+			t.Errorf("Expected keys to be sorted, got %v", keys)
+		}
+		if kv.Size() != 1000 {
+			t.Errorf("Expected size to be %v, got %v", 1000, kv.Size())
+		}
+		if kv.Get(500) != 5000 {
+			t.Errorf("Expected value to be %v, got %v", 5000, kv.Get(500))
+		}
+	})
+
+	t.Run("test Set of an existing key replaces the value", func(t *testing.T) {
+		kv := NewSortedMapKeyValue[string, int]()
+		kv.Set("a", 1)
+		kv.Set("a", 2)
+
+		if kv.Size() != 1 || kv.Get("a") != 2 {
+			t.Errorf("Expected size and value to be %v and %v, got %v and %v", 1, 2, kv.Size(), kv.Get("a"))
+		}
+	})
+
+	t.Run("test Delete keeps the keys sorted", func(t *testing.T) {
+		kv := NewSortedMapKeyValue[int, int]()
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+		}
+		for i := 0; i < 100; i += 2 {
+			kv.Delete(i)
+		}
+
+		keys := kv.Keys()
+		if len(keys) != 50 {
+			t.Fatalf("Expected size to be %v, got %v", 50, len(keys))
+		}
+		for i, key := range keys {
+			if key != i*2+1 {
+				t.Fatalf("Expected key to be %v, got %v", i*2+1, key)
+			}
+		}
+	})
+}
+
+func TestRankAndSelect_SortedMapKeyValue(t *testing.T) {
+	t.Run("test Rank and Select after random changes", func(t *testing.T) {
+		kv := NewSortedMapKeyValue[int, int]()
+		present := make(map[int]bool)
+
+		for i := 0; i < 5000; i++ {
+			key := rand.Intn(500)
+			if rand.Intn(3) == 0 {
+				kv.Delete(key)
+				delete(present, key)
+			} else {
+				kv.Set(key, key)
+				present[key] = true
+			}
+		}
+
+		expected := make([]int, 0, len(present))
+		for key := range present {
+			expected = append(expected, key)
+		}
+		slices.Sort(expected)
+
+		if kv.Size() != len(expected) {
+			t.Fatalf("Expected size to be %v, got %v", len(expected), kv.Size())
+		}
+		for i, key := range expected {
+			if rank := kv.Rank(key); rank != i {
+				t.Fatalf("Expected rank of %v to be %v, got %v", key, i, rank)
+			}
+			if got, _, ok := kv.Select(i); !ok || got != key {
+				t.Fatalf("Expected Select(%v) to be %v, got %v", i, key, got)
+			}
+		}
+	})
+
+	t.Run("test Rank of missing keys and Select out of range", func(t *testing.T) {
+		kv := NewSortedMapKeyValue[int, string]()
+		kv.Set(10, "ten")
+		kv.Set(20, "twenty")
+		kv.Set(30, "thirty")
+
+		if rank := kv.Rank(5); rank != 0 {
+			t.Errorf("Expected rank to be %v, got %v", 0, rank)
+		}
+		if rank := kv.Rank(25); rank != 2 {
+			t.Errorf("Expected rank to be %v, got %v", 2, rank)
+		}
+		if rank := kv.Rank(35); rank != 3 {
+			t.Errorf("Expected rank to be %v, got %v", 3, rank)
+		}
+		if _, _, ok := kv.Select(-1); ok {
+			t.Errorf("Expected Select(%v) to be %v", -1, false)
+		}
+		if _, _, ok := kv.Select(3); ok {
+			t.Errorf("Expected Select(%v) to be %v", 3, false)
+		}
+	})
+}
+
+func TestFloorAndCeiling_SortedMapKeyValue(t *testing.T) {
+	kv := NewSortedMapKeyValue[int, string]()
+	kv.Set(10, "ten")
+	kv.Set(20, "twenty")
+	kv.Set(30, "thirty")
+
+	tests := []struct {
+		key          int
+		floor        int
+		floorFound   bool
+		ceiling      int
+		ceilingFound bool
+	}{
+		{5, 0, false, 10, true},
+		{10, 10, true, 10, true},
+		{15, 10, true, 20, true},
+		{30, 30, true, 30, true},
+		{35, 30, true, 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run("test Floor and Ceiling", func(t *testing.T) {
+			if key, _, ok := kv.Floor(tc.key); key != tc.floor || ok != tc.floorFound {
+				t.Errorf("Expected Floor(%v) to be %v %v, got %v %v", tc.key, tc.floor, tc.floorFound, key, ok)
+			}
+			if key, _, ok := kv.Ceiling(tc.key); key != tc.ceiling || ok != tc.ceilingFound {
+				t.Errorf("Expected Ceiling(%v) to be %v %v, got %v %v", tc.key, tc.ceiling, tc.ceilingFound, key, ok)
+			}
+		})
+	}
+
+	t.Run("test Min and Max", func(t *testing.T) {
+		if key, value, ok := kv.Min(); !ok || key != 10 || value != "ten" {
+			t.Errorf("Expected Min to be %v %v, got %v %v", 10, "ten", key, value)
+		}
+		if key, value, ok := kv.Max(); !ok || key != 30 || value != "thirty" {
+			t.Errorf("Expected Max to be %v %v, got %v %v", 30, "thirty", key, value)
+		}
+
+		empty := NewSortedMapKeyValue[int, string]()
+		if _, _, ok := empty.Min(); ok {
+			t.Errorf("Expected Min to be %v, got %v", false, ok)
+		}
+		if _, _, ok := empty.Max(); ok {
+			t.Errorf("Expected Max to be %v, got %v", false, ok)
+		}
+	})
+}
+
+func TestRangeAndBackward_SortedMapKeyValue(t *testing.T) {
+	kv := NewSortedMapKeyValue[int, int]()
+	for i := 9; i >= 0; i-- {
+		kv.Set(i*10, i)
+	}
+
+	t.Run("test Range", func(t *testing.T) {
+		var keys []int
+		for key := range kv.Range(25, 60) {
+			keys = append(keys, key)
+		}
+
+		if expected := []int{30, 40, 50}; !slices.Equal(keys, expected) {
+			t.Errorf("Expected keys to be %v, got %v", expected, keys)
+		}
+	})
+
+	t.Run("test Range stops early", func(t *testing.T) {
+		var keys []int
+		for key := range kv.Range(0, 100) {
+			keys = append(keys, key)
+			if len(keys) == 2 {
+				break
+			}
+		}
+
+		if expected := []int{0, 10}; !slices.Equal(keys, expected) {
+			t.Errorf("Expected keys to be %v, got %v", expected, keys)
+		}
+	})
+
+	t.Run("test Backward", func(t *testing.T) {
+		var keys []int
+		for key := range kv.Backward() {
+			keys = append(keys, key)
+		}
+
+		if expected := []int{90, 80, 70, 60, 50, 40, 30, 20, 10, 0}; !slices.Equal(keys, expected) {
+			t.Errorf("Expected keys to be %v, got %v", expected, keys)
+		}
+	})
+
+	t.Run("test Backward after deleting the last key", func(t *testing.T) {
+		clone := kv.Clone()
+		clone.Delete(90)
+		clone.Delete(0)
+
+		var keys []int
+		for key := range clone.Backward() {
+			keys = append(keys, key)
+		}
+
+		if expected := []int{80, 70, 60, 50, 40, 30, 20, 10}; !slices.Equal(keys, expected) {
+			t.Errorf("Expected keys to be %v, got %v", expected, keys)
+		}
+	})
+}
+
+func TestConcurrentIteration_SortedMapKeyValue(t *testing.T) {
+	t.Run("test the iterations run concurrently with Clear", func(t *testing.T) {
+		kv := NewSortedMapKeyValue[int, int]()
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				kv.Set(i%10, i)
+				if i%100 == 0 {
+					kv.Clear()
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				kv.ForEach(func(key, value int) {})
+				for range kv.All() {
+				}
+				for range kv.Backward() {
+				}
+				for range kv.Range(2, 8) {
+				}
+			}
+		}()
+		wg.Wait()
+
+		if kv.Size() != 10 {
+			t.Errorf("Expected size to be %v, got %v", 10, kv.Size())
+		}
+	})
+}
+
+func TestNewSortedMapKeyValueFunc(t *testing.T) {
+	type version struct {
+		major, minor int
+	}
+
+	kv := NewSortedMapKeyValueFunc[version, string](func(a, b version) int {
+		if a.major != b.major {
+			return a.major - b.major
+		}
+		return a.minor - b.minor
+	})
+	kv.Set(version{1, 10}, "1.10")
+	kv.Set(version{1, 2}, "1.2")
+	kv.Set(version{0, 9}, "0.9")
+	kv.Set(version{2, 0}, "2.0")
+
+	t.Run("test custom comparator", func(t *testing.T) {
+		if values := strings.Join(kv.Values(), " "); values != "0.9 1.2 1.10 2.0" {
+			t.Errorf("Expected values to be %v, got %v", "0.9 1.2 1.10 2.0", values)
+		}
+	})
+
+	t.Run("test Filter and Map keep the comparator", func(t *testing.T) {
+		got := kv.FilterKey(func(key version) bool {
+			return key.major == 1
+		}).MapKey(func(key version) version {
+			return version{key.major, -key.minor}
+		})
+
+		if values := strings.Join(got.Values(), " "); values != "1.10 1.2" {
+			t.Errorf("Expected values to be %v, got %v", "1.10 1.2", values)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package r9e
+
+import (
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the statistics of a container, enabled by WithStats or WithSMapStats.
+// The counters are cumulative since the container was created.
+type Stats struct {
+	// Gets counts the lookups done by Get, GetAndCheck and GetOrSet, Hits and Misses split them
+	// by whether the key was found.
+	Gets   uint64 `json:"gets"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+
+	// Sets counts the values stored.
+	Sets uint64 `json:"sets"`
+
+	// Deletes counts the key-value pairs deleted, including the ones removed by Clear.
+	Deletes uint64 `json:"deletes"`
+
+	// Evictions counts the key-value pairs evicted from a bounded container.
+	Evictions uint64 `json:"evictions"`
+
+	// Expirations counts the expired key-value pairs removed from the container.
+	Expirations uint64 `json:"expirations"`
+
+	// Size is the number of key-value pairs stored in the container.
+	Size int `json:"size"`
+}
+
+// HitRatio returns the ratio of the lookups that found the key, or zero if there were no lookups.
+func (s Stats) HitRatio() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Gets)
+}
+
+// add returns the sum of both statistics.
+func (s Stats) add(other Stats) Stats {
+	return Stats{
+		Gets:        s.Gets + other.Gets,
+		Hits:        s.Hits + other.Hits,
+		Misses:      s.Misses + other.Misses,
+		Sets:        s.Sets + other.Sets,
+		Deletes:     s.Deletes + other.Deletes,
+		Evictions:   s.Evictions + other.Evictions,
+		Expirations: s.Expirations + other.Expirations,
+		Size:        s.Size + other.Size,
+	}
+}
+
+// stats holds the counters of a container. A nil stats counts nothing, so the containers
+// without statistics only pay a nil check.
+type stats struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	sets        atomic.Uint64
+	deletes     atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+// newStats returns a new stats if enabled is true, otherwise nil.
+func newStats(enabled bool) *stats {
+	if !enabled {
+		return nil
+	}
+	return &stats{}
+}
+
+// get counts a lookup.
+func (s *stats) get(hit bool) {
+	if s == nil {
+		return
+	}
+
+	if hit {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+// set counts a stored value.
+func (s *stats) set() {
+	if s != nil {
+		s.sets.Add(1)
+	}
+}
+
+// delete counts n deleted key-value pairs.
+func (s *stats) delete(n int) {
+	if s != nil {
+		s.deletes.Add(uint64(n))
+	}
+}
+
+// remove counts a key-value pair removed by the given operation.
+func (s *stats) remove(op Op) {
+	if s == nil {
+		return
+	}
+
+	switch op {
+	case OpEvict:
+		s.evictions.Add(1)
+	case OpExpire:
+		s.expirations.Add(1)
+	default:
+		s.deletes.Add(1)
+	}
+}
+
+// snapshot returns the current value of the counters.
+func (s *stats) snapshot(size int) Stats {
+	if s == nil {
+		return Stats{Size: size}
+	}
+
+	hits, misses := s.hits.Load(), s.misses.Load()
+	return Stats{
+		Gets:        hits + misses,
+		Hits:        hits,
+		Misses:      misses,
+		Sets:        s.sets.Load(),
+		Deletes:     s.deletes.Load(),
+		Evictions:   s.evictions.Load(),
+		Expirations: s.expirations.Load(),
+		Size:        size,
+	}
+}
+
+// WithStats enables the statistics of the MapKeyValue container, returned by Stats.
+func WithStats() MapKeyValueOptions {
+	return func(kv *mapKeyValueOptions) {
+		kv.stats = true
+	}
+}
+
+// WithSMapStats enables the statistics of the SMapKeyValue container, returned by Stats.
+func WithSMapStats() SMapKeyValueOptions {
+	return func(kv *smapKeyValueOptions) {
+		kv.stats = true
+	}
+}
+
+// Stats returns a snapshot of the statistics of the container.
+// Only Size is set unless the container was created using WithStats.
+func (r *MapKeyValue[K, T]) Stats() Stats {
+	return r.stats.snapshot(r.Size())
+}
+
+// Stats returns a snapshot of the statistics of the container.
+// Only Size is set unless the container was created using WithSMapStats.
+func (r *SMapKeyValue[K, T]) Stats() Stats {
+	return r.stats.snapshot(r.Size())
+}
+
+// Stats returns the sum of the statistics of the shards.
+// Only Size is set unless the shards were created using WithShardOptions(WithStats()).
+func (r *ShardedMapKeyValue[K, T]) Stats() Stats {
+	var s Stats
+	for _, shard := range r.shards {
+		s = s.add(shard.Stats())
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package r9e
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultMetricsNamespace is the prefix of the metric names used when the namespace is empty.
+const DefaultMetricsNamespace = "r9e"
+
+// StatsProvider is implemented by the containers returning their statistics.
+type StatsProvider interface {
+	Stats() Stats
+}
+
+// PublishExpvar publishes the statistics of the container as an expvar variable with the given name,
+// encoded as a JSON object and refreshed every time the variable is read.
+// Like expvar.Publish, it panics if the name is already used.
+func PublishExpvar(name string, provider StatsProvider) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return provider.Stats()
+	}))
+}
+
+// metric is a metric of the Prometheus exposition.
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value func(s Stats) float64
+}
+
+var statsMetrics = []metric{
+	{"gets_total", "counter", "Number of lookups.", func(s Stats) float64 { return float64(s.Gets) }},
+	{"hits_total", "counter", "Number of lookups that found the key.", func(s Stats) float64 { return float64(s.Hits) }},
+	{"misses_total", "counter", "Number of lookups that didn't find the key.", func(s Stats) float64 { return float64(s.Misses) }},
+	{"sets_total", "counter", "Number of values stored.", func(s Stats) float64 { return float64(s.Sets) }},
+	{"deletes_total", "counter", "Number of key-value pairs deleted.", func(s Stats) float64 { return float64(s.Deletes) }},
+	{"evictions_total", "counter", "Number of key-value pairs evicted.", func(s Stats) float64 { return float64(s.Evictions) }},
+	{"expirations_total", "counter", "Number of expired key-value pairs removed.", func(s Stats) float64 { return float64(s.Expirations) }},
+	{"size", "gauge", "Number of key-value pairs stored.", func(s Stats) float64 { return float64(s.Size) }},
+}
+
+// NewMetricsHandler returns a http.Handler rendering the statistics of the containers in the
+// Prometheus text exposition format. Each container is identified by the label container with
+// its name in the map, and the metric names are prefixed by the namespace, DefaultMetricsNamespace
+// if it is empty.
+func NewMetricsHandler(namespace string, containers map[string]StatsProvider) http.Handler {
+	if namespace == "" {
+		namespace = DefaultMetricsNamespace
+	}
+
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		snapshots := make([]Stats, len(names))
+		for i, name := range names {
+			snapshots[i] = containers[name].Stats()
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, namespace, names, snapshots)
+	})
+}
+
+// writeMetrics writes the statistics of the containers in the Prometheus text exposition format.
+func writeMetrics(w io.Writer, namespace string, names []string, snapshots []Stats) error {
+	bw := bufio.NewWriter(w)
+
+	for _, m := range statsMetrics {
+		name := namespace + "_" + m.name
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, m.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, m.kind)
+
+		for i, container := range names {
+			fmt.Fprintf(bw, "%s{container=\"%s\"} %g\n", name, escapeLabel(container), m.value(snapshots[i]))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// labelEscaper escapes the label values of the Prometheus text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel returns the label value escaped.
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
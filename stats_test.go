@@ -0,0 +1,152 @@
+package r9e
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats_MapKeyValue(t *testing.T) {
+	t.Run("test Stats counts the operations", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int](WithStats(), WithMaxEntries(2))
+		now := time.Now()
+		kv.clock = func() time.Time { return now }
+
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+		kv.Get("one")
+		kv.GetAndCheck("three")
+		kv.Set("three", 3) // evicts two
+		kv.Delete("one")
+		kv.SetWithTTL("four", 4, time.Second)
+		now = now.Add(time.Minute)
+		kv.DeleteExpired()
+		kv.Clear()
+
+		expected := Stats{Gets: 2, Hits: 1, Misses: 1, Sets: 4, Deletes: 2, Evictions: 1, Expirations: 1}
+		if s := kv.Stats(); s != expected {
+			t.Errorf("Expected stats to be %+v, got %+v", expected, s)
+		}
+		if ratio := kv.Stats().HitRatio(); ratio != 0.5 {
+			t.Errorf("Expected hit ratio to be %v, got %v", 0.5, ratio)
+		}
+	})
+
+	t.Run("test DeepEqual doesn't count the reads", func(t *testing.T) {
+		kv1 := NewMapKeyValue[string, int](WithStats())
+		kv2 := NewMapKeyValue[string, int](WithStats())
+		kv1.Set("one", 1)
+		kv2.Set("two", 2)
+
+		if kv1.DeepEqual(kv2) {
+			t.Errorf("Expected DeepEqual to be %v, got %v", false, true)
+		}
+
+		expected := Stats{Sets: 1, Size: 1}
+		if s := kv2.Stats(); s != expected {
+			t.Errorf("Expected stats to be %+v, got %+v", expected, s)
+		}
+	})
+
+	t.Run("test Stats without WithStats", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
+		kv.Get("one")
+
+		if s := kv.Stats(); s != (Stats{Size: 1}) {
+			t.Errorf("Expected stats to be %+v, got %+v", Stats{Size: 1}, s)
+		}
+	})
+
+	t.Run("test Stats of ShardedMapKeyValue", func(t *testing.T) {
+		kv := NewShardedMapKeyValue[int, int](WithShards(4), WithShardOptions(WithStats()))
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+			kv.Get(i)
+		}
+
+		s := kv.Stats()
+		if s.Sets != 100 || s.Hits != 100 || s.Size != 100 {
+			t.Errorf("Expected sets, hits and size to be %v, got %+v", 100, s)
+		}
+	})
+}
+
+func TestStats_SMapKeyValue(t *testing.T) {
+	t.Run("test Stats counts the operations", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int](WithSMapStats())
+
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+		kv.Get("one")
+		kv.GetAndCheck("three")
+		kv.GetOrSet("three", 3)
+		kv.Delete("one")
+		kv.CompareAndDelete("two", 2, func(a, b int) bool { return a == b })
+		kv.Set("four", 4)
+		kv.Clear()
+
+		expected := Stats{Gets: 3, Hits: 1, Misses: 2, Sets: 4, Deletes: 4}
+		if s := kv.Stats(); s != expected {
+			t.Errorf("Expected stats to be %+v, got %+v", expected, s)
+		}
+	})
+}
+
+func TestPublishExpvar(t *testing.T) {
+	kv := NewMapKeyValue[string, int](WithStats())
+	PublishExpvar("r9e_test_stats", kv)
+
+	kv.Set("one", 1)
+
+	var s Stats
+	if err := json.Unmarshal([]byte(expvar.Get("r9e_test_stats").String()), &s); err != nil {
+		t.Fatalf("Expected error to be %v, got %v", nil, err)
+	}
+	if s.Sets != 1 || s.Size != 1 {
+		t.Errorf("Expected sets and size to be %v, got %+v", 1, s)
+	}
+}
+
+func TestNewMetricsHandler(t *testing.T) {
+	users := NewMapKeyValue[string, int](WithStats())
+	users.Set("alice", 1)
+	users.Get("alice")
+
+	sessions := NewSMapKeyValue[string, int](WithSMapStats())
+	sessions.Get("unknown")
+
+	handler := NewMetricsHandler("", map[string]StatsProvider{
+		"users":    users,
+		"sessions": sessions,
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Expected content type to be %v, got %v", "text/plain; version=0.0.4", ct)
+	}
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE r9e_hits_total counter",
+		"# TYPE r9e_size gauge",
+		`r9e_hits_total{container="users"} 1`,
+		`r9e_misses_total{container="sessions"} 1`,
+		`r9e_size{container="users"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected metrics to contain %q, got %v", line, body)
+		}
+	}
+
+	// the containers are sorted by name
+	if strings.Index(body, `container="sessions"`) > strings.Index(body, `container="users"`) {
+		t.Errorf("Expected containers to be sorted, got %v", body)
+	}
+}
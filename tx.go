@@ -0,0 +1,240 @@
+package r9e
+
+import (
+	"errors"
+)
+
+// maxTxRetries is the number of times the transactions of SMapKeyValue are retried on conflict,
+// before they run holding the exclusive lock.
+const maxTxRetries = 16
+
+var (
+	// ErrTxReadOnly is returned when a read-only transaction, started by View, is modified.
+	ErrTxReadOnly = errors.New("r9e: transaction is read-only")
+
+	// ErrTxClosed is returned when a transaction is used after its function returned.
+	ErrTxClosed = errors.New("r9e: transaction is closed")
+)
+
+// Tx is a transaction started by the Update and View methods of the containers.
+// The reads see a consistent view of the container and the changes of the transaction.
+// A Tx must be used only inside the function given to Update or View.
+type Tx[K comparable, T any] struct {
+	get      func(key K) (T, bool)
+	writes   map[K]txWrite[T]
+	keys     []K
+	writable bool
+	closed   bool
+}
+
+// txWrite is a change of a key done by a transaction.
+type txWrite[T any] struct {
+	value   T
+	deleted bool
+}
+
+// newTx returns a new transaction reading the container using get.
+func newTx[K comparable, T any](get func(key K) (T, bool), writable bool) *Tx[K, T] {
+	return &Tx[K, T]{
+		get:      get,
+		writes:   make(map[K]txWrite[T]),
+		writable: writable,
+	}
+}
+
+// GetAndCheck returns the value associated with the key if this exist also a
+// boolean value if this exist of not.
+func (tx *Tx[K, T]) GetAndCheck(key K) (T, bool) {
+	if w, ok := tx.writes[key]; ok {
+		return w.value, !w.deleted
+	}
+	return tx.get(key)
+}
+
+// Get returns the value associated with the key.
+// If the key does not exist, return zero value of the type.
+func (tx *Tx[K, T]) Get(key K) T {
+	value, _ := tx.GetAndCheck(key)
+	return value
+}
+
+// ContainsKey returns true if the key is in the container.
+func (tx *Tx[K, T]) ContainsKey(key K) bool {
+	_, ok := tx.GetAndCheck(key)
+	return ok
+}
+
+// Set sets the value associated with the key when the transaction is committed.
+func (tx *Tx[K, T]) Set(key K, value T) error {
+	return tx.write(key, txWrite[T]{value: value})
+}
+
+// Delete deletes the value associated with the key when the transaction is committed.
+func (tx *Tx[K, T]) Delete(key K) error {
+	return tx.write(key, txWrite[T]{deleted: true})
+}
+
+// write records the change of the key, keeping the order of the first change of each key.
+func (tx *Tx[K, T]) write(key K, w txWrite[T]) error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+	if !tx.writable {
+		return ErrTxReadOnly
+	}
+
+	if _, ok := tx.writes[key]; !ok {
+		tx.keys = append(tx.keys, key)
+	}
+	tx.writes[key] = w
+	return nil
+}
+
+// Update runs fn in a read-write transaction holding the write lock of the container.
+// If fn returns nil the changes are committed atomically, otherwise they are discarded
+// and the error is returned.
+func (r *MapKeyValue[K, T]) Update(fn func(tx *Tx[K, T]) error) error {
+	defer r.notify()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := newTx(r.peek, true)
+	err := fn(tx)
+	tx.closed = true
+	if err != nil {
+		return err
+	}
+
+	if r.wal != nil {
+		r.wal.begin()
+		defer r.wal.commit()
+	}
+
+	expireAt := r.deadline(r.defaultTTL)
+	for _, key := range tx.keys {
+		if w := tx.writes[key]; w.deleted {
+			r.delete(key, OpDelete)
+		} else {
+			r.set(key, w.value, expireAt)
+		}
+	}
+	return nil
+}
+
+// View runs fn in a read-only transaction holding the read lock of the container.
+// The error returned by fn is returned.
+func (r *MapKeyValue[K, T]) View(fn func(tx *Tx[K, T]) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tx := newTx(r.peek, false)
+	defer func() {
+		tx.closed = true
+	}()
+
+	return fn(tx)
+}
+
+// Update runs fn in a read-write transaction using optimistic concurrency.
+// fn runs without locking the container, and if it returns nil, the changes are committed
+// atomically unless the container was modified meanwhile. In that case fn is run again,
+// so it must not have side effects. After several conflicts fn runs holding the exclusive lock,
+// which waits for the writes in progress and blocks the new ones, so it must not modify the container.
+// If fn returns an error the changes are discarded and the error is returned.
+// The commit is atomic: the reads of single keys, like Get or ContainsKey, wait while it is applied.
+// The iterations, like All or ForEach, don't give a point-in-time view of the container, so they can
+// see the changes of a commit done while they run. Use View or Snapshot to read several keys consistently.
+func (r *SMapKeyValue[K, T]) Update(fn func(tx *Tx[K, T]) error) error {
+	for i := 0; i < maxTxRetries; i++ {
+		start := r.seq.Load()
+
+		tx := newTx(r.GetAndCheck, true)
+		err := fn(tx)
+		tx.closed = true
+		if err != nil {
+			return err
+		}
+
+		if r.commit(tx, start) {
+			return nil
+		}
+	}
+
+	defer r.notify()
+
+	r.lock()
+	defer r.unlock()
+
+	tx := newTx(r.GetAndCheck, true)
+	err := fn(tx)
+	tx.closed = true
+	if err != nil {
+		return err
+	}
+
+	r.apply(tx)
+	return nil
+}
+
+// View runs fn in a read-only transaction using optimistic concurrency.
+// If the container is modified while fn runs, fn is run again, so it must not have side effects.
+// After several conflicts fn runs holding the exclusive lock, like Update, so it must not modify
+// the container. The error returned by fn is returned.
+func (r *SMapKeyValue[K, T]) View(fn func(tx *Tx[K, T]) error) error {
+	for i := 0; i < maxTxRetries; i++ {
+		start := r.seq.Load()
+
+		tx := newTx(r.GetAndCheck, false)
+		err := fn(tx)
+		tx.closed = true
+		if err != nil {
+			return err
+		}
+
+		// the writes in progress are not counted by seq yet, and could have been partially read
+		if r.writing.Load() == 0 && r.seq.Load() == start {
+			return nil
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := newTx(r.GetAndCheck, false)
+	defer func() {
+		tx.closed = true
+	}()
+
+	return fn(tx)
+}
+
+// commit applies the changes of the transaction if the container wasn't modified since start.
+func (r *SMapKeyValue[K, T]) commit(tx *Tx[K, T], start uint64) bool {
+	defer r.notify()
+
+	r.lock()
+	defer r.unlock()
+
+	if r.seq.Load() != start {
+		return false
+	}
+
+	r.apply(tx)
+	return true
+}
+
+// apply applies the changes of the transaction. The reads of single keys wait until it finishes,
+// see load. The caller must hold the exclusive lock.
+func (r *SMapKeyValue[K, T]) apply(tx *Tx[K, T]) {
+	r.committing.Store(true)
+	defer r.committing.Store(false)
+
+	for _, key := range tx.keys {
+		if w := tx.writes[key]; w.deleted {
+			r.delete(key)
+		} else {
+			r.set(key, w.value)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package r9e
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdate_MapKeyValue(t *testing.T) {
+	t.Run("test Update commits the changes", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
+		kv.Set("two", 2)
+
+		err := kv.Update(func(tx *Tx[string, int]) error {
+			if err := tx.Set("three", tx.Get("one")+tx.Get("two")); err != nil {
+				return err
+			}
+			if err := tx.Delete("one"); err != nil {
+				return err
+			}
+
+			if tx.ContainsKey("one") {
+				t.Errorf("Expected ContainsKey to be %v, got %v", false, true)
+			}
+			if value, ok := tx.GetAndCheck("three"); !ok || value != 3 {
+				t.Errorf("Expected value to be %v, got %v", 3, value)
+			}
+			if _, ok := kv.data["three"]; ok {
+				t.Errorf("Expected the changes to be applied on commit")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if kv.ContainsKey("one") || kv.Get("three") != 3 || kv.Size() != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two", "three"}, kv.Keys())
+		}
+	})
+
+	t.Run("test Update rolls back when the function returns an error", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
+
+		failure := errors.New("failure")
+		err := kv.Update(func(tx *Tx[string, int]) error {
+			tx.Set("one", 11)
+			tx.Set("two", 2)
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected error to be %v, got %v", failure, err)
+		}
+
+		if kv.Get("one") != 1 || kv.ContainsKey("two") {
+			t.Errorf("Expected the changes to be discarded, got %v", kv.Keys())
+		}
+	})
+
+	t.Run("test View is read-only and the Tx can't be used after the function", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("one", 1)
+
+		var saved *Tx[string, int]
+		err := kv.View(func(tx *Tx[string, int]) error {
+			saved = tx
+			if tx.Get("one") != 1 {
+				t.Errorf("Expected value to be %v, got %v", 1, tx.Get("one"))
+			}
+			return tx.Set("two", 2)
+		})
+		if !errors.Is(err, ErrTxReadOnly) {
+			t.Errorf("Expected error to be %v, got %v", ErrTxReadOnly, err)
+		}
+
+		if err := saved.Delete("one"); !errors.Is(err, ErrTxClosed) {
+			t.Errorf("Expected error to be %v, got %v", ErrTxClosed, err)
+		}
+	})
+
+	t.Run("test View never sees a partially applied Update", func(t *testing.T) {
+		kv := NewMapKeyValue[string, int]()
+		kv.Set("a", 100)
+		kv.Set("b", 0)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				kv.Update(func(tx *Tx[string, int]) error {
+					tx.Set("a", tx.Get("a")-1)
+					tx.Set("b", tx.Get("b")+1)
+					return nil
+				})
+			}
+		}()
+
+		for i := 0; i < 1000; i++ {
+			kv.View(func(tx *Tx[string, int]) error {
+				if sum := tx.Get("a") + tx.Get("b"); sum != 100 {
+					t.Errorf("Expected sum to be %v, got %v", 100, sum)
+				}
+				return nil
+			})
+		}
+		wg.Wait()
+	})
+
+	t.Run("test Update is replayed from the write-ahead log", func(t *testing.T) {
+		dir := t.TempDir()
+
+		kv, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		kv.Set("one", 1)
+		kv.Update(func(tx *Tx[string, int]) error {
+			tx.Delete("one")
+			tx.Set("two", 2)
+			return nil
+		})
+		kv.Close()
+
+		reopened, err := OpenMapKeyValue[string, int](dir)
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		defer reopened.Close()
+
+		if reopened.ContainsKey("one") || reopened.Get("two") != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two"}, reopened.Keys())
+		}
+	})
+}
+
+func TestUpdate_SMapKeyValue(t *testing.T) {
+	t.Run("test Update commits the changes", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+		kv.Set("one", 1)
+
+		err := kv.Update(func(tx *Tx[string, int]) error {
+			tx.Set("two", tx.Get("one")+1)
+			tx.Delete("one")
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if kv.ContainsKey("one") || kv.Get("two") != 2 {
+			t.Errorf("Expected keys to be %v, got %v", []string{"two"}, kv.Keys())
+		}
+	})
+
+	t.Run("test Update retries on conflict", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+		kv.Set("counter", 0)
+
+		runs := 0
+		err := kv.Update(func(tx *Tx[string, int]) error {
+			runs++
+			if runs == 1 {
+				kv.Set("counter", 10)
+			}
+			return tx.Set("counter", tx.Get("counter")+1)
+		})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if runs != 2 || kv.Get("counter") != 11 {
+			t.Errorf("Expected runs and counter to be %v and %v, got %v and %v", 2, 11, runs, kv.Get("counter"))
+		}
+	})
+
+	t.Run("test Update holds the lock after too many conflicts", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+
+		runs := 0
+		err := kv.Update(func(tx *Tx[string, int]) error {
+			runs++
+			if runs <= maxTxRetries {
+				kv.Set("other", runs)
+			}
+			return tx.Set("key", tx.Get("other"))
+		})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+
+		if runs != maxTxRetries+1 || kv.Get("key") != maxTxRetries {
+			t.Errorf("Expected runs and value to be %v and %v, got %v and %v", maxTxRetries+1, maxTxRetries, runs, kv.Get("key"))
+		}
+	})
+
+	t.Run("test View retries on conflict and holds the lock after too many conflicts", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+
+		runs := 0
+		err := kv.View(func(tx *Tx[string, int]) error {
+			runs++
+			if runs <= maxTxRetries {
+				kv.Set("key", runs)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if runs != maxTxRetries+1 {
+			t.Errorf("Expected runs to be %v, got %v", maxTxRetries+1, runs)
+		}
+	})
+
+	t.Run("test View retries while a write is in progress", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+
+		runs := 0
+		err := kv.View(func(tx *Tx[string, int]) error {
+			runs++
+			if runs == 1 {
+				// the write is started and not finished
+				kv.lockWrite()
+				kv.data().Store("key", new(int))
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					kv.seq.Add(1)
+					kv.unlockWrite()
+				}()
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected error to be %v, got %v", nil, err)
+		}
+		if runs < 2 {
+			t.Errorf("Expected runs to be at least %v, got %v", 2, runs)
+		}
+	})
+
+	t.Run("test concurrent Update don't lose changes", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+		kv.Set("a", 100)
+		kv.Set("b", 0)
+
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 10; i++ {
+					err := kv.Update(func(tx *Tx[string, int]) error {
+						tx.Set("a", tx.Get("a")-1)
+						tx.Set("b", tx.Get("b")+1)
+						return nil
+					})
+					if err != nil {
+						t.Errorf("Expected error to be %v, got %v", nil, err)
+					}
+				}
+			}()
+		}
+
+		for i := 0; i < 100; i++ {
+			err := kv.View(func(tx *Tx[string, int]) error {
+				if sum := tx.Get("a") + tx.Get("b"); sum != 100 {
+					t.Errorf("Expected sum to be %v, got %v", 100, sum)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Expected error to be %v, got %v", nil, err)
+			}
+		}
+		wg.Wait()
+
+		if kv.Get("a") != 60 || kv.Get("b") != 40 {
+			t.Errorf("Expected values to be %v and %v, got %v and %v", 60, 40, kv.Get("a"), kv.Get("b"))
+		}
+	})
+	t.Run("test Get doesn't see part of a commit", func(t *testing.T) {
+		kv := NewSMapKeyValue[string, int]()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 1; i <= 200; i++ {
+				kv.Update(func(tx *Tx[string, int]) error {
+					tx.Set("a", i)
+					// widens the window between the writes of a and b
+					for j := 0; j < 100; j++ {
+						tx.Set(fmt.Sprint(j), i)
+					}
+					return tx.Set("b", i)
+				})
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			// a is stored before b, so b could be older than a only in the middle of a commit
+			a := kv.Get("a")
+			if b := kv.Get("b"); b < a {
+				t.Fatalf("Expected b to be at least %v, got %v", a, b)
+			}
+		}
+	})
+}
@@ -0,0 +1,374 @@
+package r9e
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultWatchBufferSize is the default size of the channel returned by Watch.
+const DefaultWatchBufferSize = 64
+
+// Op is the operation that changed a key-value pair, notified by Watch.
+type Op int
+
+const (
+	// OpSet is notified when a value is set, by Set or SetWithTTL.
+	OpSet Op = iota + 1
+
+	// OpDelete is notified when a key-value pair is deleted, by Delete or GetAnDelete.
+	OpDelete
+
+	// OpClear is notified for each key-value pair removed by Clear.
+	OpClear
+
+	// OpExpire is notified when an expired key-value pair is removed from the container.
+	OpExpire
+
+	// OpEvict is notified when a key-value pair is evicted from a bounded container.
+	OpEvict
+)
+
+// String returns the name of the operation.
+func (o Op) String() string {
+	switch o {
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	case OpClear:
+		return "clear"
+	case OpExpire:
+		return "expire"
+	case OpEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change of a key-value pair notified by Watch.
+type Event[K comparable, T any] struct {
+	Op  Op
+	Key K
+
+	// OldValue is the value before the change, if HasOldValue is true.
+	OldValue    T
+	HasOldValue bool
+
+	// NewValue is the value after the change, only for OpSet.
+	NewValue T
+}
+
+// SlowConsumerPolicy defines what happens when the channel returned by Watch is full.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerDrop discards the events that don't fit in the channel. This is the default policy.
+	SlowConsumerDrop SlowConsumerPolicy = iota
+
+	// SlowConsumerBlock blocks the writers of the container until the event fits in the channel
+	// or the context is done. The goroutine receiving the events must not write to the container,
+	// because the write waits for its events to be delivered, which waits for the receiver: it
+	// deadlocks once the channel is full. The writes can be handed to another goroutine instead.
+	SlowConsumerBlock
+
+	// SlowConsumerDisconnect closes the channel when an event doesn't fit in it.
+	SlowConsumerDisconnect
+)
+
+type watchOptions struct {
+	bufferSize int
+	policy     SlowConsumerPolicy
+}
+
+// WatchOptions are the options for Watch.
+type WatchOptions func(*watchOptions)
+
+// WithWatchBufferSize sets the size of the channel returned by Watch.
+// The default value is DefaultWatchBufferSize.
+func WithWatchBufferSize(size int) WatchOptions {
+	return func(wo *watchOptions) {
+		wo.bufferSize = size
+	}
+}
+
+// WithSlowConsumerPolicy sets what happens when the channel returned by Watch is full.
+// The default value is SlowConsumerDrop.
+func WithSlowConsumerPolicy(policy SlowConsumerPolicy) WatchOptions {
+	return func(wo *watchOptions) {
+		wo.policy = policy
+	}
+}
+
+// watch registers a new watcher in the given hubs and returns its channel.
+// The watcher is removed from the hubs and its channel closed when the context is done.
+func watch[K comparable, T any](ctx context.Context, hubs []*watchHub[K, T], filter func(key K) bool, options ...WatchOptions) <-chan Event[K, T] {
+	wo := watchOptions{
+		bufferSize: DefaultWatchBufferSize,
+	}
+	for _, opt := range options {
+		opt(&wo)
+	}
+	if wo.bufferSize < 0 {
+		wo.bufferSize = 0
+	}
+
+	w := &watcher[K, T]{
+		ch:     make(chan Event[K, T], wo.bufferSize),
+		ctx:    ctx,
+		filter: filter,
+		policy: wo.policy,
+		quit:   make(chan struct{}),
+	}
+
+	for _, h := range hubs {
+		h.add(w)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.quit:
+		}
+
+		for _, h := range hubs {
+			h.remove(w)
+		}
+		w.close()
+	}()
+
+	return w.ch
+}
+
+// watcher is a subscriber of a watchHub.
+type watcher[K comparable, T any] struct {
+	mu     sync.Mutex
+	ch     chan Event[K, T]
+	ctx    context.Context
+	filter func(key K) bool
+	policy SlowConsumerPolicy
+	closed bool
+	quit   chan struct{}
+}
+
+// send delivers the event to the watcher according to its filter and slow consumer policy.
+func (w *watcher[K, T]) send(ev Event[K, T]) {
+	if w.filter != nil && !w.filter(ev.Key) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	switch w.policy {
+	case SlowConsumerBlock:
+		select {
+		case w.ch <- ev:
+		case <-w.ctx.Done():
+		}
+
+	case SlowConsumerDisconnect:
+		select {
+		case w.ch <- ev:
+		default:
+			w.closeLocked()
+		}
+
+	default:
+		select {
+		case w.ch <- ev:
+		default:
+		}
+	}
+}
+
+// close closes the channel of the watcher, only once.
+func (w *watcher[K, T]) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closeLocked()
+}
+
+// closeLocked closes the channel of the watcher. The caller must hold the lock.
+func (w *watcher[K, T]) closeLocked() {
+	if w.closed {
+		return
+	}
+
+	w.closed = true
+	close(w.ch)
+	close(w.quit)
+}
+
+// watchHub delivers the events of a container to its watchers.
+// The events are queued while the container is locked, so they are queued in the same
+// order as the changes, and delivered by flush once the container is unlocked, so the
+// watchers can use the container while they receive the events.
+type watchHub[K comparable, T any] struct {
+	mu       sync.Mutex
+	cond     sync.Cond
+	watchers []*watcher[K, T]
+	queue    []Event[K, T]
+
+	// queued and delivered count the events, so flush waits until its events are delivered.
+	queued      uint64
+	delivered   uint64
+	dispatching bool
+}
+
+// newWatchHub returns a new watchHub.
+func newWatchHub[K comparable, T any]() *watchHub[K, T] {
+	h := &watchHub[K, T]{}
+	h.cond.L = &h.mu
+	return h
+}
+
+// add registers the watcher.
+func (h *watchHub[K, T]) add(w *watcher[K, T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// copy on write, so flush can iterate the watchers without the lock
+	watchers := make([]*watcher[K, T], 0, len(h.watchers)+1)
+	h.watchers = append(append(watchers, h.watchers...), w)
+}
+
+// remove unregisters the watcher.
+func (h *watchHub[K, T]) remove(w *watcher[K, T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	watchers := make([]*watcher[K, T], 0, len(h.watchers))
+	for _, other := range h.watchers {
+		if other != w {
+			watchers = append(watchers, other)
+		}
+	}
+	h.watchers = watchers
+}
+
+// watched returns true if there are watchers. Used to avoid building events nobody receives.
+func (h *watchHub[K, T]) watched() bool {
+	if h == nil {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.watchers) > 0
+}
+
+// publish queues the event if there are watchers.
+func (h *watchHub[K, T]) publish(ev Event[K, T]) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.watchers) == 0 {
+		return
+	}
+
+	h.queue = append(h.queue, ev)
+	h.queued++
+}
+
+// flush delivers the queued events and returns once they are delivered.
+// Only one goroutine delivers at a time, keeping the order of the events.
+func (h *watchHub[K, T]) flush() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	target := h.queued
+	for h.delivered < target {
+		if h.dispatching {
+			h.cond.Wait()
+			continue
+		}
+
+		h.dispatching = true
+		queue, watchers := h.queue, h.watchers
+		h.queue = nil
+		h.mu.Unlock()
+
+		for _, ev := range queue {
+			for _, w := range watchers {
+				w.send(ev)
+			}
+		}
+
+		h.mu.Lock()
+		h.delivered += uint64(len(queue))
+		h.dispatching = false
+		h.cond.Broadcast()
+	}
+}
+
+// loadWatchHub returns the watchHub stored in p, creating it if needed.
+func loadWatchHub[K comparable, T any](p *atomic.Pointer[watchHub[K, T]]) *watchHub[K, T] {
+	if h := p.Load(); h != nil {
+		return h
+	}
+
+	p.CompareAndSwap(nil, newWatchHub[K, T]())
+	return p.Load()
+}
+
+// Watch returns a channel receiving the changes of the key-value pairs accepted by the filter,
+// or all of them if the filter is nil. The channel is closed when the context is done, or when
+// it is full and the slow consumer policy is SlowConsumerDisconnect.
+// The events are delivered in the same order as the changes, once the container is unlocked,
+// so the receiver can use the container, but it must not write to it using SlowConsumerBlock.
+func (r *MapKeyValue[K, T]) Watch(ctx context.Context, filter func(key K) bool, options ...WatchOptions) <-chan Event[K, T] {
+	return watch(ctx, []*watchHub[K, T]{loadWatchHub(&r.hub)}, filter, options...)
+}
+
+// publish queues the event for the watchers.
+// The caller must hold the write lock.
+func (r *MapKeyValue[K, T]) publish(ev Event[K, T]) {
+	r.hub.Load().publish(ev)
+}
+
+// notify delivers the queued events to the watchers.
+// This must be called without holding the lock.
+func (r *MapKeyValue[K, T]) notify() {
+	r.hub.Load().flush()
+}
+
+// Watch returns a channel receiving the changes of the key-value pairs accepted by the filter,
+// or all of them if the filter is nil. The channel is closed when the context is done, or when
+// it is full and the slow consumer policy is SlowConsumerDisconnect.
+// The writers don't hold an exclusive lock, so the events of the same key written concurrently
+// may be delivered in a different order than the changes. The OldValue of each event is the value
+// it replaced, so the order of the changes can be rebuilt from it, and the container holds the
+// NewValue of the last change applied, which is not always the one of the last event delivered.
+// Use MapKeyValue or ShardedMapKeyValue when the events of a key must be delivered in order.
+func (r *SMapKeyValue[K, T]) Watch(ctx context.Context, filter func(key K) bool, options ...WatchOptions) <-chan Event[K, T] {
+	return watch(ctx, []*watchHub[K, T]{loadWatchHub(&r.hub)}, filter, options...)
+}
+
+// Watch returns a channel receiving the changes of the key-value pairs accepted by the filter,
+// or all of them if the filter is nil. The channel is closed when the context is done, or when
+// it is full and the slow consumer policy is SlowConsumerDisconnect.
+// The events of the same key are delivered in order, but the events of keys stored in
+// different shards may be interleaved.
+func (r *ShardedMapKeyValue[K, T]) Watch(ctx context.Context, filter func(key K) bool, options ...WatchOptions) <-chan Event[K, T] {
+	hubs := make([]*watchHub[K, T], len(r.shards))
+	for i, shard := range r.shards {
+		hubs[i] = loadWatchHub(&shard.hub)
+	}
+	return watch(ctx, hubs, filter, options...)
+}
@@ -0,0 +1,341 @@
+package r9e
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receive returns the next event of the channel, failing the test after a timeout.
+func receive[K comparable, T any](t *testing.T, ch <-chan Event[K, T]) Event[K, T] {
+	t.Helper()
+
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatalf("Expected channel to be open")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatalf("Expected an event")
+	}
+	return Event[K, T]{}
+}
+
+func TestWatch_MapKeyValue(t *testing.T) {
+	t.Run("test Watch receives Set, Delete, GetAnDelete and Clear events", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewMapKeyValue[string, int]()
+		ch := kv.Watch(ctx, nil)
+
+		kv.Set("one", 1)
+		kv.Set("one", 11)
+		kv.Set("two", 2)
+		kv.Delete("two")
+		kv.Delete("missing")
+		kv.GetAnDelete("one")
+		kv.Set("three", 3)
+		kv.Clear()
+
+		expected := []Event[string, int]{
+			{Op: OpSet, Key: "one", NewValue: 1},
+			{Op: OpSet, Key: "one", OldValue: 1, HasOldValue: true, NewValue: 11},
+			{Op: OpSet, Key: "two", NewValue: 2},
+			{Op: OpDelete, Key: "two", OldValue: 2, HasOldValue: true},
+			{Op: OpDelete, Key: "one", OldValue: 11, HasOldValue: true},
+			{Op: OpSet, Key: "three", NewValue: 3},
+			{Op: OpClear, Key: "three", OldValue: 3, HasOldValue: true},
+		}
+		for _, want := range expected {
+			if got := receive(t, ch); got != want {
+				t.Errorf("Expected event to be %+v, got %+v", want, got)
+			}
+		}
+	})
+
+	t.Run("test Watch receives expiration and eviction events", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		clock := newFakeClock()
+		kv := NewMapKeyValue[string, int](WithMaxEntries(1))
+		kv.clock = clock.Now
+		defer kv.Close()
+
+		ch := kv.Watch(ctx, nil)
+
+		kv.SetWithTTL("one", 1, time.Second)
+		clock.Advance(2 * time.Second)
+		kv.DeleteExpired()
+		kv.Set("two", 2)
+		kv.Set("three", 3)
+
+		expected := []Event[string, int]{
+			{Op: OpSet, Key: "one", NewValue: 1},
+			{Op: OpExpire, Key: "one", OldValue: 1, HasOldValue: true},
+			{Op: OpSet, Key: "two", NewValue: 2},
+			{Op: OpSet, Key: "three", NewValue: 3},
+			{Op: OpEvict, Key: "two", OldValue: 2, HasOldValue: true},
+		}
+		for _, want := range expected {
+			if got := receive(t, ch); got != want {
+				t.Errorf("Expected event to be %+v, got %+v", want, got)
+			}
+		}
+	})
+
+	t.Run("test Watch with filter", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewMapKeyValue[int, int]()
+		ch := kv.Watch(ctx, func(key int) bool { return key%2 == 0 })
+
+		for i := 0; i < 10; i++ {
+			kv.Set(i, i)
+		}
+
+		for i := 0; i < 10; i += 2 {
+			if ev := receive(t, ch); ev.Key != i {
+				t.Errorf("Expected key to be %v, got %v", i, ev.Key)
+			}
+		}
+	})
+
+	t.Run("test Watch closes the channel when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		kv := NewMapKeyValue[int, int]()
+		ch := kv.Watch(ctx, nil)
+		cancel()
+
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Errorf("Expected channel to be closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected channel to be closed")
+		}
+
+		// the container works without watchers
+		kv.Set(1, 1)
+	})
+
+	t.Run("test Watch receiver can use the container", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewMapKeyValue[int, int]()
+		ch := kv.Watch(ctx, nil, WithWatchBufferSize(0), WithSlowConsumerPolicy(SlowConsumerBlock))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				ev := <-ch
+				if kv.Get(ev.Key) < ev.NewValue {
+					t.Errorf("Expected value to be at least %v, got %v", ev.NewValue, kv.Get(ev.Key))
+				}
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			kv.Set(i%10, i)
+		}
+		<-done
+	})
+
+	for name, policy := range map[string]SlowConsumerPolicy{
+		"SlowConsumerDrop":       SlowConsumerDrop,
+		"SlowConsumerDisconnect": SlowConsumerDisconnect,
+	} {
+		t.Run("test Watch receiver can write to the container with "+name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			kv := NewMapKeyValue[int, int]()
+			ch := kv.Watch(ctx, func(key int) bool { return key >= 0 }, WithWatchBufferSize(1), WithSlowConsumerPolicy(policy))
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for ev := range ch {
+					kv.Set(-ev.Key-1, ev.NewValue)
+				}
+			}()
+
+			withoutDeadlock(t, func() {
+				for i := 0; i < 100; i++ {
+					kv.Set(i, i)
+				}
+			})
+			cancel()
+			<-done
+		})
+	}
+
+	t.Run("test Watch receiver hands the writes to another goroutine with SlowConsumerBlock", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewMapKeyValue[int, int]()
+		ch := kv.Watch(ctx, func(key int) bool { return key >= 0 }, WithWatchBufferSize(1), WithSlowConsumerPolicy(SlowConsumerBlock))
+
+		writes := make(chan Event[int, int], 100)
+		go func() {
+			for ev := range writes {
+				kv.Set(-ev.Key-1, ev.NewValue)
+			}
+		}()
+
+		go func() {
+			defer close(writes)
+			for ev := range ch {
+				writes <- ev
+			}
+		}()
+
+		withoutDeadlock(t, func() {
+			for i := 0; i < 100; i++ {
+				kv.Set(i, i)
+			}
+		})
+		cancel()
+
+		withoutDeadlock(t, func() {
+			for i := 0; i < 100; i++ {
+				for kv.Get(-i-1) != i {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		})
+	})
+}
+
+func TestWatch_SlowConsumerPolicy(t *testing.T) {
+	t.Run("test SlowConsumerDrop discards the events", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewMapKeyValue[int, int]()
+		ch := kv.Watch(ctx, nil, WithWatchBufferSize(2))
+
+		for i := 0; i < 10; i++ {
+			kv.Set(i, i)
+		}
+
+		if len(ch) != 2 {
+			t.Errorf("Expected buffered events to be %v, got %v", 2, len(ch))
+		}
+		if ev := receive(t, ch); ev.Key != 0 {
+			t.Errorf("Expected key to be %v, got %v", 0, ev.Key)
+		}
+	})
+
+	t.Run("test SlowConsumerDisconnect closes the channel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewMapKeyValue[int, int]()
+		ch := kv.Watch(ctx, nil, WithWatchBufferSize(2), WithSlowConsumerPolicy(SlowConsumerDisconnect))
+
+		for i := 0; i < 10; i++ {
+			kv.Set(i, i)
+		}
+
+		count := 0
+		for range ch {
+			count++
+		}
+		if count != 2 {
+			t.Errorf("Expected received events to be %v, got %v", 2, count)
+		}
+	})
+
+	t.Run("test SlowConsumerBlock blocks the writers", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewMapKeyValue[int, int]()
+		ch := kv.Watch(ctx, nil, WithWatchBufferSize(1), WithSlowConsumerPolicy(SlowConsumerBlock))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				kv.Set(i, i)
+			}
+		}()
+
+		for i := 0; i < 10; i++ {
+			if ev := receive(t, ch); ev.Key != i {
+				t.Errorf("Expected key to be %v, got %v", i, ev.Key)
+			}
+		}
+		wg.Wait()
+	})
+}
+
+func TestWatch_SMapKeyValue(t *testing.T) {
+	t.Run("test Watch receives Set, Delete, GetAnDelete and Clear events", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewSMapKeyValue[string, int]()
+		ch := kv.Watch(ctx, nil)
+
+		kv.Set("one", 1)
+		kv.Set("one", 11)
+		kv.Delete("one")
+		kv.Set("two", 2)
+		kv.GetAnDelete("two")
+		kv.Set("three", 3)
+		kv.Clear()
+
+		expected := []Event[string, int]{
+			{Op: OpSet, Key: "one", NewValue: 1},
+			{Op: OpSet, Key: "one", OldValue: 1, HasOldValue: true, NewValue: 11},
+			{Op: OpDelete, Key: "one", OldValue: 11, HasOldValue: true},
+			{Op: OpSet, Key: "two", NewValue: 2},
+			{Op: OpDelete, Key: "two", OldValue: 2, HasOldValue: true},
+			{Op: OpSet, Key: "three", NewValue: 3},
+			{Op: OpClear, Key: "three", OldValue: 3, HasOldValue: true},
+		}
+		for _, want := range expected {
+			if got := receive(t, ch); got != want {
+				t.Errorf("Expected event to be %+v, got %+v", want, got)
+			}
+		}
+	})
+}
+
+func TestWatch_ShardedMapKeyValue(t *testing.T) {
+	t.Run("test Watch receives the events of all the shards", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		kv := NewShardedMapKeyValue[int, int](WithShards(4))
+		ch := kv.Watch(ctx, nil, WithWatchBufferSize(100))
+
+		for i := 0; i < 100; i++ {
+			kv.Set(i, i)
+		}
+
+		seen := make(map[int]bool)
+		for i := 0; i < 100; i++ {
+			seen[receive(t, ch).Key] = true
+		}
+		if len(seen) != 100 {
+			t.Errorf("Expected events to be %v, got %v", 100, len(seen))
+		}
+
+		cancel()
+		for range ch {
+		}
+	})
+}